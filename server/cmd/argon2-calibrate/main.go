@@ -0,0 +1,84 @@
+// server/cmd/argon2-calibrate/main.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"server/pkgs/security"
+)
+
+// Mide el costo de Argon2id en este servidor y sugiere parámetros cuyo hash
+// tarde como máximo la latencia objetivo. Primero fija la memoria (bajándola
+// si ni una iteración cabe en el objetivo) y luego sube las iteraciones.
+func main() {
+	target := flag.Duration("target", 500*time.Millisecond, "latencia objetivo por hash")
+	maxMemory := flag.Int("max-memory", 256*1024, "memoria máxima por hash en KiB")
+	minMemory := flag.Int("min-memory", 19*1024, "memoria mínima aceptable en KiB (OWASP: 19 MiB)")
+	parallelism := flag.Int("parallelism", min(runtime.NumCPU(), 4), "hilos por hash")
+	samples := flag.Int("samples", 3, "mediciones por combinación")
+	flag.Parse()
+
+	params := security.DefaultArgon2Params()
+	params.Memory = uint32(*maxMemory)
+	params.Parallelism = uint8(*parallelism)
+	params.Iterations = 1
+
+	if err := params.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("🔬 Calibrando Argon2id (objetivo %s, %d hilos)\n", *target, params.Parallelism)
+
+	elapsed := measure(params, *samples)
+	for elapsed > *target && params.Memory/2 >= uint32(*minMemory) {
+		params.Memory /= 2
+		elapsed = measure(params, *samples)
+	}
+	if elapsed > *target {
+		fmt.Fprintf(os.Stderr, "⚠️  Ni la memoria mínima cabe en el objetivo (%s con %d KiB); usa estos valores con cuidado\n",
+			elapsed.Round(time.Millisecond), params.Memory)
+	}
+
+	for {
+		next := params
+		next.Iterations++
+		nextElapsed := measure(next, *samples)
+		if nextElapsed > *target {
+			break
+		}
+		params, elapsed = next, nextElapsed
+	}
+
+	fmt.Printf("✅ %d KiB, %d iteraciones, %d hilos → %s por hash\n\n",
+		params.Memory, params.Iterations, params.Parallelism, elapsed.Round(time.Millisecond))
+	fmt.Printf("ARGON2_MEMORY_KB=%d\n", params.Memory)
+	fmt.Printf("ARGON2_ITERATIONS=%d\n", params.Iterations)
+	fmt.Printf("ARGON2_PARALLELISM=%d\n", params.Parallelism)
+}
+
+// measure devuelve el promedio de varias ejecuciones de HashPassword
+func measure(params security.Argon2Params, samples int) time.Duration {
+	if samples < 1 {
+		samples = 1
+	}
+	argon := security.NewArgon2ServiceWithParams(&params)
+
+	var total time.Duration
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		if _, err := argon.HashPassword("calibration-password"); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		total += time.Since(start)
+	}
+
+	avg := total / time.Duration(samples)
+	fmt.Printf("   %7d KiB × %d iteraciones: %s\n", params.Memory, params.Iterations, avg.Round(time.Millisecond))
+	return avg
+}
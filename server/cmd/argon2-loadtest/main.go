@@ -0,0 +1,180 @@
+// server/cmd/argon2-loadtest/main.go
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"server/pkgs/security"
+)
+
+// options son los parámetros de una ráfaga
+type options struct {
+	requests      int
+	maxConcurrent int
+	queueTimeout  time.Duration
+	memory        int
+	iterations    int
+	unbounded     bool
+}
+
+// errRSSExceeded indica que el pico de RSS superó el límite esperado
+var errRSSExceeded = errors.New("el RSS superó el límite esperado")
+
+// Lanza una ráfaga de hashes concurrentes y mide el RSS del proceso para
+// comprobar que el limitador mantiene la memoria acotada. Con -unbounded se
+// repite sin limitador para comparar.
+func main() {
+	var opts options
+	flag.IntVar(&opts.requests, "requests", 64, "hashes simultáneos a lanzar")
+	flag.IntVar(&opts.maxConcurrent, "max-concurrent", runtime.NumCPU(), "cupos del limitador")
+	flag.DurationVar(&opts.queueTimeout, "queue-timeout", 30*time.Second, "espera máxima por un cupo")
+	flag.IntVar(&opts.memory, "memory", 64*1024, "memoria por hash en KiB")
+	flag.IntVar(&opts.iterations, "iterations", 3, "iteraciones por hash")
+	flag.BoolVar(&opts.unbounded, "unbounded", false, "no usar el limitador")
+	flag.Parse()
+
+	if err := run(opts, os.Stdout); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run lanza la ráfaga, imprime el informe en out y devuelve errRSSExceeded
+// si con limitador el pico de RSS pasa del límite esperado
+func run(opts options, out io.Writer) error {
+	params := security.DefaultArgon2Params()
+	params.Memory = uint32(opts.memory)
+	params.Iterations = uint32(opts.iterations)
+	argon := security.NewArgon2ServiceWithParams(&params)
+
+	var limiter *security.HashLimiter
+	if !opts.unbounded {
+		limiter = security.NewHashLimiter(security.HashLimiterConfig{
+			MaxConcurrent: opts.maxConcurrent,
+			QueueTimeout:  opts.queueTimeout,
+		})
+		argon.WithLimiter(limiter)
+	}
+
+	baseline := readRSS()
+	var peak atomic.Int64
+	peak.Store(baseline)
+
+	stop := make(chan struct{})
+	var sampler sync.WaitGroup
+	sampler.Add(1)
+	go func() {
+		defer sampler.Done()
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if rss := readRSS(); rss > peak.Load() {
+					peak.Store(rss)
+				}
+				if limiter != nil {
+					stats := limiter.Stats()
+					fmt.Fprintf(out, "\r   activos=%d en cola=%d completados=%d rechazados=%d   ",
+						stats.Active, stats.Queued, stats.Completed, stats.Rejected)
+				}
+			}
+		}
+	}()
+
+	var ok, busy, failed atomic.Int64
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := argon.HashPassword("load-test-password")
+			switch {
+			case err == nil:
+				ok.Add(1)
+			case errors.Is(err, security.ErrHasherBusy):
+				busy.Add(1)
+			default:
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	sampler.Wait()
+
+	perHash := int64(params.Memory) * 1024
+	fmt.Fprintf(out, "\n\n📊 %d hashes en %s (ok=%d, 503=%d, error=%d)\n",
+		opts.requests, time.Since(start).Round(time.Millisecond), ok.Load(), busy.Load(), failed.Load())
+	fmt.Fprintf(out, "   RSS inicial: %s\n", mib(baseline))
+	fmt.Fprintf(out, "   RSS máximo:  %s\n", mib(peak.Load()))
+
+	if failed.Load() > 0 {
+		return fmt.Errorf("%d hashes fallaron", failed.Load())
+	}
+	if limiter == nil {
+		fmt.Fprintf(out, "   Sin limitador el pico teórico es %s (%d × %s)\n",
+			mib(int64(opts.requests)*perHash), opts.requests, mib(perHash))
+		return nil
+	}
+
+	// Con GOGC=N el heap puede crecer N% sobre lo vivo antes de recolectar,
+	// así que los bloques ya liberados cuentan en el límite. No depende de -requests.
+	gcPercent := debug.SetGCPercent(100)
+	debug.SetGCPercent(gcPercent)
+	live := int64(opts.maxConcurrent) * perHash
+	bound := baseline + live + live*int64(gcPercent)/100
+	fmt.Fprintf(out, "   Límite esperado: %s (%d × %s vivos, GOGC=%d), pico de cola %d\n",
+		mib(bound), opts.maxConcurrent, mib(perHash), gcPercent, limiter.Stats().PeakQueued)
+
+	if peak.Load() > bound+perHash {
+		return fmt.Errorf("%w: pico %s, límite %s", errRSSExceeded, mib(peak.Load()), mib(bound))
+	}
+	fmt.Fprintln(out, "✅ El RSS se mantuvo acotado")
+	return nil
+}
+
+// readRSS lee VmRSS de /proc; fuera de Linux usa la memoria pedida al sistema
+func readRSS() int64 {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		return int64(stats.Sys)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "VmRSS:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			kb, _ := strconv.ParseInt(fields[1], 10, 64)
+			return kb * 1024
+		}
+	}
+	return 0
+}
+
+func mib(bytes int64) string {
+	return fmt.Sprintf("%.0f MiB", float64(bytes)/(1024*1024))
+}
@@ -0,0 +1,29 @@
+//go:build loadtest
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRSSStaysBounded corre una ráfaga reducida con el limitador y falla si el
+// pico de RSS pasa del límite. Necesita memoria y CPU reales, por eso queda
+// fuera de go test ./... y se ejecuta con:
+//
+//	go test -tags loadtest -run TestRSSStaysBounded ./cmd/argon2-loadtest
+func TestRSSStaysBounded(t *testing.T) {
+	var out strings.Builder
+	err := run(options{
+		requests:      32,
+		maxConcurrent: 2,
+		queueTimeout:  2 * time.Minute,
+		memory:        32 * 1024,
+		iterations:    1,
+	}, &out)
+	t.Log(out.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
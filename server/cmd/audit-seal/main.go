@@ -0,0 +1,48 @@
+// server/cmd/audit-seal/main.go
+package main
+
+import (
+	"flag"
+
+	"server/internal/config"
+	"server/internal/database/migrate"
+	"server/internal/models"
+	"server/pkgs/logger"
+
+	"github.com/joho/godotenv"
+)
+
+// Sella en la cadena de hashes las entradas de audit_logs que no la tienen:
+// las anteriores a la cadena o insertadas por fuera del servidor. Sellar las
+// da por legítimas, así que el servidor nunca lo hace solo. Sin -confirm solo
+// lista las pendientes para revisarlas; con -confirm las sella.
+func main() {
+	confirm := flag.Bool("confirm", false, "sellar las entradas pendientes tras revisarlas")
+	flag.Parse()
+
+	logger.InitLogger()
+	_ = godotenv.Load()
+
+	config.LoadConfig()
+	if err := config.ConnectDB(); err != nil {
+		logger.Log.Fatalf("❌ Error conectando a la DB: %v", err)
+	}
+
+	if !*confirm {
+		var pending []models.AuditLog
+		if err := config.DB.Where("sequence IS NULL").Order("created_at ASC, id ASC").Find(&pending).Error; err != nil {
+			logger.Log.Fatalf("❌ Error al leer las entradas sin sellar: %v", err)
+		}
+		for _, entry := range pending {
+			logger.Log.Infof("• %s %s %s %s/%s", entry.ID, entry.CreatedAt.Format("2006-01-02 15:04:05"), entry.Action, entry.EntityType, entry.EntityID)
+		}
+		logger.Log.Infof("🔗 %d entradas sin sellar; revisarlas y ejecutar con -confirm para sellarlas", len(pending))
+		return
+	}
+
+	sealed, err := migrate.SealAuditLog(config.DB)
+	if err != nil {
+		logger.Log.Fatalf("❌ Error al sellar el registro de auditoría: %v", err)
+	}
+	logger.Log.Infof("🔗 %d entradas selladas en la cadena ✅", sealed)
+}
@@ -0,0 +1,62 @@
+// server/cmd/audit-verify/main.go
+package main
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+
+	"server/internal/audit"
+	"server/internal/config"
+	"server/pkgs/logger"
+
+	"github.com/joho/godotenv"
+)
+
+// Recorre la cadena de hashes de audit_logs y reporta cada eslabón roto:
+// filas modificadas, borradas o insertadas por fuera del servidor. Termina
+// con código 1 si la cadena no está íntegra. Para detectar también borrados
+// del final, anotar la cabeza que imprime y pasarla en la siguiente ejecución
+// con -head <secuencia>:<hash>.
+func main() {
+	head := flag.String("head", "", "cabeza anotada en una verificación anterior (<secuencia>:<hash>)")
+	flag.Parse()
+
+	logger.InitLogger()
+	_ = godotenv.Load()
+
+	config.LoadConfig()
+	if err := config.ConnectDB(); err != nil {
+		logger.Log.Fatalf("❌ Error conectando a la DB: %v", err)
+	}
+
+	report, err := audit.Verify(config.DB)
+	if err != nil {
+		logger.Log.Fatalf("❌ Error al verificar el registro de auditoría: %v", err)
+	}
+
+	if *head != "" {
+		seqStr, hash, ok := strings.Cut(*head, ":")
+		seq, err := strconv.ParseInt(seqStr, 10, 64)
+		if !ok || err != nil || seq < 1 {
+			logger.Log.Fatalf("❌ -head inválido: usa <secuencia>:<hash>")
+		}
+		if err := audit.CheckHead(config.DB, report, seq, hash); err != nil {
+			logger.Log.Fatalf("❌ Error al verificar la cabeza anotada: %v", err)
+		}
+	}
+
+	logger.Log.Infof("🔗 %d entradas verificadas; cabeza %d:%s", report.Entries, report.HeadSeq, report.HeadHash)
+	if report.Unsealed > 0 {
+		logger.Log.Errorf("⚠️  %d entradas sin sellar (insertadas por fuera de la cadena); revisarlas con cmd/audit-seal", report.Unsealed)
+	}
+	for _, problem := range report.Problems {
+		logger.Log.Errorf("❌ %s", problem)
+	}
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+	logger.Log.Info("✅ Cadena de auditoría íntegra")
+}
@@ -0,0 +1,42 @@
+// server/cmd/ldap-link/main.go
+package main
+
+import (
+	"flag"
+
+	"server/internal/config"
+	"server/internal/services"
+	"server/pkgs/ldapauth"
+	"server/pkgs/logger"
+
+	"github.com/joho/godotenv"
+)
+
+// Vincula una cuenta local existente con su entrada del directorio LDAP. El
+// inicio de sesión por LDAP no vincula cuentas locales por coincidencia de
+// correo; este paso lo hace un administrador de forma explícita.
+func main() {
+	email := flag.String("email", "", "correo de la cuenta local a vincular")
+	flag.Parse()
+
+	logger.InitLogger()
+	_ = godotenv.Load()
+
+	config.LoadConfig()
+	ldapCfg := config.GetConfig().LDAP
+	if !ldapCfg.Enabled {
+		logger.Log.Fatalf("❌ LDAP no está habilitado (LDAP_ENABLED)")
+	}
+	if *email == "" {
+		logger.Log.Fatalf("❌ Indica la cuenta con -email")
+	}
+	if err := config.ConnectDB(); err != nil {
+		logger.Log.Fatalf("❌ Error conectando a la DB: %v", err)
+	}
+
+	user, err := services.LinkDirectoryUser(config.DB, ldapauth.NewClient(ldapCfg.Directory), *email)
+	if err != nil {
+		logger.Log.Fatalf("❌ No se pudo vincular %s: %v", *email, err)
+	}
+	logger.Log.Infof("🔗 %s vinculado al directorio con rol %s ✅", user.Email, user.Rol)
+}
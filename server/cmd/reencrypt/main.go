@@ -0,0 +1,44 @@
+// server/cmd/reencrypt/main.go
+package main
+
+import (
+	"flag"
+
+	"server/internal/config"
+	"server/internal/database/migrate"
+	"server/pkgs/logger"
+	"server/pkgs/security"
+
+	"github.com/joho/godotenv"
+)
+
+// Re-cifra con la clave activa (la versión más alta de FIELD_ENCRYPTION_KEYS)
+// los tokens de cuentas vinculadas guardados en texto plano, con una clave
+// anterior o sin ligar a su fila. Ejecutar después de agregar una clave nueva
+// y antes de retirar la anterior.
+func main() {
+	dryRun := flag.Bool("dry-run", false, "solo contar las filas pendientes")
+	flag.Parse()
+
+	logger.InitLogger()
+	_ = godotenv.Load()
+
+	config.LoadConfig()
+	if err := security.InitFieldCipher(config.GetConfig().FieldKeys); err != nil {
+		logger.Log.Fatalf("❌ Error al cargar las claves de cifrado: %v", err)
+	}
+	if err := config.ConnectDB(); err != nil {
+		logger.Log.Fatalf("❌ Error conectando a la DB: %v", err)
+	}
+
+	count, err := migrate.ReencryptAccounts(config.DB, *dryRun)
+	if err != nil {
+		logger.Log.Fatalf("❌ Error al re-cifrar cuentas: %v", err)
+	}
+
+	if *dryRun {
+		logger.Log.Infof("🔐 %d cuentas pendientes de re-cifrar con la clave v%d", count, security.Fields.ActiveVersion())
+		return
+	}
+	logger.Log.Infof("🔐 %d cuentas re-cifradas con la clave v%d ✅", count, security.Fields.ActiveVersion())
+}
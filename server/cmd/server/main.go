@@ -3,21 +3,31 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
 	"github.com/joho/godotenv"
 
 	"server/internal/config"
+	"server/internal/database/migrate"
 	"server/internal/database/seed"
 	"server/internal/middlewares"
 	"server/internal/models"
 	"server/internal/routes"
+	"server/pkgs/geoip"
 	"server/pkgs/logger"
+	"server/pkgs/mailer"
+	"server/pkgs/security"
+	"server/pkgs/storage"
 	"server/pkgs/validator"
 )
 
@@ -33,6 +43,12 @@ func main() {
 
 	// Cargar configuración
 	config.LoadConfig()
+	if err := config.GetConfig().Argon2.Validate(); err != nil {
+		logger.Log.Fatalf("❌ Parámetros Argon2 inválidos: %v", err)
+	}
+	if _, err := config.GetConfig().PasswordReset.Key(); err != nil {
+		logger.Log.Fatalf("❌ Clave de recuperación de contraseña inválida: %v", err)
+	}
 
 	// Conectar base de datos y manejar tablas
 	wasCreated, wasReset, err := initDatabase()
@@ -54,6 +70,32 @@ func main() {
 		logger.Log.Fatalf("❌ Error al inicializar el validador: %v", err)
 	}
 
+	// Inicializar correo saliente
+	if err := mailer.InitMailer(config.GetConfig().Mail); err != nil {
+		logger.Log.Fatalf("❌ Error al inicializar el servicio de correo: %v", err)
+	}
+
+	// Inicializar almacenamiento de archivos
+	if err := storage.InitStorage(config.GetConfig().Storage); err != nil {
+		logger.Log.Fatalf("❌ Error al inicializar el almacenamiento: %v", err)
+	}
+
+	// Cargar base de geolocalización de IP (opcional)
+	if err := geoip.InitGeoIP(config.GetConfig().GeoIP); err != nil {
+		logger.Log.Fatalf("❌ Error al cargar la base GeoIP: %v", err)
+	}
+
+	// Cargar claves de firma de tokens
+	if err := security.InitKeys(config.GetConfig().JWTKeys); err != nil {
+		logger.Log.Fatalf("❌ Error al cargar las claves JWT: %v", err)
+	}
+	logger.Log.Infof("🔑 Firmando tokens con la clave %s", security.Keys.ActiveKeyID())
+
+	// Cargar claves de cifrado de columnas sensibles
+	if err := security.InitFieldCipher(config.GetConfig().FieldKeys); err != nil {
+		logger.Log.Fatalf("❌ Error al cargar las claves de cifrado: %v", err)
+	}
+
 	// Crear instancia Fiber
 	app := fiber.New(fiber.Config{
 		AppName:      "Inventario Server",
@@ -61,6 +103,8 @@ func main() {
 	})
 
 	// Middlewares globales
+	app.Use(requestid.New())
+	app.Use(middlewares.AuditContext())
 	app.Use(middlewares.CORSMiddleware())
 	app.Use(middlewares.LoggerMiddleware())
 
@@ -84,10 +128,51 @@ func main() {
 		port = "8080"
 	}
 
-	logger.Log.Infof("✅ Servidor escuchando en http://localhost:%s", port)
-	if err := app.Listen(":" + port); err != nil {
-		logger.Log.Fatalf("❌ Error al iniciar el servidor: %v", err)
+	// Escuchar en segundo plano para poder apagar ordenadamente con SIGINT/SIGTERM
+	listenErr := make(chan error, 1)
+	go func() {
+		logger.Log.Infof("✅ Servidor escuchando en http://localhost:%s", port)
+		listenErr <- app.Listen(":" + port)
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-listenErr:
+		if err != nil {
+			logger.Log.Fatalf("❌ Error al iniciar el servidor: %v", err)
+		}
+	case sig := <-stop:
+		logger.Log.Infof("🛑 Señal %s recibida, apagando servidor...", sig)
+	}
+
+	shutdown(app)
+}
+
+// shutdownTimeout es lo que se espera a las peticiones en curso y, aparte, a
+// que salgan los correos en cola
+const shutdownTimeout = 15 * time.Second
+
+// shutdown deja de aceptar conexiones, espera las peticiones en curso y
+// libera los recursos globales
+func shutdown(app *fiber.App) {
+	if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+		logger.Log.Errorf("❌ Error al detener el servidor HTTP: %v", err)
 	}
+
+	// Los correos encolados (códigos, avisos) se envían antes de salir
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	mailer.Mail.Stop(ctx)
+
+	if err := geoip.Geo.Close(); err != nil {
+		logger.Log.Errorf("❌ Error al cerrar la base GeoIP: %v", err)
+	}
+	if sqlDB, err := config.DB.DB(); err == nil {
+		sqlDB.Close()
+	}
+	logger.Log.Info("👋 Servidor detenido")
 }
 
 // initDatabase crea DB si no existe, migra y retorna si fue recién creada o reseteada
@@ -140,11 +225,27 @@ func initDatabase() (wasCreated bool, wasReset bool, err error) {
 		if reset {
 			err = config.DB.Migrator().DropTable(
 				&models.Asset{},
+				"api_key_permissions",
+				&models.APIKey{},
+				&models.OfficeMembership{},
+				&models.OffboardingRecord{},
+				&models.AuditLog{},
+				&models.PasswordHistory{},
+				&models.Invitation{},
+				&models.SecurityEvent{},
+				&models.AuthThrottle{},
+				&models.TwoFactorPolicy{},
+				&models.RecoveryCode{},
+				&models.OAuthState{},
 				&models.PasswordResetToken{},
 				&models.VerificationToken{},
 				&models.Session{},
 				&models.Account{},
 				&models.User{},
+				&models.Office{},
+				"role_permissions",
+				&models.Role{},
+				&models.Permission{},
 			)
 			if err != nil {
 				return wasCreated, false, fmt.Errorf("error eliminando tablas: %w", err)
@@ -156,16 +257,45 @@ func initDatabase() (wasCreated bool, wasReset bool, err error) {
 
 	// Migrar tablas
 	err = config.DB.AutoMigrate(
+		&models.Permission{},
+		&models.Role{},
+		&models.Office{},
 		&models.User{},
 		&models.Account{},
 		&models.Session{},
 		&models.VerificationToken{},
 		&models.PasswordResetToken{},
+		&models.OAuthState{},
+		&models.RecoveryCode{},
+		&models.TwoFactorPolicy{},
+		&models.AuthThrottle{},
+		&models.SecurityEvent{},
+		&models.Invitation{},
+		&models.PasswordHistory{},
+		&models.AuditLog{},
+		&models.OffboardingRecord{},
+		&models.OfficeMembership{},
+		&models.APIKey{},
 		&models.Asset{},
 	)
 	if err != nil {
 		return wasCreated, wasReset, fmt.Errorf("error migrando tablas: %w", err)
 	}
+	if err := migrate.ProtectAuditLog(config.DB); err != nil {
+		return wasCreated, wasReset, fmt.Errorf("error protegiendo el registro de auditoría: %w", err)
+	}
+	if err := migrate.NormalizeUserEmails(config.DB); err != nil {
+		return wasCreated, wasReset, fmt.Errorf("error normalizando correos: %w", err)
+	}
+	if err := migrate.ConvertOfficeEnum(config.DB); err != nil {
+		return wasCreated, wasReset, fmt.Errorf("error convirtiendo oficinas: %w", err)
+	}
+	if err := migrate.SeedRoles(config.DB); err != nil {
+		return wasCreated, wasReset, fmt.Errorf("error creando roles y permisos: %w", err)
+	}
+	if err := migrate.ProtectSecurityEvents(config.DB); err != nil {
+		return wasCreated, wasReset, fmt.Errorf("error protegiendo eventos de seguridad: %w", err)
+	}
 
 	logger.Log.Info("✅ Tablas migradas correctamente")
 	return wasCreated, wasReset, nil
@@ -0,0 +1,202 @@
+// server/internal/audit/audit.go
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+	"server/pkgs/security"
+)
+
+// chainLockKey identifica el advisory lock que serializa las inserciones en
+// la cadena; cualquier valor fijo sirve mientras nadie más lo use
+const chainLockKey = 0x61756469746c6f67
+
+// ErrChainKeyMissing indica que no se cargó la clave de la cadena (Register)
+var ErrChainKeyMissing = errors.New("audit: falta la clave HMAC de la cadena")
+
+// chainKey firma cada eslabón. Sin ella, quien pueda escribir en la base no
+// puede recalcular hashes válidos tras alterar una fila.
+var chainKey security.SecretKey
+
+// Meta identifica a quién ejecuta un cambio y desde dónde. Bajo
+// suplantación, ActorID es el usuario suplantado e ImpersonatorID el real.
+type Meta struct {
+	ActorID        string
+	ImpersonatorID string
+	IP             string
+	RequestID      string
+}
+
+type metaKey struct{}
+
+// WithMeta adjunta el autor del cambio al contexto. Los cambios hechos con
+// db.WithContext(ctx) quedan registrados a su nombre.
+func WithMeta(ctx context.Context, meta Meta) context.Context {
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+// MetaFrom devuelve el autor adjuntado con WithMeta; vacío si no hay
+func MetaFrom(ctx context.Context) Meta {
+	if ctx == nil {
+		return Meta{}
+	}
+	meta, _ := ctx.Value(metaKey{}).(Meta)
+	return meta
+}
+
+// Append agrega la entrada al final de la cadena: le asigna secuencia, el
+// hash de la anterior y su propio hash. Si db es una transacción, la entrada
+// se guarda o se descarta junto con el cambio que describe; el lock de la
+// cadena se mantiene hasta que esa transacción termina.
+func Append(db *gorm.DB, entry *models.AuditLog) error {
+	if len(chainKey) == 0 {
+		return ErrChainKeyMissing
+	}
+	return db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", chainLockKey).Error; err != nil {
+			return err
+		}
+
+		var last models.AuditLog
+		if err := tx.Select("sequence", "hash").
+			Where("sequence IS NOT NULL").
+			Order("sequence DESC").Limit(1).
+			Find(&last).Error; err != nil {
+			return err
+		}
+
+		seal(entry, &last)
+		return tx.Create(entry).Error
+	})
+}
+
+// SealPending encadena, en orden de creación, las entradas que aún no tienen
+// secuencia: las anteriores a la cadena o insertadas por fuera de Append.
+// Sellarlas las da por legítimas, por eso solo se ejecuta a pedido (ver
+// cmd/audit-seal). Devuelve cuántas selló.
+func SealPending(db *gorm.DB) (int, error) {
+	if len(chainKey) == 0 {
+		return 0, ErrChainKeyMissing
+	}
+	sealed := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", chainLockKey).Error; err != nil {
+			return err
+		}
+
+		var last models.AuditLog
+		if err := tx.Select("sequence", "hash").
+			Where("sequence IS NOT NULL").
+			Order("sequence DESC").Limit(1).
+			Find(&last).Error; err != nil {
+			return err
+		}
+
+		var pending []models.AuditLog
+		if err := tx.Where("sequence IS NULL").Order("created_at ASC, id ASC").Find(&pending).Error; err != nil {
+			return err
+		}
+
+		previous := &last
+		for i := range pending {
+			entry := &pending[i]
+			seal(entry, previous)
+			if err := tx.Model(&models.AuditLog{}).Where("id = ?", entry.ID).Updates(map[string]interface{}{
+				"sequence":  entry.Sequence,
+				"prev_hash": entry.PrevHash,
+				"hash":      entry.Hash,
+			}).Error; err != nil {
+				return err
+			}
+			previous = entry
+			sealed++
+		}
+		return nil
+	})
+	return sealed, err
+}
+
+// seal encadena la entrada a la anterior (nil o sin secuencia si es la primera)
+func seal(entry, previous *models.AuditLog) {
+	sequence := int64(1)
+	prevHash := ""
+	if previous != nil && previous.Sequence != nil {
+		sequence = *previous.Sequence + 1
+		prevHash = previous.Hash
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	// Postgres guarda microsegundos; el hash debe poder recalcularse
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+	entry.Sequence = &sequence
+	entry.PrevHash = prevHash
+	entry.Hash = Hash(entry)
+}
+
+// Hash calcula el HMAC de la entrada sobre todos sus campos y el hash de la
+// anterior. Cualquier cambio en la fila o en el orden rompe la cadena.
+func Hash(entry *models.AuditLog) string {
+	payload, _ := json.Marshal(struct {
+		Sequence       *int64  `json:"seq"`
+		PrevHash       string  `json:"prev"`
+		CreatedAt      string  `json:"at"`
+		ActorID        *string `json:"actor"`
+		ImpersonatorID *string `json:"impersonator"`
+		Action         string  `json:"action"`
+		EntityType     string  `json:"entityType"`
+		EntityID       string  `json:"entityId"`
+		Changes        *string `json:"changes"`
+		Before         *string `json:"before"`
+		After          *string `json:"after"`
+		IP             *string `json:"ip"`
+		RequestID      *string `json:"requestId"`
+	}{
+		Sequence:       entry.Sequence,
+		PrevHash:       entry.PrevHash,
+		CreatedAt:      entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		ActorID:        entry.ActorID,
+		ImpersonatorID: entry.ImpersonatorID,
+		Action:         entry.Action,
+		EntityType:     entry.EntityType,
+		EntityID:       entry.EntityID,
+		Changes:        entry.Changes,
+		Before:         entry.Before,
+		After:          entry.After,
+		IP:             entry.IP,
+		RequestID:      entry.RequestID,
+	})
+	return chainKey.Sum(string(payload))
+}
+
+// NewEntry arma una entrada con los datos del autor
+func NewEntry(meta Meta, action, entityType, entityID string) *models.AuditLog {
+	return &models.AuditLog{
+		ActorID:        optional(meta.ActorID),
+		ImpersonatorID: optional(meta.ImpersonatorID),
+		Action:         action,
+		EntityType:     entityType,
+		EntityID:       entityID,
+		IP:             optional(meta.IP),
+		RequestID:      optional(truncate(meta.RequestID, 64)),
+	}
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"server/internal/models"
+	"server/pkgs/security"
+)
+
+func withChainKey(t *testing.T, key string) {
+	t.Helper()
+	previous := chainKey
+	t.Cleanup(func() { chainKey = previous })
+	chainKey = security.SecretKey(key)
+}
+
+func sealedEntry() *models.AuditLog {
+	entry := NewEntry(Meta{ActorID: "actor", IP: "10.0.0.1", RequestID: "req-1"}, ActionUpdate, "user", "42")
+	entry.CreatedAt = time.Date(2026, 10, 1, 12, 0, 0, 123456789, time.UTC)
+	seal(entry, nil)
+	return entry
+}
+
+func TestHashDependsOnChainKey(t *testing.T) {
+	withChainKey(t, "clave-de-prueba-a-32-bytes-minimo")
+	entry := sealedEntry()
+	if Hash(entry) != entry.Hash {
+		t.Fatal("el hash debe poder recalcularse con la misma clave")
+	}
+
+	// Sin la clave no se puede reconstruir un eslabón válido
+	withChainKey(t, "otra-clave-de-prueba-de-32-bytes")
+	if Hash(entry) == entry.Hash {
+		t.Fatal("con otra clave el hash no debe coincidir")
+	}
+}
+
+func TestSealChainsToPrevious(t *testing.T) {
+	withChainKey(t, "clave-de-prueba-a-32-bytes-minimo")
+	first := sealedEntry()
+
+	second := NewEntry(Meta{ActorID: "actor"}, ActionDelete, "user", "42")
+	seal(second, first)
+	if *second.Sequence != *first.Sequence+1 || second.PrevHash != first.Hash {
+		t.Fatalf("el eslabón no apunta al anterior: seq=%d prev=%q", *second.Sequence, second.PrevHash)
+	}
+
+	first.Action = ActionCreate
+	if Hash(first) == first.Hash {
+		t.Fatal("modificar la fila debe romper su hash")
+	}
+}
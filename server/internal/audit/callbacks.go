@@ -0,0 +1,341 @@
+// server/internal/audit/callbacks.go
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"server/pkgs/security"
+)
+
+// Acciones que registran los callbacks sobre cada fila
+const (
+	ActionCreate = "CREATE"
+	ActionUpsert = "UPSERT"
+	ActionUpdate = "UPDATE"
+	ActionDelete = "DELETE"
+)
+
+const beforeKey = "audit:before"
+
+// excludedTables no se auditan: son los propios registros, o estado efímero
+// de autenticación que cambia en cada request
+var excludedTables = map[string]bool{
+	"audit_logs":            true,
+	"security_events":       true,
+	"sessions":              true,
+	"auth_throttles":        true,
+	"oauth_states":          true,
+	"verification_tokens":   true,
+	"password_reset_tokens": true,
+	"recovery_codes":        true,
+}
+
+// redactedColumns guardan secretos o sus hashes; se registra que cambiaron
+// pero no su valor
+var redactedColumns = map[string]bool{
+	"password":          true,
+	"two_factor_secret": true,
+	"key_hash":          true,
+	"token_hash":        true,
+	"code_hash":         true,
+	"hash":              true,
+	"refresh_token":     true,
+	"access_token":      true,
+	"id_token":          true,
+	"session_token":     true,
+	"token":             true,
+}
+
+const redacted = "[REDACTED]"
+
+// ignoredColumns cambian con el uso normal (último acceso, marcas de tiempo);
+// una actualización que solo toca estas columnas no genera registro
+var ignoredColumns = map[string]bool{
+	"updated_at":           true,
+	"last_login":           true,
+	"last_ip":              true,
+	"last_device":          true,
+	"last_os":              true,
+	"last_location":        true,
+	"last_country":         true,
+	"two_factor_last_step": true,
+	"last_used_at":         true,
+	"last_used_ip":         true,
+}
+
+// Register engancha la auditoría a los create, update y delete de GORM. Cada
+// fila afectada genera una entrada con su estado antes y después, dentro de
+// la misma transacción del cambio. El autor se toma del contexto (WithMeta).
+// key firma la cadena y también se usa al verificarla.
+func Register(db *gorm.DB, key security.SecretKey) error {
+	if len(key) == 0 {
+		return ErrChainKeyMissing
+	}
+	chainKey = key
+
+	cb := db.Callback()
+	steps := []error{
+		cb.Create().After("gorm:after_create").Before("gorm:commit_or_rollback_transaction").
+			Register("audit:after_create", afterCreate),
+		cb.Update().After("gorm:before_update").Before("gorm:update").Register("audit:before_update", snapshotBefore),
+		cb.Update().After("gorm:after_update").Before("gorm:commit_or_rollback_transaction").
+			Register("audit:after_update", afterUpdate),
+		cb.Delete().After("gorm:before_delete").Before("gorm:delete").Register("audit:before_delete", snapshotBefore),
+		cb.Delete().After("gorm:after_delete").Before("gorm:commit_or_rollback_transaction").
+			Register("audit:after_delete", afterDelete),
+	}
+	for _, err := range steps {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func audited(db *gorm.DB) bool {
+	stmt := db.Statement
+	return db.Error == nil && !db.DryRun && stmt.Schema != nil &&
+		len(stmt.Schema.PrimaryFields) > 0 && !excludedTables[stmt.Table]
+}
+
+func afterCreate(db *gorm.DB) {
+	if !audited(db) || db.RowsAffected == 0 {
+		return
+	}
+	action := ActionCreate
+	if _, ok := db.Statement.Clauses["ON CONFLICT"]; ok {
+		action = ActionUpsert
+	}
+
+	_, keys := schema.GetIdentityFieldValuesMap(db.Statement.Context, db.Statement.ReflectValue, db.Statement.Schema.PrimaryFields)
+	after, err := fetchByKeys(db, keys)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	for _, key := range sortedKeys(after) {
+		db.AddError(appendRow(db, action, key, nil, after[key]))
+	}
+}
+
+// snapshotBefore guarda las filas que el update o delete va a tocar. Aplica
+// las mismas condiciones que GORM: el WHERE explícito y la clave primaria
+// del modelo si viene cargada.
+func snapshotBefore(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+	stmt := db.Statement
+
+	var conds []clause.Expression
+	if where, ok := stmt.Clauses["WHERE"].Expression.(clause.Where); ok {
+		conds = append(conds, where.Exprs...)
+	}
+	conds = append(conds, keyConditions(stmt, stmt.ReflectValue)...)
+	if stmt.Model != nil && stmt.Dest != stmt.Model {
+		conds = append(conds, keyConditions(stmt, reflect.ValueOf(stmt.Model))...)
+	}
+	if len(conds) == 0 {
+		// Sin condiciones GORM rechaza el cambio salvo AllowGlobalUpdate
+		if !db.AllowGlobalUpdate {
+			return
+		}
+	}
+
+	before, err := fetch(db, conds)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	db.InstanceSet(beforeKey, before)
+}
+
+func afterUpdate(db *gorm.DB) {
+	before, ok := takeBefore(db)
+	if !ok {
+		return
+	}
+	var keys [][]interface{}
+	for _, doc := range before {
+		keys = append(keys, doc.key)
+	}
+	after, err := fetchByKeys(db, keys)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+
+	for _, key := range sortedKeys(before) {
+		prev, next := before[key], after[key]
+		if next == nil || !changed(prev, next) {
+			continue
+		}
+		db.AddError(appendRow(db, ActionUpdate, key, prev, next))
+	}
+}
+
+func afterDelete(db *gorm.DB) {
+	before, ok := takeBefore(db)
+	if !ok {
+		return
+	}
+	var keys [][]interface{}
+	for _, doc := range before {
+		keys = append(keys, doc.key)
+	}
+	// Con borrado lógico la fila sigue existiendo: se registra como quedó
+	after, err := fetchByKeys(db, keys)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	for _, key := range sortedKeys(before) {
+		db.AddError(appendRow(db, ActionDelete, key, before[key], after[key]))
+	}
+}
+
+func takeBefore(db *gorm.DB) (map[string]*rowDoc, bool) {
+	value, ok := db.InstanceGet(beforeKey)
+	if !ok || db.Error != nil || db.RowsAffected == 0 {
+		return nil, false
+	}
+	before, _ := value.(map[string]*rowDoc)
+	return before, len(before) > 0
+}
+
+// rowDoc es una fila leída como JSON junto con su clave primaria
+type rowDoc struct {
+	key    []interface{}
+	fields map[string]interface{}
+}
+
+func keyConditions(stmt *gorm.Statement, value reflect.Value) []clause.Expression {
+	if !value.IsValid() {
+		return nil
+	}
+	_, keys := schema.GetIdentityFieldValuesMap(stmt.Context, reflect.Indirect(value), stmt.Schema.PrimaryFields)
+	if len(keys) == 0 {
+		return nil
+	}
+	column, values := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, keys)
+	return []clause.Expression{clause.IN{Column: column, Values: values}}
+}
+
+func fetchByKeys(db *gorm.DB, keys [][]interface{}) (map[string]*rowDoc, error) {
+	if len(keys) == 0 {
+		return map[string]*rowDoc{}, nil
+	}
+	stmt := db.Statement
+	column, values := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, keys)
+	return fetch(db, []clause.Expression{clause.IN{Column: column, Values: values}})
+}
+
+// fetch lee las filas como JSON con to_jsonb, en la misma conexión (y por
+// tanto la misma transacción) que el cambio auditado
+func fetch(db *gorm.DB, conds []clause.Expression) (map[string]*rowDoc, error) {
+	stmt := db.Statement
+	model := reflect.New(stmt.Schema.ModelType).Interface()
+
+	query := db.Session(&gorm.Session{NewDB: true}).Model(model).Table(stmt.Table).
+		Select("to_jsonb(" + stmt.Quote(stmt.Table) + ")::text")
+	if len(conds) > 0 {
+		query = query.Clauses(clause.Where{Exprs: conds})
+	}
+
+	var raws []string
+	if err := query.Scan(&raws).Error; err != nil {
+		return nil, err
+	}
+
+	docs := make(map[string]*rowDoc, len(raws))
+	for _, raw := range raws {
+		fields := map[string]interface{}{}
+		decoder := json.NewDecoder(strings.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&fields); err != nil {
+			return nil, err
+		}
+		doc := &rowDoc{fields: fields}
+		for _, name := range stmt.Schema.PrimaryFieldDBNames {
+			doc.key = append(doc.key, keyValue(fields[name]))
+		}
+		docs[entityID(doc.key)] = doc
+	}
+	return docs, nil
+}
+
+func appendRow(db *gorm.DB, action, id string, before, after *rowDoc) error {
+	entry := NewEntry(MetaFrom(db.Statement.Context), action, db.Statement.Table, id)
+	entry.Before = document(before)
+	entry.After = document(after)
+	return Append(db, entry)
+}
+
+// changed compara las filas sin las columnas de uso normal
+func changed(before, after *rowDoc) bool {
+	for name, value := range after.fields {
+		if ignoredColumns[name] {
+			continue
+		}
+		if !reflect.DeepEqual(before.fields[name], value) {
+			return true
+		}
+	}
+	return false
+}
+
+// document serializa la fila ocultando los secretos; json.Marshal ordena las
+// claves, así el texto guardado es estable
+func document(doc *rowDoc) *string {
+	if doc == nil {
+		return nil
+	}
+	fields := make(map[string]interface{}, len(doc.fields))
+	for name, value := range doc.fields {
+		if redactedColumns[name] && value != nil {
+			value = redacted
+		}
+		fields[name] = value
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+	str := string(raw)
+	return &str
+}
+
+// keyValue devuelve las claves numéricas como enteros para volver a
+// consultarlas; las demás llegan como texto
+func keyValue(value interface{}) interface{} {
+	if number, ok := value.(json.Number); ok {
+		if n, err := number.Int64(); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+func entityID(key []interface{}) string {
+	parts := make([]string, len(key))
+	for i, value := range key {
+		parts[i] = fmt.Sprint(value)
+	}
+	return strings.Join(parts, ":")
+}
+
+func sortedKeys(docs map[string]*rowDoc) []string {
+	keys := make([]string, 0, len(docs))
+	for key := range docs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
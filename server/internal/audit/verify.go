@@ -0,0 +1,111 @@
+// server/internal/audit/verify.go
+package audit
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+const verifyBatchSize = 1000
+
+// Problem describe un eslabón roto de la cadena
+type Problem struct {
+	Sequence int64
+	EntryID  string
+	Reason   string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("#%d (%s): %s", p.Sequence, p.EntryID, p.Reason)
+}
+
+// Report es el resultado de recorrer la cadena completa
+type Report struct {
+	Entries  int64
+	HeadSeq  int64
+	HeadHash string
+	Unsealed int64
+	Problems []Problem
+}
+
+// OK indica que la cadena está íntegra
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0 && r.Unsealed == 0
+}
+
+// Verify recorre la cadena en orden y recalcula cada hash. Detecta filas
+// modificadas, borradas (huecos en la secuencia), insertadas fuera de la
+// cadena o reordenadas. Un borrado del final solo se detecta comparando
+// HeadSeq y HeadHash con un valor anotado antes (ver CheckHead).
+func Verify(db *gorm.DB) (*Report, error) {
+	if len(chainKey) == 0 {
+		return nil, ErrChainKeyMissing
+	}
+	report := &Report{}
+
+	if err := db.Model(&models.AuditLog{}).Where("sequence IS NULL").Count(&report.Unsealed).Error; err != nil {
+		return nil, err
+	}
+
+	var last int64
+	prevHash := ""
+	for {
+		var batch []models.AuditLog
+		if err := db.Where("sequence > ?", last).
+			Order("sequence ASC").Limit(verifyBatchSize).
+			Find(&batch).Error; err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for i := range batch {
+			entry := &batch[i]
+			seq := *entry.Sequence
+
+			if seq != last+1 {
+				report.Problems = append(report.Problems, Problem{Sequence: seq, EntryID: entry.ID,
+					Reason: fmt.Sprintf("faltan las entradas %d a %d", last+1, seq-1)})
+			}
+			if entry.PrevHash != prevHash {
+				report.Problems = append(report.Problems, Problem{Sequence: seq, EntryID: entry.ID,
+					Reason: "el hash anterior no coincide con la entrada previa"})
+			}
+			if Hash(entry) != entry.Hash {
+				report.Problems = append(report.Problems, Problem{Sequence: seq, EntryID: entry.ID,
+					Reason: "el contenido no coincide con su hash (fila modificada)"})
+			}
+
+			last = seq
+			prevHash = entry.Hash
+			report.Entries++
+		}
+	}
+
+	report.HeadSeq = last
+	report.HeadHash = prevHash
+	return report, nil
+}
+
+// CheckHead confirma que la entrada anotada como cabeza en una verificación
+// anterior sigue en la cadena con el mismo hash
+func CheckHead(db *gorm.DB, report *Report, seq int64, hash string) error {
+	if seq > report.HeadSeq {
+		report.Problems = append(report.Problems, Problem{Sequence: seq,
+			Reason: fmt.Sprintf("la cadena termina en %d: se borraron entradas del final", report.HeadSeq)})
+		return nil
+	}
+
+	var entry models.AuditLog
+	if err := db.Where("sequence = ?", seq).Limit(1).Find(&entry).Error; err != nil {
+		return err
+	}
+	if entry.Hash != hash {
+		report.Problems = append(report.Problems, Problem{Sequence: seq, EntryID: entry.ID,
+			Reason: "el hash no coincide con la cabeza anotada"})
+	}
+	return nil
+}
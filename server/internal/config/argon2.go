@@ -0,0 +1,30 @@
+package config
+
+import (
+	"runtime"
+	"time"
+
+	"server/pkgs/security"
+)
+
+// loadArgon2Params lee los parámetros de hash; por defecto los de OWASP.
+// Usa cmd/argon2-calibrate para obtener valores adecuados al servidor.
+func loadArgon2Params() security.Argon2Params {
+	defaults := security.DefaultArgon2Params()
+	return security.Argon2Params{
+		Memory:      uint32(getEnvInt("ARGON2_MEMORY_KB", int(defaults.Memory))),
+		Iterations:  uint32(getEnvInt("ARGON2_ITERATIONS", int(defaults.Iterations))),
+		Parallelism: uint8(getEnvInt("ARGON2_PARALLELISM", int(defaults.Parallelism))),
+		SaltLength:  uint32(getEnvInt("ARGON2_SALT_LENGTH", int(defaults.SaltLength))),
+		KeyLength:   uint32(getEnvInt("ARGON2_KEY_LENGTH", int(defaults.KeyLength))),
+	}
+}
+
+// loadArgon2Limiter define cuántos hashes se calculan a la vez. Cada hash
+// reserva ARGON2_MEMORY_KB, así que el pico es MAX_CONCURRENT × memoria.
+func loadArgon2Limiter() security.HashLimiterConfig {
+	return security.HashLimiterConfig{
+		MaxConcurrent: getEnvInt("ARGON2_MAX_CONCURRENT", runtime.NumCPU()),
+		QueueTimeout:  getEnvDuration("ARGON2_QUEUE_TIMEOUT", 5*time.Second),
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+
+	"server/pkgs/security"
+)
+
+// AuditConfig define la clave HMAC que encadena el registro de auditoría.
+// Vive fuera de la base: quien pueda escribir en audit_logs no puede
+// recalcular la cadena sin ella.
+type AuditConfig struct {
+	HMACKey string
+}
+
+func loadAuditConfig() AuditConfig {
+	return AuditConfig{
+		HMACKey: getEnv("AUDIT_HMAC_KEY", ""),
+	}
+}
+
+// Key decodifica AUDIT_HMAC_KEY; sin ella no se abre la base
+func (c AuditConfig) Key() (security.SecretKey, error) {
+	key, err := security.ParseSecretKey(c.HMACKey)
+	if err != nil {
+		return nil, fmt.Errorf("AUDIT_HMAC_KEY: %w", err)
+	}
+	return key, nil
+}
@@ -2,7 +2,15 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"sync"
+	"time"
+
+	"server/pkgs/geoip"
+	"server/pkgs/mailer"
+	"server/pkgs/oidc"
+	"server/pkgs/security"
+	"server/pkgs/storage"
 )
 
 // AppConfig contiene toda la configuración del entorno.
@@ -14,6 +22,58 @@ type AppConfig struct {
 	DBPassword string
 	DBName     string
 	DBSSLMode  string
+
+	// Proveedores OpenID Connect habilitados (Keycloak, Entra, ...)
+	OIDCProviders []oidc.Config
+
+	// Autenticación contra Active Directory / LDAP
+	LDAP LDAPConfig
+
+	// Protección contra fuerza bruta
+	Throttle ThrottleConfig
+
+	// Recuperación de contraseña
+	PasswordReset PasswordResetConfig
+
+	// Clave de la cadena del registro de auditoría
+	Audit AuditConfig
+
+	// Sesiones emitidas por el servidor y claves con las que se firman
+	JWTKeys    security.KeyConfig
+	SessionTTL time.Duration
+	TOTPIssuer string
+
+	// Claves de cifrado de columnas sensibles
+	FieldKeys security.FieldKeyConfig
+
+	// Correo saliente
+	Mail mailer.Config
+
+	// Verificación de correo
+	Verification VerificationConfig
+
+	// Vigencia del enlace de invitación
+	InvitationTTL time.Duration
+
+	// Duración máxima de una sesión de suplantación
+	ImpersonationTTL time.Duration
+
+	// Vigencia por defecto de las API keys (0 = sin expiración)
+	APIKeyTTL time.Duration
+
+	// Antigüedad máxima de la contraseña (0 = sin vencimiento)
+	PasswordMaxAge time.Duration
+	PasswordPolicy PasswordPolicyConfig
+
+	// Parámetros de hash Argon2id
+	Argon2        security.Argon2Params
+	Argon2Limiter security.HashLimiterConfig
+
+	// Almacenamiento de archivos subidos
+	Storage storage.Config
+
+	// Geolocalización de IP sin conexión (base MMDB)
+	GeoIP geoip.Config
 }
 
 var (
@@ -32,6 +92,33 @@ func LoadConfig() {
 			DBPassword: getEnv("DB_PASSWORD", ""),
 			DBName:     getEnv("DB_NAME", "inventario"),
 			DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
+
+			OIDCProviders: loadOIDCProviders(),
+			LDAP:          loadLDAPConfig(),
+			Throttle:      loadThrottleConfig(),
+			PasswordReset: loadPasswordResetConfig(),
+			Audit:         loadAuditConfig(),
+
+			JWTKeys:    loadJWTKeyConfig(),
+			SessionTTL: getEnvDuration("SESSION_TTL", 8*time.Hour),
+			TOTPIssuer: getEnv("TOTP_ISSUER", "Inventario"),
+
+			FieldKeys: loadFieldKeyConfig(),
+
+			Mail:         loadMailConfig(),
+			Verification: loadVerificationConfig(),
+
+			InvitationTTL:    getEnvDuration("INVITATION_TTL", 72*time.Hour),
+			ImpersonationTTL: getEnvDuration("IMPERSONATION_TTL", 30*time.Minute),
+			APIKeyTTL:        getEnvDuration("API_KEY_TTL", 90*24*time.Hour),
+			PasswordMaxAge:   getEnvDuration("PASSWORD_MAX_AGE", 0),
+			PasswordPolicy:   loadPasswordPolicyConfig(),
+
+			Argon2:        loadArgon2Params(),
+			Argon2Limiter: loadArgon2Limiter(),
+
+			Storage: loadStorageConfig(),
+			GeoIP:   loadGeoIPConfig(),
 		}
 	})
 }
@@ -51,3 +138,23 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt obtiene una variable entera o retorna el valor por defecto si no es válida.
+func getEnvInt(key string, defaultValue int) int {
+	if val, ok := os.LookupEnv(key); ok {
+		if n, err := strconv.Atoi(val); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDuration obtiene una duración ("15m", "1h") o retorna el valor por defecto.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if val, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(val); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,12 @@
+package config
+
+import "server/pkgs/security"
+
+// loadFieldKeyConfig lee las claves versionadas con las que se cifran las
+// columnas sensibles (tokens OAuth de las cuentas vinculadas)
+func loadFieldKeyConfig() security.FieldKeyConfig {
+	return security.FieldKeyConfig{
+		Keys:   getEnv("FIELD_ENCRYPTION_KEYS", ""),
+		Active: getEnvInt("FIELD_ENCRYPTION_ACTIVE_VERSION", 0),
+	}
+}
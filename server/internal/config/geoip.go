@@ -0,0 +1,11 @@
+package config
+
+import "server/pkgs/geoip"
+
+// loadGeoIPConfig lee la base MaxMind local con la que se geolocalizan las IP
+func loadGeoIPConfig() geoip.Config {
+	return geoip.Config{
+		DBPath:   getEnv("GEOIP_DB_PATH", ""),
+		Language: getEnv("GEOIP_LANGUAGE", "es"),
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+import (
+	"time"
+
+	"server/pkgs/security"
+)
+
+// loadJWTKeyConfig lee la carpeta de claves de firma y la ventana de rotación
+func loadJWTKeyConfig() security.KeyConfig {
+	return security.KeyConfig{
+		Dir:            getEnv("JWT_KEYS_DIR", ""),
+		Overlap:        getEnvDuration("JWT_KEY_OVERLAP", 24*time.Hour),
+		ReloadInterval: getEnvDuration("JWT_KEYS_RELOAD_INTERVAL", 5*time.Minute),
+	}
+}
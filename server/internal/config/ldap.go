@@ -0,0 +1,63 @@
+package config
+
+import (
+	"strings"
+
+	"server/pkgs/ldapauth"
+)
+
+// LDAPConfig agrupa la conexión al directorio y el mapeo de grupos
+type LDAPConfig struct {
+	Enabled   bool
+	Directory ldapauth.Config
+	// GroupRoles y GroupOffices asocian el DN (o CN) de un grupo a un Rol / Office
+	GroupRoles   map[string]string
+	GroupOffices map[string]string
+	DefaultRole  string
+	// SyncGroups reaplica rol y oficina de los grupos en cada inicio de
+	// sesión; si es false solo se usan al crear el usuario
+	SyncGroups bool
+}
+
+// loadLDAPConfig lee LDAP_* del entorno. Los mapeos usan el formato
+// "CN=Inv-Admins,OU=Grupos,DC=ejemplo,DC=pe=ADMIN;Inv-OTIC=OTIC".
+func loadLDAPConfig() LDAPConfig {
+	return LDAPConfig{
+		Enabled: getEnv("LDAP_ENABLED", "false") == "true",
+		Directory: ldapauth.Config{
+			URL:                getEnv("LDAP_URL", ""),
+			StartTLS:           getEnv("LDAP_START_TLS", "false") == "true",
+			InsecureSkipVerify: getEnv("LDAP_INSECURE_SKIP_VERIFY", "false") == "true",
+			BindDN:             getEnv("LDAP_BIND_DN", ""),
+			BindPassword:       getEnv("LDAP_BIND_PASSWORD", ""),
+			BaseDN:             getEnv("LDAP_BASE_DN", ""),
+			UserFilter:         getEnv("LDAP_USER_FILTER", ""),
+			AttrID:             getEnv("LDAP_ATTR_ID", ""),
+			AttrEmail:          getEnv("LDAP_ATTR_EMAIL", ""),
+			AttrName:           getEnv("LDAP_ATTR_NAME", ""),
+			AttrPhone:          getEnv("LDAP_ATTR_PHONE", ""),
+			AttrGroups:         getEnv("LDAP_ATTR_GROUPS", ""),
+		},
+		GroupRoles:   parseGroupMap(getEnv("LDAP_GROUP_ROLE_MAP", "")),
+		GroupOffices: parseGroupMap(getEnv("LDAP_GROUP_OFFICE_MAP", "")),
+		DefaultRole:  getEnv("LDAP_DEFAULT_ROLE", "EMPLOYEE"),
+		SyncGroups:   getEnv("LDAP_SYNC_GROUPS", "false") == "true",
+	}
+}
+
+func parseGroupMap(raw string) map[string]string {
+	mapping := make(map[string]string)
+	for _, pair := range strings.Split(raw, ";") {
+		// El DN contiene "=", por eso se separa en el último
+		idx := strings.LastIndex(pair, "=")
+		if idx <= 0 {
+			continue
+		}
+		group := strings.ToLower(strings.TrimSpace(pair[:idx]))
+		value := strings.TrimSpace(pair[idx+1:])
+		if group != "" && value != "" {
+			mapping[group] = value
+		}
+	}
+	return mapping
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"time"
+
+	"server/pkgs/mailer"
+)
+
+// loadMailConfig lee la configuración de correo saliente.
+// MAIL_DRIVER=log guarda los correos en disco (desarrollo).
+func loadMailConfig() mailer.Config {
+	return mailer.Config{
+		Driver:      getEnv("MAIL_DRIVER", "log"),
+		FromAddress: getEnv("MAIL_FROM", "no-reply@localhost"),
+		FromName:    getEnv("MAIL_FROM_NAME", "Soporte Inventario"),
+		AppName:     getEnv("APP_NAME", "Inventario"),
+		AppBaseURL:  getEnv("APP_BASE_URL", "http://localhost:3000"),
+		SMTP: mailer.SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnv("SMTP_PORT", "587"),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			TLSMode:  getEnv("SMTP_TLS", "starttls"),
+		},
+		LogDir:      getEnv("MAIL_LOG_DIR", "logs/mail"),
+		QueueSize:   getEnvInt("MAIL_QUEUE_SIZE", 100),
+		Workers:     getEnvInt("MAIL_WORKERS", 2),
+		MaxAttempts: getEnvInt("MAIL_MAX_ATTEMPTS", 5),
+		RetryDelay:  getEnvDuration("MAIL_RETRY_DELAY", 5*time.Second),
+	}
+}
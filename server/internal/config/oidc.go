@@ -0,0 +1,42 @@
+package config
+
+import (
+	"strings"
+
+	"server/pkgs/oidc"
+)
+
+// loadOIDCProviders lee los proveedores desde OIDC_PROVIDERS=keycloak,entra
+// y sus variables OIDC_<NOMBRE>_ISSUER, _CLIENT_ID, _CLIENT_SECRET,
+// _DISCOVERY_URL, _REDIRECT_URL, _SCOPES, _DISPLAY_NAME y _TRUST_EMAIL.
+func loadOIDCProviders() []oidc.Config {
+	var providers []oidc.Config
+
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		provider := oidc.Config{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", name),
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			DiscoveryURL: getEnv(prefix+"DISCOVERY_URL", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", ""),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+			TrustEmail:   getEnv(prefix+"TRUST_EMAIL", "false") == "true",
+		}
+
+		// Un proveedor sin issuer o client id no puede operar
+		if provider.Issuer == "" || provider.ClientID == "" {
+			continue
+		}
+		providers = append(providers, provider)
+	}
+
+	return providers
+}
@@ -0,0 +1,28 @@
+package config
+
+// PasswordPolicyConfig define las reglas aplicadas a toda contraseña nueva
+type PasswordPolicyConfig struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// HistorySize impide reutilizar las últimas N contraseñas (0 = desactivado)
+	HistorySize int
+	// BreachedDir apunta a la copia local de prefijos SHA-1 filtrados ("" = desactivado)
+	BreachedDir string
+}
+
+func loadPasswordPolicyConfig() PasswordPolicyConfig {
+	return PasswordPolicyConfig{
+		MinLength:     getEnvInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:     getEnvInt("PASSWORD_MAX_LENGTH", 128),
+		RequireUpper:  getEnv("PASSWORD_REQUIRE_UPPER", "true") == "true",
+		RequireLower:  getEnv("PASSWORD_REQUIRE_LOWER", "true") == "true",
+		RequireDigit:  getEnv("PASSWORD_REQUIRE_DIGIT", "true") == "true",
+		RequireSymbol: getEnv("PASSWORD_REQUIRE_SYMBOL", "false") == "true",
+		HistorySize:   getEnvInt("PASSWORD_HISTORY_SIZE", 5),
+		BreachedDir:   getEnv("PASSWORD_BREACHED_DIR", ""),
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+
+	"server/pkgs/security"
+)
+
+// PasswordResetConfig define la clave con la que se guardan los códigos de
+// recuperación y la cola que los emite en segundo plano
+type PasswordResetConfig struct {
+	Secret         string
+	IssueWorkers   int
+	IssueQueueSize int
+}
+
+func loadPasswordResetConfig() PasswordResetConfig {
+	return PasswordResetConfig{
+		Secret:         getEnv("PASSWORD_RESET_SECRET", ""),
+		IssueWorkers:   getEnvInt("PASSWORD_RESET_WORKERS", 2),
+		IssueQueueSize: getEnvInt("PASSWORD_RESET_QUEUE_SIZE", 100),
+	}
+}
+
+// Key decodifica PASSWORD_RESET_SECRET; el servidor no arranca sin ella
+func (c PasswordResetConfig) Key() (security.SecretKey, error) {
+	key, err := security.ParseSecretKey(c.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("PASSWORD_RESET_SECRET: %w", err)
+	}
+	return key, nil
+}
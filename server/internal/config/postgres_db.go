@@ -9,14 +9,22 @@ import (
 	"gorm.io/gorm"
 
 	_ "github.com/lib/pq"
+
+	"server/internal/audit"
 )
 
 // Variable global de conexión GORM.
 var DB *gorm.DB
 
 // ConnectDB establece la conexión con la base de datos PostgreSQL usando GORM.
+// Todos los create, update y delete quedan registrados en la auditoría, por
+// lo que exige AUDIT_HMAC_KEY.
 func ConnectDB() error {
 	c := GetConfig()
+	auditKey, err := c.Audit.Key()
+	if err != nil {
+		return err
+	}
 
 	dsn := fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=America/Lima",
@@ -27,6 +35,9 @@ func ConnectDB() error {
 	if err != nil {
 		return fmt.Errorf("error conectando a la base de datos: %w", err)
 	}
+	if err := audit.Register(db, auditKey); err != nil {
+		return fmt.Errorf("error registrando la auditoría: %w", err)
+	}
 
 	DB = db
 	log.Println("✅ Conexión a la base de datos establecida correctamente")
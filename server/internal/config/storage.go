@@ -0,0 +1,12 @@
+package config
+
+import "server/pkgs/storage"
+
+// loadStorageConfig lee dónde se guardan los archivos subidos (avatares)
+func loadStorageConfig() storage.Config {
+	return storage.Config{
+		Driver:    getEnv("STORAGE_DRIVER", "local"),
+		LocalDir:  getEnv("STORAGE_LOCAL_DIR", "uploads"),
+		PublicURL: getEnv("STORAGE_PUBLIC_URL", "/media"),
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+// ThrottleConfig define los límites de intentos fallidos y el bloqueo progresivo
+type ThrottleConfig struct {
+	AccountMaxAttempts   int
+	IPMaxAttempts        int
+	AttemptWindow        time.Duration
+	LockoutBase          time.Duration
+	LockoutMax           time.Duration
+	ResetCodeMaxAttempts int
+}
+
+func loadThrottleConfig() ThrottleConfig {
+	return ThrottleConfig{
+		AccountMaxAttempts:   getEnvInt("LOGIN_MAX_ATTEMPTS", 5),
+		IPMaxAttempts:        getEnvInt("LOGIN_IP_MAX_ATTEMPTS", 20),
+		AttemptWindow:        getEnvDuration("LOGIN_ATTEMPT_WINDOW", 15*time.Minute),
+		LockoutBase:          getEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute),
+		LockoutMax:           getEnvDuration("LOGIN_LOCKOUT_MAX", 24*time.Hour),
+		ResetCodeMaxAttempts: getEnvInt("RESET_CODE_MAX_ATTEMPTS", 5),
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+// VerificationConfig controla la verificación de correo de cuentas nuevas
+type VerificationConfig struct {
+	// Required bloquea el inicio de sesión con contraseña mientras el correo no esté verificado
+	Required       bool
+	TokenTTL       time.Duration
+	ResendCooldown time.Duration
+}
+
+func loadVerificationConfig() VerificationConfig {
+	return VerificationConfig{
+		Required:       getEnv("EMAIL_VERIFICATION_REQUIRED", "true") == "true",
+		TokenTTL:       getEnvDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+		ResendCooldown: getEnvDuration("EMAIL_VERIFICATION_RESEND_COOLDOWN", time.Minute),
+	}
+}
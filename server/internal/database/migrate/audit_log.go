@@ -0,0 +1,55 @@
+package migrate
+
+import (
+	"gorm.io/gorm"
+	"server/internal/audit"
+)
+
+// ProtectAuditLog deja audit_logs como tabla de solo inserción, igual que
+// security_events. No sella las entradas que no tienen cadena: siguen
+// fallando la verificación hasta que alguien las revise y las selle con
+// SealAuditLog. Es idempotente.
+func ProtectAuditLog(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_logs es de solo inserción';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_logs_no_update ON audit_logs`,
+		`CREATE TRIGGER audit_logs_no_update BEFORE UPDATE OR DELETE ON audit_logs
+			FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()`,
+		`DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs`,
+		`CREATE TRIGGER audit_logs_no_truncate BEFORE TRUNCATE ON audit_logs
+			FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only()`,
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SealAuditLog encadena las entradas sin sellar. El sellado actualiza filas,
+// así que el trigger de solo inserción se suspende dentro de la misma
+// transacción y vuelve a quedar activo al terminar. Devuelve cuántas selló.
+func SealAuditLog(db *gorm.DB) (int, error) {
+	sealed := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`ALTER TABLE audit_logs DISABLE TRIGGER audit_logs_no_update`).Error; err != nil {
+			return err
+		}
+
+		var err error
+		sealed, err = audit.SealPending(tx)
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(`ALTER TABLE audit_logs ENABLE TRIGGER audit_logs_no_update`).Error
+	})
+	return sealed, err
+}
@@ -0,0 +1,37 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"server/pkgs/logger"
+)
+
+// NormalizeUserEmails guarda los correos de users en minúsculas y sin
+// espacios y crea un índice único sobre LOWER(email), para que Foo@x y foo@x
+// no puedan ser dos cuentas. Si ya existen correos que solo difieren en
+// mayúsculas no toca nada y los lista: hay que fusionar o renombrar esas
+// cuentas a mano. Es idempotente.
+func NormalizeUserEmails(db *gorm.DB) error {
+	var duplicates []string
+	if err := db.Raw(`SELECT LOWER(TRIM(email)) FROM users
+		GROUP BY LOWER(TRIM(email)) HAVING COUNT(*) > 1 ORDER BY 1`).
+		Scan(&duplicates).Error; err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("correos repetidos con distinta capitalización: %s", strings.Join(duplicates, ", "))
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`UPDATE users SET email = LOWER(TRIM(email)) WHERE email <> LOWER(TRIM(email))`)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			logger.Log.Infof("📧 %d correos de usuario normalizados a minúsculas", res.RowsAffected)
+		}
+		return tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email))`).Error
+	})
+}
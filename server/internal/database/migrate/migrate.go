@@ -15,11 +15,25 @@ import (
 // Aquí defines qué tablas se crean primero (padres → hijos)
 func modelOrderUp() []any {
 	return []any{
+		&models.Permission{},
+		&models.Role{},
+		&models.Office{},
 		&models.User{},
 		&models.Account{},
 		&models.Session{},
 		&models.VerificationToken{},
 		&models.PasswordResetToken{},
+		&models.OAuthState{},
+		&models.RecoveryCode{},
+		&models.TwoFactorPolicy{},
+		&models.AuthThrottle{},
+		&models.SecurityEvent{},
+		&models.Invitation{},
+		&models.PasswordHistory{},
+		&models.AuditLog{},
+		&models.OffboardingRecord{},
+		&models.OfficeMembership{},
+		&models.APIKey{},
 		&models.Asset{},
 	}
 }
@@ -28,11 +42,27 @@ func modelOrderUp() []any {
 func modelOrderDown() []any {
 	return []any{
 		&models.Asset{},
+		"api_key_permissions",
+		&models.APIKey{},
+		&models.OfficeMembership{},
+		&models.OffboardingRecord{},
+		&models.AuditLog{},
+		&models.PasswordHistory{},
+		&models.Invitation{},
+		&models.SecurityEvent{},
+		&models.AuthThrottle{},
+		&models.TwoFactorPolicy{},
+		&models.RecoveryCode{},
+		&models.OAuthState{},
 		&models.PasswordResetToken{},
 		&models.VerificationToken{},
 		&models.Session{},
 		&models.Account{},
 		&models.User{},
+		&models.Office{},
+		"role_permissions",
+		&models.Role{},
+		&models.Permission{},
 	}
 }
 
@@ -48,6 +78,21 @@ func migrateUp(db *gorm.DB) error {
 			return fmt.Errorf("AutoMigrate %T: %w", m, err)
 		}
 	}
+	if err := ProtectAuditLog(db); err != nil {
+		return fmt.Errorf("registro de auditoría: %w", err)
+	}
+	if err := NormalizeUserEmails(db); err != nil {
+		return fmt.Errorf("correos de usuario: %w", err)
+	}
+	if err := ConvertOfficeEnum(db); err != nil {
+		return fmt.Errorf("conversión de oficinas: %w", err)
+	}
+	if err := SeedRoles(db); err != nil {
+		return fmt.Errorf("roles y permisos: %w", err)
+	}
+	if err := ProtectSecurityEvents(db); err != nil {
+		return fmt.Errorf("eventos de seguridad: %w", err)
+	}
 	logger.Log.Info("Migración UP completada ✅")
 	return nil
 }
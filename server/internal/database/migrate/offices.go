@@ -0,0 +1,66 @@
+package migrate
+
+import (
+	"fmt"
+
+	"server/internal/models"
+	"server/pkgs/logger"
+
+	"gorm.io/gorm"
+)
+
+// defaultOffices es el catálogo inicial; corresponde al antiguo enum Office
+var defaultOffices = []models.Office{
+	{Code: "OTIC", Name: "Oficina de Tecnologías de la Información y Comunicaciones", IsActive: true},
+	{Code: "PATRIMONIO", Name: "Oficina de Control Patrimonial", IsActive: true},
+	{Code: "ABASTECIMIENTO", Name: "Oficina de Abastecimiento", IsActive: true},
+}
+
+// ConvertOfficeEnum crea el catálogo inicial de oficinas y pasa las columnas
+// varchar "office" de users e invitations a la FK office_id. Es idempotente:
+// se ejecuta después de AutoMigrate en cada arranque.
+func ConvertOfficeEnum(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Office{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			offices := make([]models.Office, len(defaultOffices))
+			copy(offices, defaultOffices)
+			if err := tx.Omit("Parent").Create(&offices).Error; err != nil {
+				return fmt.Errorf("no se pudo crear el catálogo de oficinas: %w", err)
+			}
+		}
+
+		for _, table := range []string{"users", "invitations"} {
+			if !tx.Migrator().HasColumn(table, "office") {
+				continue
+			}
+
+			// Valores que no estén en el catálogo se crean como oficinas nuevas
+			if err := tx.Exec(fmt.Sprintf(`INSERT INTO offices (code, name, is_active)
+				SELECT DISTINCT office, office, true FROM %s WHERE office IS NOT NULL AND office <> ''
+				ON CONFLICT (code) DO NOTHING`, table)).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(fmt.Sprintf(`UPDATE %s t SET office_id = o.id
+				FROM offices o WHERE t.office = o.code AND t.office_id IS NULL`, table)).Error; err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(table, "office"); err != nil {
+				return err
+			}
+			logger.Log.Infof("🏢 Columna %s.office convertida a office_id", table)
+		}
+
+		// users ↔ offices es un ciclo, así que esta FK se agrega al final
+		if !tx.Migrator().HasConstraint(&models.Office{}, "fk_offices_head_user") {
+			if err := tx.Exec(`ALTER TABLE offices ADD CONSTRAINT fk_offices_head_user
+				FOREIGN KEY (head_user_id) REFERENCES users(id) ON DELETE SET NULL`).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
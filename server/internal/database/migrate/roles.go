@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+
+	"server/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// permissionCatalog es el catálogo completo de permisos que entiende el servidor
+var permissionCatalog = []models.Permission{
+	{Code: models.PermUserCreate, Description: "Invitar usuarios"},
+	{Code: models.PermUserList, Description: "Listar usuarios e invitaciones"},
+	{Code: models.PermUserUpdate, Description: "Editar nombre y teléfono de usuarios"},
+	{Code: models.PermUserRoleAssign, Description: "Cambiar el rol de un usuario"},
+	{Code: models.PermUserOfficeAssign, Description: "Cambiar la oficina principal y las adicionales de un usuario"},
+	{Code: models.PermUserDeactivate, Description: "Desactivar y reactivar usuarios"},
+	{Code: models.PermUserPasswordReset, Description: "Restablecer o exigir el cambio de contraseña"},
+	{Code: models.PermUserUnlock, Description: "Desbloquear cuentas bloqueadas por intentos fallidos"},
+	{Code: models.PermUserOffboard, Description: "Dar de baja usuarios y reasignar lo que tienen a cargo"},
+	{Code: models.PermUserLookup, Description: "Consultar si un correo tiene cuenta"},
+	{Code: models.PermUserManage, Description: "Gestionar usuarios de las oficinas que supervisa"},
+	{Code: models.PermUserManageAll, Description: "Gestionar usuarios de todas las oficinas"},
+	{Code: models.PermUserImpersonate, Description: "Iniciar sesión en nombre de otro usuario"},
+	{Code: models.PermServiceAccountManage, Description: "Administrar cuentas de servicio y sus API keys"},
+	{Code: models.PermAssetCreate, Description: "Registrar bienes"},
+	{Code: models.PermAssetUpdate, Description: "Editar bienes"},
+	{Code: models.PermAssetDelete, Description: "Eliminar bienes"},
+	{Code: models.PermAssetTransfer, Description: "Solicitar el traslado de bienes"},
+	{Code: models.PermAssetTransferApprove, Description: "Aprobar traslados de bienes"},
+	{Code: models.PermOfficeManage, Description: "Administrar el catálogo de oficinas"},
+	{Code: models.PermRoleManage, Description: "Administrar roles y permisos"},
+	{Code: models.PermSecurityPolicyManage, Description: "Configurar políticas de seguridad"},
+	{Code: models.PermMetricsView, Description: "Ver métricas del servidor"},
+	{Code: models.PermSecurityEventRead, Description: "Consultar el registro de eventos de seguridad"},
+}
+
+// defaultRoles son los roles del sistema con sus permisos iniciales. ADMIN
+// recibe siempre el catálogo completo.
+var defaultRoles = []struct {
+	role        models.Role
+	permissions []string
+}{
+	{
+		role: models.Role{Name: models.RolAdmin, Description: "Administrador del sistema", Level: 100, IsSystem: true},
+	},
+	{
+		role: models.Role{Name: models.RolManager, Description: "Jefe de oficina", Level: 50, IsSystem: true},
+		permissions: []string{
+			models.PermUserManage, models.PermUserCreate, models.PermUserList, models.PermUserUpdate,
+			models.PermUserDeactivate, models.PermUserPasswordReset, models.PermUserOffboard,
+			models.PermAssetCreate, models.PermAssetUpdate, models.PermAssetTransfer, models.PermAssetTransferApprove,
+		},
+	},
+	{
+		role:        models.Role{Name: models.RolEmployee, Description: "Empleado", Level: 10, IsSystem: true},
+		permissions: []string{models.PermAssetCreate, models.PermAssetTransfer},
+	},
+}
+
+// SeedRoles sincroniza el catálogo de permisos y crea los roles del sistema si
+// no existen. Los permisos de un rol existente no se tocan (los edita ADMIN),
+// salvo ADMIN, que recibe los permisos nuevos del catálogo.
+func SeedRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "code"}},
+			DoUpdates: clause.AssignmentColumns([]string{"description"}),
+		}).Create(&permissionCatalog).Error; err != nil {
+			return fmt.Errorf("no se pudo sincronizar el catálogo de permisos: %w", err)
+		}
+
+		for _, def := range defaultRoles {
+			var role models.Role
+			err := tx.Where("name = ?", def.role.Name).First(&role).Error
+			switch {
+			case err == nil:
+				if role.Name != models.RolAdmin {
+					continue
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				role = def.role
+				if err := tx.Omit("Permissions").Create(&role).Error; err != nil {
+					return fmt.Errorf("no se pudo crear el rol %s: %w", role.Name, err)
+				}
+			default:
+				return err
+			}
+
+			codes := def.permissions
+			if role.Name == models.RolAdmin {
+				codes = make([]string, 0, len(permissionCatalog))
+				for _, p := range permissionCatalog {
+					codes = append(codes, p.Code)
+				}
+			}
+			for _, code := range codes {
+				if err := tx.Exec(`INSERT INTO role_permissions (role_id, permission_code) VALUES (?, ?)
+					ON CONFLICT DO NOTHING`, role.ID, code).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
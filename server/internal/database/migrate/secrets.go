@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"server/internal/models"
+	"server/pkgs/security"
+
+	"gorm.io/gorm"
+)
+
+const reencryptBatchSize = 200
+
+// encryptedAccountColumns son las columnas de accounts con serializer:encrypted
+var encryptedAccountColumns = []string{"RefreshToken", "AccessToken", "IDToken"}
+
+// accountSecrets son las columnas cifradas tal como están guardadas
+type accountSecrets struct {
+	ID           string
+	RefreshToken *string
+	AccessToken  *string
+	IDToken      *string
+}
+
+// ReencryptAccounts vuelve a cifrar con la clave activa los tokens de las
+// cuentas vinculadas que estén en texto plano, con una versión anterior o
+// cifrados antes de ligarlos a la fila.
+// Con dryRun solo cuenta las filas pendientes. Devuelve cuántas filas
+// necesitaban re-encriptarse.
+func ReencryptAccounts(db *gorm.DB, dryRun bool) (int, error) {
+	if security.Fields == nil {
+		return 0, security.ErrFieldCipherDisabled
+	}
+
+	stale := 0
+	lastID := ""
+	for {
+		var batch []accountSecrets
+		query := db.Table("accounts").
+			Select("id, refresh_token, access_token, id_token").
+			Order("id").
+			Limit(reencryptBatchSize)
+		if lastID != "" {
+			query = query.Where("id > ?", lastID)
+		}
+		if err := query.Scan(&batch).Error; err != nil {
+			return stale, err
+		}
+		if len(batch) == 0 {
+			return stale, nil
+		}
+		lastID = batch[len(batch)-1].ID
+
+		for _, row := range batch {
+			if isCurrent(row.ID, "refresh_token", row.RefreshToken) &&
+				isCurrent(row.ID, "access_token", row.AccessToken) &&
+				isCurrent(row.ID, "id_token", row.IDToken) {
+				continue
+			}
+			stale++
+			if dryRun {
+				continue
+			}
+
+			// Al leer por el modelo se descifra con la clave de cada valor y
+			// al guardar se cifra con la activa
+			if err := db.Transaction(func(tx *gorm.DB) error {
+				var account models.Account
+				if err := tx.Omit("User").First(&account, "id = ?", row.ID).Error; err != nil {
+					return err
+				}
+				return tx.Model(&account).Select(encryptedAccountColumns).Updates(&account).Error
+			}); err != nil {
+				return stale, err
+			}
+		}
+	}
+}
+
+// isCurrent indica si el valor está cifrado con la clave activa y ligado a su fila
+func isCurrent(id, column string, value *string) bool {
+	if value == nil {
+		return true
+	}
+	if !security.Fields.IsCurrent(*value) {
+		return false
+	}
+	_, err := security.Fields.Decrypt(*value, models.FieldContext("accounts", column, id))
+	return err == nil
+}
@@ -0,0 +1,31 @@
+package migrate
+
+import (
+	"gorm.io/gorm"
+)
+
+// ProtectSecurityEvents deja security_events como tabla de solo inserción:
+// un trigger rechaza cualquier UPDATE, DELETE o TRUNCATE. Es idempotente.
+func ProtectSecurityEvents(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION security_events_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'security_events es de solo inserción';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS security_events_no_update ON security_events`,
+		`CREATE TRIGGER security_events_no_update BEFORE UPDATE OR DELETE ON security_events
+			FOR EACH ROW EXECUTE FUNCTION security_events_append_only()`,
+		`DROP TRIGGER IF EXISTS security_events_no_truncate ON security_events`,
+		`CREATE TRIGGER security_events_no_truncate BEFORE TRUNCATE ON security_events
+			FOR EACH STATEMENT EXECUTE FUNCTION security_events_append_only()`,
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"server/internal/config"
 	"server/internal/models"
 	"server/pkgs/security"
 )
@@ -50,7 +51,8 @@ func seedUsers(db *gorm.DB) error {
 		return fmt.Errorf("error al parsear user.json: %w", err)
 	}
 
-	argon := security.NewArgon2Service()
+	argonParams := config.GetConfig().Argon2
+	argon := security.NewArgon2ServiceWithParams(&argonParams)
 
 	var adminID string
 	managerIDs := make(map[string]string)
@@ -93,10 +95,9 @@ func seedUsers(db *gorm.DB) error {
 			return fmt.Errorf("error al encriptar la contraseña de %s: %w", u.Email, err)
 		}
 
-		var office *models.Office
-		if u.Office != nil && *u.Office != "" {
-			officeValue := models.Office(*u.Office)
-			office = &officeValue
+		office, err := seedOfficeID(db, u.Office)
+		if err != nil {
+			return err
 		}
 
 		user := models.User{
@@ -104,10 +105,12 @@ func seedUsers(db *gorm.DB) error {
 			Email:         u.Email,
 			Password:      ptrString(hashedPassword),
 			Rol:           models.Rol(u.Rol),
-			Office:        office,
+			OfficeID:      office,
 			IsActive:      true,
 			CreatedByID:   &adminID,
 			EmailVerified: ptrTime(time.Now()),
+			// La contraseña del seed es conocida: se cambia en el primer login
+			MustChangePassword: true,
 		}
 
 		if err := db.Create(&user).Error; err != nil {
@@ -132,12 +135,13 @@ func seedUsers(db *gorm.DB) error {
 			return fmt.Errorf("error al encriptar la contraseña de %s: %w", u.Email, err)
 		}
 
-		var office *models.Office
+		office, err := seedOfficeID(db, u.Office)
+		if err != nil {
+			return err
+		}
+
 		var createdByID *string
 		if u.Office != nil && *u.Office != "" {
-			officeValue := models.Office(*u.Office)
-			office = &officeValue
-
 			if managerID, exists := managerIDs[*u.Office]; exists {
 				createdByID = &managerID
 			}
@@ -148,10 +152,12 @@ func seedUsers(db *gorm.DB) error {
 			Email:         u.Email,
 			Password:      ptrString(hashedPassword),
 			Rol:           models.Rol(u.Rol),
-			Office:        office,
+			OfficeID:      office,
 			CreatedByID:   createdByID,
 			IsActive:      true,
 			EmailVerified: ptrTime(time.Now()),
+			// La contraseña del seed es conocida: se cambia en el primer login
+			MustChangePassword: true,
 		}
 
 		if err := db.Create(&user).Error; err != nil {
@@ -165,6 +171,19 @@ func seedUsers(db *gorm.DB) error {
 	return nil
 }
 
+// seedOfficeID busca la oficina por código en el catálogo creado por la migración
+func seedOfficeID(db *gorm.DB, code *string) (*string, error) {
+	if code == nil || *code == "" {
+		return nil, nil
+	}
+
+	var office models.Office
+	if err := db.Where("code = ?", *code).First(&office).Error; err != nil {
+		return nil, fmt.Errorf("la oficina %s no existe en el catálogo: %w", *code, err)
+	}
+	return &office.ID, nil
+}
+
 func ptrTime(t time.Time) *time.Time {
 	return &t
 }
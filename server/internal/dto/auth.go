@@ -5,6 +5,9 @@ type SigninRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 	Provider string `json:"provider"`
+
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 type SignupRequest struct {
@@ -22,6 +25,21 @@ type AuthResponse struct {
 	Image  *string `json:"image"`
 	Role   string  `json:"role"`
 	Office *string `json:"office"`
+
+	// Sesión emitida por el servidor
+	AccessToken *string `json:"accessToken,omitempty"`
+	ExpiresAt   *int64  `json:"expiresAt,omitempty"`
+
+	// Segundo paso de autenticación
+	TwoFactorRequired      bool    `json:"twoFactorRequired,omitempty"`
+	TwoFactorSetupRequired bool    `json:"twoFactorSetupRequired,omitempty"`
+	ChallengeToken         *string `json:"challengeToken,omitempty"`
+
+	// La sesión solo permite cambiar la contraseña
+	PasswordChangeRequired bool `json:"passwordChangeRequired,omitempty"`
+
+	// Sesión de suplantación: usuario real que la opera
+	ImpersonatedBy *string `json:"impersonatedBy,omitempty"`
 }
 
 type CreateUserRequest struct {
@@ -32,14 +50,29 @@ type CreateUserRequest struct {
 	Phone  *string `json:"phone"`
 }
 
-type CreateUserResponse struct {
-	ID                string  `json:"id"`
-	Name              *string `json:"name"`
-	Email             string  `json:"email"`
-	Role              string  `json:"role"`
-	Office            *string `json:"office"`
-	Phone             *string `json:"phone"`
-	GeneratedPassword *string `json:"generatedPassword"`
+// UpdateUserRequest contiene solo los campos que se desean modificar
+type UpdateUserRequest struct {
+	Name   *string `json:"name"`
+	Phone  *string `json:"phone"`
+	Role   *string `json:"role" validate:"omitempty,oneof=MANAGER EMPLOYEE"`
+	Office *string `json:"office"`
+}
+
+// InvitationResponse describe una invitación; CreateUser la devuelve en lugar de una contraseña
+type InvitationResponse struct {
+	ID        string  `json:"id"`
+	Name      *string `json:"name"`
+	Email     string  `json:"email"`
+	Role      string  `json:"role"`
+	Office    *string `json:"office"`
+	Phone     *string `json:"phone"`
+	Status    string  `json:"status"`
+	ExpiresAt string  `json:"expiresAt"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
 }
 
 type GetUsersRequest struct {
@@ -47,12 +80,19 @@ type GetUsersRequest struct {
 }
 
 type UserListResponse struct {
-	ID     string  `json:"id"`
-	Name   *string `json:"name"`
-	Email  string  `json:"email"`
-	Role   string  `json:"role"`
-	Office *string `json:"office"`
-	Phone  *string `json:"phone"`
-	IsActive bool  `json:"isActive"`
-	CreatedAt string `json:"createdAt"`
+	ID        string  `json:"id"`
+	Name      *string `json:"name"`
+	Email     string  `json:"email"`
+	Role      string  `json:"role"`
+	Office    *string `json:"office"`
+	Phone     *string `json:"phone"`
+	IsActive  bool    `json:"isActive"`
+	CreatedAt string  `json:"createdAt"`
+
+	// ACTIVE o INACTIVE para usuarios; INVITED para invitaciones pendientes
+	Status              string  `json:"status"`
+	InvitationExpiresAt *string `json:"invitationExpiresAt,omitempty"`
+
+	// Códigos de las oficinas adicionales a la principal
+	Offices []string `json:"offices,omitempty"`
 }
\ No newline at end of file
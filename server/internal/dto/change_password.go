@@ -1,8 +1,8 @@
 //server/internal/dto/change_password.go
 package dto
 
+// UpdatePasswordByIDDTO: el usuario se toma de la sesión, no del cuerpo
 type UpdatePasswordByIDDTO struct {
-	UserID      string `json:"userId" validate:"required"`
 	Password    string `json:"password" validate:"required"`
 	NewPassword string `json:"newPassword" validate:"required,min=8"`
 }
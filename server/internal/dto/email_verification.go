@@ -0,0 +1,15 @@
+// server/internal/dto/email_verification.go
+package dto
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+type VerifyEmailResponse struct {
+	Email      string `json:"email"`
+	VerifiedAt string `json:"verifiedAt"`
+}
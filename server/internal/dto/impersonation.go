@@ -0,0 +1,8 @@
+// server/internal/dto/impersonation.go
+package dto
+
+// StartImpersonationRequest inicia una sesión en nombre de otro usuario.
+// El motivo queda en la auditoría.
+type StartImpersonationRequest struct {
+	Reason *string `json:"reason"`
+}
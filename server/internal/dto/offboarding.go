@@ -0,0 +1,44 @@
+// server/internal/dto/offboarding.go
+package dto
+
+// OffboardingAsset es un bien bajo custodia del usuario saliente.
+// Relation indica el vínculo: REGISTERED, RESPONSIBLE o FINAL.
+type OffboardingAsset struct {
+	ID              uint     `json:"id"`
+	PatrimonialCode string   `json:"patrimonialCode"`
+	Description     string   `json:"description"`
+	Location        string   `json:"location"`
+	Relations       []string `json:"relations"`
+}
+
+// OffboardingPreview lista lo que debe reasignarse antes de desactivar la
+// cuenta. NameMatches son bienes que solo coinciden por nombre: se reasignan
+// únicamente si se confirman en la solicitud de baja.
+type OffboardingPreview struct {
+	User             UserListResponse   `json:"user"`
+	Assets           []OffboardingAsset `json:"assets"`
+	NameMatches      []OffboardingAsset `json:"nameMatches"`
+	ManagedEmployees []UserListResponse `json:"managedEmployees"`
+}
+
+// OffboardingRequest define los receptores. AssetsTo y EmployeesTo aplican a
+// todos los elementos; Assets y Employees permiten excepciones por ID.
+// ConfirmedNameMatches son los IDs de NameMatches que sí pertenecen al usuario.
+type OffboardingRequest struct {
+	AssetsTo             *string           `json:"assetsTo"`
+	EmployeesTo          *string           `json:"employeesTo"`
+	Assets               map[string]string `json:"assets"`
+	Employees            map[string]string `json:"employees"`
+	ConfirmedNameMatches []uint            `json:"confirmedNameMatches"`
+	Notes                *string           `json:"notes"`
+}
+
+// OffboardingResponse resume la entrega realizada
+type OffboardingResponse struct {
+	ID                  string `json:"id"`
+	UserID              string `json:"userId"`
+	AssetsHandedOver    int    `json:"assetsHandedOver"`
+	EmployeesHandedOver int    `json:"employeesHandedOver"`
+	Document            string `json:"document"`
+	CreatedAt           string `json:"createdAt"`
+}
@@ -0,0 +1,50 @@
+// server/internal/dto/office.go
+package dto
+
+// OfficeResponse es una oficina del catálogo
+type OfficeResponse struct {
+	ID         string  `json:"id"`
+	Code       string  `json:"code"`
+	Name       string  `json:"name"`
+	ParentID   *string `json:"parentId"`
+	ParentCode *string `json:"parentCode"`
+	HeadUserID *string `json:"headUserId"`
+	IsActive   bool    `json:"isActive"`
+	CreatedAt  string  `json:"createdAt"`
+	UpdatedAt  string  `json:"updatedAt"`
+}
+
+// CreateOfficeRequest da de alta una oficina
+type CreateOfficeRequest struct {
+	Code       string  `json:"code" validate:"required,max=50"`
+	Name       string  `json:"name" validate:"required,max=150"`
+	ParentID   *string `json:"parentId"`
+	HeadUserID *string `json:"headUserId"`
+}
+
+// UpdateOfficeRequest modifica solo los campos enviados. Una cadena vacía en
+// parentId o headUserId quita la oficina padre o el responsable.
+type UpdateOfficeRequest struct {
+	Code       *string `json:"code" validate:"omitempty,max=50"`
+	Name       *string `json:"name" validate:"omitempty,max=150"`
+	ParentID   *string `json:"parentId"`
+	HeadUserID *string `json:"headUserId"`
+	IsActive   *bool   `json:"isActive"`
+}
+
+// OfficeMembershipResponse es una oficina a la que pertenece un usuario. La
+// principal (User.Office) se marca con primary y no tiene fecha de asignación.
+type OfficeMembershipResponse struct {
+	OfficeID  string  `json:"officeId"`
+	Code      string  `json:"code"`
+	Name      string  `json:"name"`
+	Role      string  `json:"role"`
+	Primary   bool    `json:"primary"`
+	GrantedAt *string `json:"grantedAt,omitempty"`
+}
+
+// GrantOfficeMembershipRequest agrega al usuario a otra oficina
+type GrantOfficeMembershipRequest struct {
+	Office string `json:"office" validate:"required"`
+	Role   string `json:"role" validate:"required,oneof=MANAGER EMPLOYEE"`
+}
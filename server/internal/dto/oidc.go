@@ -0,0 +1,28 @@
+// server/internal/dto/oidc.go
+package dto
+
+type OIDCProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type OIDCAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+	State            string `json:"state"`
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+type OIDCCallbackResponse struct {
+	Linked bool          `json:"linked"`
+	User   *AuthResponse `json:"user"`
+}
+
+type LinkedAccountResponse struct {
+	Provider          string `json:"provider"`
+	ProviderAccountID string `json:"providerAccountId"`
+	Type              string `json:"type"`
+}
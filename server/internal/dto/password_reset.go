@@ -6,7 +6,9 @@ type UserExistsRequestDTO struct {
 }
 
 type PasswordResetRequestDTO struct {
-	Email string `json:"email" validate:"required,email"`
+	Email     string `json:"email" validate:"required,email"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 type ValidateCodeDTO struct {
@@ -18,4 +20,6 @@ type ResetPasswordDTO struct {
 	Email       string `json:"email" validate:"required,email"`
 	Token       string `json:"token" validate:"required"`
 	NewPassword string `json:"newPassword" validate:"required,min=8"`
+	IP          string `json:"-"`
+	UserAgent   string `json:"-"`
 }
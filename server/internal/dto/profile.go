@@ -0,0 +1,34 @@
+// server/internal/dto/profile.go
+package dto
+
+// ProfileResponse es el perfil del usuario autenticado
+type ProfileResponse struct {
+	ID               string            `json:"id"`
+	Name             *string           `json:"name"`
+	Email            string            `json:"email"`
+	EmailVerified    bool              `json:"emailVerified"`
+	Phone            *string           `json:"phone"`
+	Role             string            `json:"role"`
+	Office           *string           `json:"office"`
+	Image            *string           `json:"image"`
+	Avatar           map[string]string `json:"avatar,omitempty"`
+	TwoFactorEnabled bool              `json:"twoFactorEnabled"`
+	CreatedAt        string            `json:"createdAt"`
+	LastLogin        *LastLoginInfo    `json:"lastLogin"`
+}
+
+// LastLoginInfo describe el último inicio de sesión registrado
+type LastLoginInfo struct {
+	At       *string `json:"at"`
+	IP       *string `json:"ip"`
+	Device   *string `json:"device"`
+	OS       *string `json:"os"`
+	Location *string `json:"location"`
+	Country  *string `json:"country"`
+}
+
+// UpdateProfileRequest solo admite los datos que el usuario puede editar
+type UpdateProfileRequest struct {
+	Name  *string `json:"name" validate:"omitempty,max=100"`
+	Phone *string `json:"phone" validate:"omitempty,max=30"`
+}
@@ -0,0 +1,34 @@
+// server/internal/dto/role.go
+package dto
+
+// RoleResponse es un rol con sus permisos
+type RoleResponse struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Level       int      `json:"level"`
+	IsSystem    bool     `json:"isSystem"`
+	Permissions []string `json:"permissions"`
+}
+
+// PermissionResponse es una entrada del catálogo de permisos
+type PermissionResponse struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// CreateRoleRequest da de alta un rol personalizado
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,max=20"`
+	Description string   `json:"description" validate:"max=255"`
+	Level       int      `json:"level" validate:"required"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRoleRequest modifica solo los campos enviados. Permissions reemplaza
+// la lista completa de permisos del rol.
+type UpdateRoleRequest struct {
+	Description *string   `json:"description" validate:"omitempty,max=255"`
+	Level       *int      `json:"level"`
+	Permissions *[]string `json:"permissions"`
+}
@@ -0,0 +1,38 @@
+// server/internal/dto/security_event.go
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SecurityEventFilter filtra el registro de eventos de seguridad. From y To
+// aceptan una fecha (2006-01-02, To incluye el día completo) o RFC 3339.
+type SecurityEventFilter struct {
+	UserID string `query:"userId"`
+	Type   string `query:"type"`
+	From   string `query:"from"`
+	To     string `query:"to"`
+	Page   int    `query:"page"`
+	Limit  int    `query:"limit"`
+}
+
+// SecurityEventResponse es una entrada del registro de eventos de seguridad
+type SecurityEventResponse struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	UserID    *string         `json:"userId"`
+	Email     *string         `json:"email"`
+	IP        *string         `json:"ip"`
+	UserAgent *string         `json:"userAgent"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// SecurityEventPage es una página de eventos, del más reciente al más antiguo
+type SecurityEventPage struct {
+	Items []SecurityEventResponse `json:"items"`
+	Total int64                   `json:"total"`
+	Page  int                     `json:"page"`
+	Limit int                     `json:"limit"`
+}
@@ -0,0 +1,50 @@
+// server/internal/dto/service_account.go
+package dto
+
+import "time"
+
+// ServiceAccountResponse es una cuenta de servicio con sus API keys
+type ServiceAccountResponse struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name"`
+	Role      string           `json:"role"`
+	Office    *string          `json:"office"`
+	IsActive  bool             `json:"isActive"`
+	CreatedAt string           `json:"createdAt"`
+	Keys      []APIKeyResponse `json:"keys"`
+}
+
+// CreateServiceAccountRequest da de alta una cuenta de servicio. Name se usa
+// como identificador: minúsculas, números y guiones.
+type CreateServiceAccountRequest struct {
+	Name   string  `json:"name" validate:"required"`
+	Role   string  `json:"role" validate:"required"`
+	Office *string `json:"office"`
+}
+
+// APIKeyResponse describe una API key sin el secreto
+type APIKeyResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *string  `json:"expiresAt"`
+	LastUsedAt *string  `json:"lastUsedAt"`
+	LastUsedIP *string  `json:"lastUsedIp"`
+	RevokedAt  *string  `json:"revokedAt"`
+	CreatedAt  string   `json:"createdAt"`
+}
+
+// CreateAPIKeyRequest emite una API key con los scopes indicados. Sin
+// expiresAt se aplica la vigencia configurada.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required"`
+	Scopes    []string   `json:"scopes" validate:"required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// APIKeyCreatedResponse incluye la clave completa; solo se muestra una vez
+type APIKeyCreatedResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
@@ -0,0 +1,37 @@
+// server/internal/dto/two_factor.go
+package dto
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorConfirmResponse struct {
+	RecoveryCodes []string      `json:"recoveryCodes"`
+	Session       *AuthResponse `json:"session,omitempty"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type TwoFactorPolicyRequest struct {
+	Role     string `json:"role" validate:"required,oneof=ADMIN MANAGER EMPLOYEE"`
+	Required bool   `json:"required"`
+}
+
+type TwoFactorPolicyResponse struct {
+	Role     string `json:"role"`
+	Required bool   `json:"required"`
+}
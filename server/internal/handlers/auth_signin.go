@@ -26,6 +26,8 @@ func (h *AuthHandler) Signin(c fiber.Ctx) (interface{}, string, error) {
 		logger.Log.Errorf("❌ Invalid request body: %v", err)
 		return nil, "Invalid request body", fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
+	req.IP = c.IP()
+	req.UserAgent = c.Get(fiber.HeaderUserAgent)
 
 	logger.Log.Infof("📧 Email: %s, Provider: %s", req.Email, req.Provider)
 
@@ -33,9 +35,19 @@ func (h *AuthHandler) Signin(c fiber.Ctx) (interface{}, string, error) {
 	if err != nil {
 		logger.Log.Errorf("❌ Signin failed: %v", err)
 
-		if errors.Is(err, services.ErrGoogleUserNotRegistered) {
+		if errors.Is(err, services.ErrTooManyAttempts) {
+			return nil, err.Error(), lockoutError(c, err)
+		}
+		if busyErr := hasherBusyError(c, err); busyErr != nil {
+			return nil, err.Error(), busyErr
+		}
+		if errors.Is(err, services.ErrProviderSigninUnsupported) || errors.Is(err, services.ErrEmailNotVerified) ||
+			errors.Is(err, services.ErrDirectoryLinkRequired) {
 			return nil, err.Error(), fiber.NewError(fiber.StatusForbidden, err.Error())
 		}
+		if errors.Is(err, services.ErrDirectoryUnavailable) {
+			return nil, err.Error(), fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+		}
 
 		return nil, err.Error(), fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
@@ -4,6 +4,7 @@ package handlers
 import (
 	"github.com/gofiber/fiber/v3"
 	"server/internal/dto"
+	"server/internal/middlewares"
 	"server/internal/services"
 	"server/pkgs/logger"
 )
@@ -16,10 +17,16 @@ func (h *AuthHandler) Signup(c fiber.Ctx) (interface{}, string, error) {
 		return nil, "Invalid request body", fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	user, err := h.authService.Signup(req)
+	user, err := h.authService.Signup(c.Context(), req)
 	if err != nil {
 		logger.Log.Errorf("❌ Signup failed: %v", err)
 
+		if busyErr := hasherBusyError(c, err); busyErr != nil {
+			return nil, err.Error(), busyErr
+		}
+		if policyErr := passwordPolicyError(err); policyErr != nil {
+			return nil, policyErr.Message, policyErr
+		}
 		if err == services.ErrSignupEmailTaken {
 			return nil, err.Error(), fiber.NewError(fiber.StatusConflict, err.Error())
 		}
@@ -31,21 +38,22 @@ func (h *AuthHandler) Signup(c fiber.Ctx) (interface{}, string, error) {
 	}
 
 	logger.Log.Infof("✅ Signup successful for %s", req.Email)
-	return user, "Signup successful", nil
+	return user, "Signup successful, check your email to verify your account", nil
 }
 
 type UserManagementHandler struct {
 	userManagementService *services.UserManagementService
+	throttleService       *services.LoginThrottleService
 }
 
-func NewUserManagementHandler(userManagementService *services.UserManagementService) *UserManagementHandler {
-	return &UserManagementHandler{userManagementService: userManagementService}
+func NewUserManagementHandler(userManagementService *services.UserManagementService, throttleService *services.LoginThrottleService) *UserManagementHandler {
+	return &UserManagementHandler{userManagementService: userManagementService, throttleService: throttleService}
 }
 
 func (h *UserManagementHandler) CreateUser(c fiber.Ctx) (interface{}, string, error) {
 	logger.Log.Info("📥 Create user request received")
 
-	createdByID := c.Get("X-User-ID")
+	createdByID := middlewares.CurrentUserID(c)
 	if createdByID == "" {
 		return nil, "Usuario no autenticado", fiber.NewError(fiber.StatusUnauthorized, "Usuario no autenticado")
 	}
@@ -55,30 +63,33 @@ func (h *UserManagementHandler) CreateUser(c fiber.Ctx) (interface{}, string, er
 		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
 	}
 
-	user, err := h.userManagementService.CreateUser(req, createdByID)
+	user, err := h.userManagementService.CreateUser(c.Context(), req, createdByID)
 	if err != nil {
 		logger.Log.Errorf("❌ Create user failed: %v", err)
 
-		if err == services.ErrCreateUserEmailTaken {
+		if err == services.ErrCreateUserEmailTaken || err == services.ErrInvitationPending {
 			return nil, err.Error(), fiber.NewError(fiber.StatusConflict, err.Error())
 		}
+		if err == services.ErrPermissionDenied || err == services.ErrRoleAboveRequester || err == services.ErrCannotManageUser {
+			return nil, err.Error(), fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
 
 		return nil, err.Error(), fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	logger.Log.Infof("✅ User created: %s", user.Email)
-	return user, "Usuario creado exitosamente", nil
+	logger.Log.Infof("✅ Invitation sent: %s", user.Email)
+	return user, "Invitación enviada exitosamente", nil
 }
 
 func (h *UserManagementHandler) GetAllUsers(c fiber.Ctx) (interface{}, string, error) {
 	logger.Log.Info("📥 Get all users request received")
 
-	requestedByID := c.Get("X-User-ID")
+	requestedByID := middlewares.CurrentUserID(c)
 	if requestedByID == "" {
 		return nil, "Usuario no autenticado", fiber.NewError(fiber.StatusUnauthorized, "Usuario no autenticado")
 	}
 
-	users, err := h.userManagementService.GetAllUsers(requestedByID)
+	users, err := h.userManagementService.GetAllUsers(c.Context(), requestedByID)
 	if err != nil {
 		logger.Log.Errorf("❌ Get users failed: %v", err)
 
@@ -91,4 +102,28 @@ func (h *UserManagementHandler) GetAllUsers(c fiber.Ctx) (interface{}, string, e
 
 	logger.Log.Infof("✅ Users retrieved: %d users", len(users))
 	return users, "Usuarios obtenidos exitosamente", nil
-}
\ No newline at end of file
+}
+
+func (h *UserManagementHandler) UnlockUser(c fiber.Ctx) (interface{}, string, error) {
+	logger.Log.Info("📥 Unlock user request received")
+
+	adminID := middlewares.CurrentUserID(c)
+	if adminID == "" {
+		return nil, "Usuario no autenticado", fiber.NewError(fiber.StatusUnauthorized, "Usuario no autenticado")
+	}
+
+	if err := h.throttleService.UnlockUser(adminID, c.Params("id"), c.IP()); err != nil {
+		logger.Log.Errorf("❌ Unlock user failed: %v", err)
+
+		if err == services.ErrOnlyAdminUnlock || err == services.ErrCannotManageUser || err == services.ErrCannotManageSelf {
+			return nil, err.Error(), fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if err == services.ErrManagedUserNotFound {
+			return nil, err.Error(), fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+
+		return nil, err.Error(), fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return nil, "Cuenta desbloqueada exitosamente", nil
+}
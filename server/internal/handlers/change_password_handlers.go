@@ -2,9 +2,11 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/gofiber/fiber/v3"
 	"net/http"
 	"server/internal/dto"
+	"server/internal/middlewares"
 	"server/internal/services"
 )
 
@@ -25,12 +27,33 @@ func (h *UserHandler) UpdatePasswordByID(c fiber.Ctx) error {
 		})
 	}
 
-	err := h.changePasswordService.UpdatePasswordWithVerification(req.UserID, req.Password, req.NewPassword)
+	sessionID, scope := middlewares.CurrentSession(c)
+	meta := services.LoginMeta{IP: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+
+	session, err := h.changePasswordService.UpdatePasswordWithVerification(
+		c.Context(), middlewares.CurrentUserID(c), sessionID, scope, req.Password, req.NewPassword, meta)
 
 	if err != nil {
+		if busyErr := hasherBusyError(c, err); busyErr != nil {
+			return c.Status(busyErr.Code).JSON(fiber.Map{
+				"message": busyErr.Message,
+				"status":  busyErr.Code,
+			})
+		}
+		if policyErr := passwordPolicyError(err); policyErr != nil {
+			return c.Status(policyErr.Code).JSON(fiber.Map{
+				"data":    policyErr.Data,
+				"message": policyErr.Message,
+				"status":  policyErr.Code,
+			})
+		}
+
 		status := http.StatusBadRequest
 
-		if err.Error() == "usuario no encontrado o inactivo" {
+		if errors.Is(err, services.ErrTooManyAttempts) {
+			status = http.StatusTooManyRequests
+			setRetryAfter(c, err)
+		} else if err.Error() == "usuario no encontrado o inactivo" {
 			status = http.StatusNotFound
 		} else if err.Error() == "la contraseña actual es incorrecta" {
 			status = http.StatusUnauthorized
@@ -42,7 +65,9 @@ func (h *UserHandler) UpdatePasswordByID(c fiber.Ctx) error {
 		})
 	}
 
+	// Si la sesión era restringida se devuelve la nueva sesión
 	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"data":    session,
 		"message": "Contraseña actualizada exitosamente",
 		"status":  http.StatusOK,
 	})
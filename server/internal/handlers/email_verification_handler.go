@@ -0,0 +1,50 @@
+// server/internal/handlers/email_verification_handler.go
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/dto"
+	"server/internal/services"
+	"server/pkgs/logger"
+)
+
+type EmailVerificationHandler struct {
+	verificationService *services.EmailVerificationService
+}
+
+func NewEmailVerificationHandler(verificationService *services.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{verificationService: verificationService}
+}
+
+func (h *EmailVerificationHandler) VerifyEmail(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.VerifyEmailRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Token == "" {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	data, err := h.verificationService.Verify(c.Context(), req.Token)
+	if err != nil {
+		logger.Log.Errorf("❌ Email verification failed: %v", err)
+		if errors.Is(err, services.ErrVerificationTokenInvalid) {
+			return nil, err.Error(), fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return nil, "Error al verificar el correo", fiber.NewError(fiber.StatusInternalServerError, "Error al verificar el correo")
+	}
+
+	logger.Log.Infof("✅ Email verified: %s", data.Email)
+	return data, "Correo verificado exitosamente", nil
+}
+
+func (h *EmailVerificationHandler) ResendVerification(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.ResendVerificationRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Email == "" {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	// La respuesta es idéntica exista o no la cuenta, esté o no en espera
+	h.verificationService.Resend(c.Context(), req.Email)
+
+	return nil, "Si la cuenta existe y no está verificada, te enviamos un nuevo correo", nil
+}
@@ -0,0 +1,21 @@
+// server/internal/handlers/hasher_busy.go
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"server/pkgs/security"
+)
+
+// hasherBusyError responde 503 con Retry-After cuando la cola de hashing
+// Argon2 está saturada. Devuelve nil si el error es de otro tipo.
+func hasherBusyError(c fiber.Ctx, err error) *fiber.Error {
+	var busy *security.HasherBusyError
+	if !errors.As(err, &busy) {
+		return nil
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(busy.RetryAfter()))
+	return fiber.NewError(fiber.StatusServiceUnavailable, busy.Error())
+}
@@ -0,0 +1,87 @@
+// server/internal/handlers/invitation_handler.go
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/dto"
+	"server/internal/middlewares"
+	"server/internal/services"
+	"server/pkgs/logger"
+)
+
+type InvitationHandler struct {
+	invitationService *services.InvitationService
+}
+
+func NewInvitationHandler(invitationService *services.InvitationService) *InvitationHandler {
+	return &InvitationHandler{invitationService: invitationService}
+}
+
+func (h *InvitationHandler) Resend(c fiber.Ctx) (interface{}, string, error) {
+	requestedByID := middlewares.CurrentUserID(c)
+	if requestedByID == "" {
+		return nil, "Usuario no autenticado", fiber.NewError(fiber.StatusUnauthorized, "Usuario no autenticado")
+	}
+
+	data, err := h.invitationService.Resend(c.Context(), c.Params("id"), requestedByID)
+	if err != nil {
+		logger.Log.Errorf("❌ Resend invitation failed: %v", err)
+		return nil, err.Error(), invitationError(err)
+	}
+
+	return data, "Invitación reenviada exitosamente", nil
+}
+
+func (h *InvitationHandler) Revoke(c fiber.Ctx) (interface{}, string, error) {
+	requestedByID := middlewares.CurrentUserID(c)
+	if requestedByID == "" {
+		return nil, "Usuario no autenticado", fiber.NewError(fiber.StatusUnauthorized, "Usuario no autenticado")
+	}
+
+	if err := h.invitationService.Revoke(c.Context(), c.Params("id"), requestedByID); err != nil {
+		logger.Log.Errorf("❌ Revoke invitation failed: %v", err)
+		return nil, err.Error(), invitationError(err)
+	}
+
+	return nil, "Invitación revocada exitosamente", nil
+}
+
+func (h *InvitationHandler) Accept(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.AcceptInvitationRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Token == "" {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	user, err := h.invitationService.Accept(c.Context(), req)
+	if err != nil {
+		logger.Log.Errorf("❌ Accept invitation failed: %v", err)
+		if busyErr := hasherBusyError(c, err); busyErr != nil {
+			return nil, err.Error(), busyErr
+		}
+		if policyErr := passwordPolicyError(err); policyErr != nil {
+			return nil, policyErr.Message, policyErr
+		}
+		return nil, err.Error(), invitationError(err)
+	}
+
+	logger.Log.Infof("✅ Invitation accepted: %s", user.Email)
+	return user, "Cuenta activada, ya puedes iniciar sesión", nil
+}
+
+func invitationError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvitationNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrInvitationForbidden):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrInvitationNotPending),
+		errors.Is(err, services.ErrCreateUserEmailTaken):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvitationResendTooSoon):
+		return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+}
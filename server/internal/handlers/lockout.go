@@ -0,0 +1,25 @@
+// server/internal/handlers/lockout.go
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/services"
+)
+
+// lockoutError responde 429 con Retry-After cuando la cuenta o IP están bloqueadas
+func lockoutError(c fiber.Ctx, err error) error {
+	setRetryAfter(c, err)
+	return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+}
+
+// setRetryAfter agrega Retry-After para los handlers que arman su propia
+// respuesta JSON en lugar de devolver el error
+func setRetryAfter(c fiber.Ctx, err error) {
+	var lockout *services.LockoutError
+	if errors.As(err, &lockout) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(lockout.RetryAfter()))
+	}
+}
@@ -0,0 +1,20 @@
+// server/internal/handlers/metrics_handler.go
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"server/pkgs/security"
+)
+
+type MetricsHandler struct {
+	hashLimiter *security.HashLimiter
+}
+
+func NewMetricsHandler(hashLimiter *security.HashLimiter) *MetricsHandler {
+	return &MetricsHandler{hashLimiter: hashLimiter}
+}
+
+// Hashing expone la ocupación de la cola de hashing Argon2
+func (h *MetricsHandler) Hashing(c fiber.Ctx) (interface{}, string, error) {
+	return h.hashLimiter.Stats(), "Métricas de hashing", nil
+}
@@ -0,0 +1,93 @@
+// server/internal/handlers/office_handler.go
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/dto"
+	"server/internal/services"
+	"server/pkgs/logger"
+)
+
+type OfficeHandler struct {
+	officeService *services.OfficeService
+}
+
+func NewOfficeHandler(officeService *services.OfficeService) *OfficeHandler {
+	return &OfficeHandler{officeService: officeService}
+}
+
+// List devuelve las oficinas activas; con ?all=true incluye las desactivadas
+func (h *OfficeHandler) List(c fiber.Ctx) (interface{}, string, error) {
+	offices, err := h.officeService.List(c.Query("all") == "true")
+	if err != nil {
+		return nil, err.Error(), officeError(err)
+	}
+	return offices, "Oficinas obtenidas exitosamente", nil
+}
+
+func (h *OfficeHandler) Get(c fiber.Ctx) (interface{}, string, error) {
+	office, err := h.officeService.Get(c.Params("id"))
+	if err != nil {
+		return nil, err.Error(), officeError(err)
+	}
+	return office, "Oficina obtenida exitosamente", nil
+}
+
+func (h *OfficeHandler) Create(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.CreateOfficeRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	office, err := h.officeService.Create(req, auditMeta(c))
+	if err != nil {
+		logger.Log.Errorf("❌ Create office failed: %v", err)
+		return nil, err.Error(), officeError(err)
+	}
+
+	logger.Log.Infof("✅ Office created: %s", office.Code)
+	return office, "Oficina creada exitosamente", nil
+}
+
+func (h *OfficeHandler) Update(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.UpdateOfficeRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	office, err := h.officeService.Update(c.Params("id"), req, auditMeta(c))
+	if err != nil {
+		logger.Log.Errorf("❌ Update office failed: %v", err)
+		return nil, err.Error(), officeError(err)
+	}
+
+	return office, "Oficina actualizada exitosamente", nil
+}
+
+func (h *OfficeHandler) Delete(c fiber.Ctx) (interface{}, string, error) {
+	if err := h.officeService.Delete(c.Params("id"), auditMeta(c)); err != nil {
+		logger.Log.Errorf("❌ Delete office failed: %v", err)
+		return nil, err.Error(), officeError(err)
+	}
+
+	return nil, "Oficina eliminada exitosamente", nil
+}
+
+func officeError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrOfficeNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrOfficeCodeTaken),
+		errors.Is(err, services.ErrOfficeInUse):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrOfficeCodeRequired),
+		errors.Is(err, services.ErrOfficeNameRequired),
+		errors.Is(err, services.ErrOfficeParentInvalid),
+		errors.Is(err, services.ErrOfficeHeadInvalid):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,132 @@
+// server/internal/handlers/oidc_handler.go
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/dto"
+	"server/internal/middlewares"
+	"server/internal/services"
+	"server/pkgs/logger"
+	"server/pkgs/oidc"
+)
+
+type OIDCHandler struct {
+	oidcService *services.OIDCService
+}
+
+func NewOIDCHandler(oidcService *services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{oidcService: oidcService}
+}
+
+func (h *OIDCHandler) ListProviders(c fiber.Ctx) (interface{}, string, error) {
+	return h.oidcService.ListProviders(), "Proveedores obtenidos exitosamente", nil
+}
+
+func (h *OIDCHandler) Authorize(c fiber.Ctx) (interface{}, string, error) {
+	provider := c.Params("provider")
+	logger.Log.Infof("📥 OIDC authorize request for %s", provider)
+
+	data, err := h.oidcService.BeginAuth(c.Context(), provider, nil)
+	if err != nil {
+		return nil, err.Error(), oidcError(err)
+	}
+
+	return data, "URL de autorización generada", nil
+}
+
+func (h *OIDCHandler) Callback(c fiber.Ctx) (interface{}, string, error) {
+	provider := c.Params("provider")
+	logger.Log.Infof("📥 OIDC callback received for %s", provider)
+
+	if idpErr := c.Query("error"); idpErr != "" {
+		msg := "El proveedor rechazó la autenticación: " + idpErr
+		return nil, msg, fiber.NewError(fiber.StatusUnauthorized, msg)
+	}
+
+	req := dto.OIDCCallbackRequest{
+		Code:  c.Query("code"),
+		State: c.Query("state"),
+	}
+	if req.Code == "" || req.State == "" {
+		if err := c.Bind().JSON(&req); err != nil || req.Code == "" || req.State == "" {
+			return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+		}
+	}
+
+	meta := services.LoginMeta{IP: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	data, err := h.oidcService.CompleteAuth(c.Context(), provider, req, meta)
+	if err != nil {
+		logger.Log.Errorf("❌ OIDC callback failed: %v", err)
+		return nil, err.Error(), oidcError(err)
+	}
+
+	if data.Linked {
+		logger.Log.Infof("🔗 Provider %s linked to %s", provider, data.User.Email)
+		return data, "Cuenta vinculada exitosamente", nil
+	}
+
+	logger.Log.Infof("✅ OIDC signin successful for %s", data.User.Email)
+	return data, "Login successful", nil
+}
+
+func (h *OIDCHandler) Link(c fiber.Ctx) (interface{}, string, error) {
+	userID := middlewares.CurrentUserID(c)
+	if userID == "" {
+		return nil, "Usuario no autenticado", fiber.NewError(fiber.StatusUnauthorized, "Usuario no autenticado")
+	}
+
+	data, err := h.oidcService.BeginAuth(c.Context(), c.Params("provider"), &userID)
+	if err != nil {
+		return nil, err.Error(), oidcError(err)
+	}
+
+	return data, "URL de vinculación generada", nil
+}
+
+func (h *OIDCHandler) Unlink(c fiber.Ctx) (interface{}, string, error) {
+	userID := middlewares.CurrentUserID(c)
+	if userID == "" {
+		return nil, "Usuario no autenticado", fiber.NewError(fiber.StatusUnauthorized, "Usuario no autenticado")
+	}
+
+	if err := h.oidcService.Unlink(c.Context(), userID, c.Params("provider")); err != nil {
+		logger.Log.Errorf("❌ OIDC unlink failed: %v", err)
+		return nil, err.Error(), oidcError(err)
+	}
+
+	return nil, "Cuenta desvinculada exitosamente", nil
+}
+
+func (h *OIDCHandler) ListLinkedAccounts(c fiber.Ctx) (interface{}, string, error) {
+	userID := middlewares.CurrentUserID(c)
+	if userID == "" {
+		return nil, "Usuario no autenticado", fiber.NewError(fiber.StatusUnauthorized, "Usuario no autenticado")
+	}
+
+	accounts, err := h.oidcService.ListLinkedAccounts(userID)
+	if err != nil {
+		return nil, err.Error(), err
+	}
+
+	return accounts, "Cuentas vinculadas obtenidas exitosamente", nil
+}
+
+func oidcError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrOIDCProviderNotFound), errors.Is(err, services.ErrOIDCLinkNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrOIDCAccountLinkedToOther), errors.Is(err, services.ErrOIDCProviderAlreadyLinked),
+		errors.Is(err, services.ErrOIDCUnlinkLastMethod):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrOIDCAccountNotLinked), errors.Is(err, services.ErrUserInactive):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrOIDCStateInvalid), errors.Is(err, oidc.ErrInvalidIDToken),
+		errors.Is(err, oidc.ErrNonceMismatch), errors.Is(err, oidc.ErrExchangeFailed):
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	case errors.Is(err, oidc.ErrDiscoveryFailed), errors.Is(err, oidc.ErrIssuerMismatch):
+		return fiber.NewError(fiber.StatusBadGateway, err.Error())
+	}
+	return fiber.NewError(fiber.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,25 @@
+// server/internal/handlers/password_policy.go
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"server/pkgs/httpwrap"
+	"server/pkgs/passpolicy"
+)
+
+// passwordPolicyError convierte las infracciones de la política en una respuesta
+// 422 con los códigos en data.violations, para que el cliente los traduzca.
+// Devuelve nil si err no proviene de la política.
+func passwordPolicyError(err error) *httpwrap.DataError {
+	var policyErr *passpolicy.PolicyError
+	if !errors.As(err, &policyErr) {
+		return nil
+	}
+	return &httpwrap.DataError{
+		Code:    fiber.StatusUnprocessableEntity,
+		Message: "La contraseña no cumple la política de seguridad",
+		Data:    fiber.Map{"violations": policyErr.Violations},
+	}
+}
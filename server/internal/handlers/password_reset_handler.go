@@ -18,6 +18,7 @@ func NewPasswordResetHandler(service *services.PasswordResetService, userService
 	return &PasswordResetHandler{service: service, userService: userService}
 }
 
+// CheckUserExists es solo para administradores: revela si el correo tiene cuenta
 func (h *PasswordResetHandler) CheckUserExists(c fiber.Ctx) error {
 	var req dto.UserExistsRequestDTO
 	if err := c.Bind().JSON(&req); err != nil {
@@ -63,24 +64,15 @@ func (h *PasswordResetHandler) RequestPasswordReset(c fiber.Ctx) error {
 		})
 	}
 
-	data, err := h.service.RequestPasswordReset(req)
-	if err != nil {
-		status := http.StatusInternalServerError
-		if err.Error() == "no existe una cuenta para el correo ingresado" { // 👈 Minúscula
-			status = http.StatusNotFound
-		} else if err.Error() == "debes esperar antes de solicitar un nuevo código" { // 👈 Minúscula
-			status = http.StatusTooManyRequests
-		}
-		return c.Status(status).JSON(fiber.Map{
-			"data":    nil,
-			"message": err.Error(),
-			"status":  status,
-		})
-	}
+	req.IP = c.IP()
+	req.UserAgent = c.Get(fiber.HeaderUserAgent)
+
+	// La respuesta es idéntica exista o no la cuenta
+	data := h.service.RequestPasswordReset(c.Context(), req)
 
 	return c.Status(http.StatusOK).JSON(fiber.Map{
 		"data":    data,
-		"message": "Código generado exitosamente",
+		"message": "Si el correo está registrado, recibirás un código de verificación",
 		"status":  http.StatusOK,
 	})
 }
@@ -95,12 +87,17 @@ func (h *PasswordResetHandler) ValidateResetCode(c fiber.Ctx) error {
 		})
 	}
 
-	data, err := h.service.ValidateResetCode(req)
+	data, err := h.service.ValidateResetCode(c.Context(), req, c.IP())
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrTooManyAttempts) {
+			status = http.StatusTooManyRequests
+			setRetryAfter(c, err)
+		}
+		return c.Status(status).JSON(fiber.Map{
 			"data":    nil,
 			"message": err.Error(),
-			"status":  http.StatusBadRequest,
+			"status":  status,
 		})
 	}
 
@@ -121,7 +118,24 @@ func (h *PasswordResetHandler) ResetPassword(c fiber.Ctx) error {
 		})
 	}
 
-	if err := h.service.ResetPassword(req); err != nil {
+	req.IP = c.IP()
+	req.UserAgent = c.Get(fiber.HeaderUserAgent)
+
+	if err := h.service.ResetPassword(c.Context(), req); err != nil {
+		if busyErr := hasherBusyError(c, err); busyErr != nil {
+			return c.Status(busyErr.Code).JSON(fiber.Map{
+				"data":    nil,
+				"message": busyErr.Message,
+				"status":  busyErr.Code,
+			})
+		}
+		if policyErr := passwordPolicyError(err); policyErr != nil {
+			return c.Status(policyErr.Code).JSON(fiber.Map{
+				"data":    policyErr.Data,
+				"message": policyErr.Message,
+				"status":  policyErr.Code,
+			})
+		}
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"data":    nil,
 			"message": err.Error(),
@@ -0,0 +1,104 @@
+// server/internal/handlers/profile_handler.go
+package handlers
+
+import (
+	"errors"
+	"io"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/dto"
+	"server/internal/middlewares"
+	"server/internal/services"
+	"server/pkgs/imaging"
+	"server/pkgs/logger"
+)
+
+// avatarMaxBytes limita el tamaño del archivo subido
+const avatarMaxBytes = 2 << 20
+
+type ProfileHandler struct {
+	profileService *services.ProfileService
+}
+
+func NewProfileHandler(profileService *services.ProfileService) *ProfileHandler {
+	return &ProfileHandler{profileService: profileService}
+}
+
+func (h *ProfileHandler) Get(c fiber.Ctx) (interface{}, string, error) {
+	profile, err := h.profileService.Get(middlewares.CurrentUserID(c))
+	if err != nil {
+		return nil, err.Error(), profileError(err)
+	}
+	return profile, "Perfil obtenido exitosamente", nil
+}
+
+func (h *ProfileHandler) Update(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.UpdateProfileRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	profile, err := h.profileService.Update(c.Context(), middlewares.CurrentUserID(c), req)
+	if err != nil {
+		logger.Log.Errorf("❌ Update profile failed: %v", err)
+		return nil, err.Error(), profileError(err)
+	}
+	return profile, "Perfil actualizado exitosamente", nil
+}
+
+// UploadAvatar recibe la imagen en el campo multipart "avatar"
+func (h *ProfileHandler) UploadAvatar(c fiber.Ctx) (interface{}, string, error) {
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		return nil, "Debes adjuntar una imagen en el campo avatar", fiber.NewError(fiber.StatusBadRequest, "Debes adjuntar una imagen en el campo avatar")
+	}
+	if file.Size > avatarMaxBytes {
+		return nil, "La imagen no debe superar 2 MB", fiber.NewError(fiber.StatusRequestEntityTooLarge, "La imagen no debe superar 2 MB")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err.Error(), fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, avatarMaxBytes+1))
+	if err != nil {
+		return nil, err.Error(), fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if len(data) > avatarMaxBytes {
+		return nil, "La imagen no debe superar 2 MB", fiber.NewError(fiber.StatusRequestEntityTooLarge, "La imagen no debe superar 2 MB")
+	}
+
+	profile, err := h.profileService.UploadAvatar(c.Context(), middlewares.CurrentUserID(c), data)
+	if err != nil {
+		logger.Log.Errorf("❌ Avatar upload failed: %v", err)
+		return nil, err.Error(), profileError(err)
+	}
+	return profile, "Avatar actualizado exitosamente", nil
+}
+
+func (h *ProfileHandler) DeleteAvatar(c fiber.Ctx) (interface{}, string, error) {
+	profile, err := h.profileService.DeleteAvatar(c.Context(), middlewares.CurrentUserID(c))
+	if err != nil {
+		return nil, err.Error(), profileError(err)
+	}
+	return profile, "Avatar eliminado", nil
+}
+
+func profileError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrProfileNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, imaging.ErrUnsupportedType):
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, imaging.ErrInvalidImage),
+		errors.Is(err, imaging.ErrTooLarge),
+		errors.Is(err, services.ErrAvatarEmpty):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, services.ErrStorageNotEnabled):
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+}
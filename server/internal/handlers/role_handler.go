@@ -0,0 +1,96 @@
+// server/internal/handlers/role_handler.go
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/dto"
+	"server/internal/services"
+	"server/pkgs/logger"
+)
+
+type RoleHandler struct {
+	roleService *services.RoleService
+}
+
+func NewRoleHandler(roleService *services.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+func (h *RoleHandler) List(c fiber.Ctx) (interface{}, string, error) {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		return nil, err.Error(), roleError(err)
+	}
+	return roles, "Roles obtenidos exitosamente", nil
+}
+
+func (h *RoleHandler) Permissions(c fiber.Ctx) (interface{}, string, error) {
+	permissions, err := h.roleService.ListPermissions()
+	if err != nil {
+		return nil, err.Error(), roleError(err)
+	}
+	return permissions, "Catálogo de permisos", nil
+}
+
+func (h *RoleHandler) Create(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.CreateRoleRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	role, err := h.roleService.CreateRole(req, auditMeta(c))
+	if err != nil {
+		logger.Log.Errorf("❌ Create role failed: %v", err)
+		return nil, err.Error(), roleError(err)
+	}
+
+	logger.Log.Infof("✅ Role created: %s", role.Name)
+	return role, "Rol creado exitosamente", nil
+}
+
+func (h *RoleHandler) Update(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.UpdateRoleRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	role, err := h.roleService.UpdateRole(c.Params("id"), req, auditMeta(c))
+	if err != nil {
+		logger.Log.Errorf("❌ Update role failed: %v", err)
+		return nil, err.Error(), roleError(err)
+	}
+
+	return role, "Rol actualizado exitosamente", nil
+}
+
+func (h *RoleHandler) Delete(c fiber.Ctx) (interface{}, string, error) {
+	if err := h.roleService.DeleteRole(c.Params("id"), auditMeta(c)); err != nil {
+		logger.Log.Errorf("❌ Delete role failed: %v", err)
+		return nil, err.Error(), roleError(err)
+	}
+
+	return nil, "Rol eliminado exitosamente", nil
+}
+
+func roleError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrRoleNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrPermissionNotHeld),
+		errors.Is(err, services.ErrRoleOutOfReach):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrRoleNameTaken),
+		errors.Is(err, services.ErrRoleInUse),
+		errors.Is(err, services.ErrRoleSystem),
+		errors.Is(err, services.ErrRoleManageLockout):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrRoleNameInvalid),
+		errors.Is(err, services.ErrRoleLevelInvalid),
+		errors.Is(err, services.ErrPermissionUnknown):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+}
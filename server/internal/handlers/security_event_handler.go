@@ -0,0 +1,40 @@
+// server/internal/handlers/security_event_handler.go
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/dto"
+	"server/internal/services"
+)
+
+type SecurityEventHandler struct {
+	securityEventService *services.SecurityEventService
+}
+
+func NewSecurityEventHandler(securityEventService *services.SecurityEventService) *SecurityEventHandler {
+	return &SecurityEventHandler{securityEventService: securityEventService}
+}
+
+// List consulta el registro de eventos de seguridad.
+// Filtros: ?userId=&type=&from=&to=&page=&limit=
+func (h *SecurityEventHandler) List(c fiber.Ctx) (interface{}, string, error) {
+	var filter dto.SecurityEventFilter
+	if err := c.Bind().Query(&filter); err != nil {
+		return nil, "Filtros inválidos", fiber.NewError(fiber.StatusBadRequest, "Filtros inválidos")
+	}
+
+	page, err := h.securityEventService.List(filter)
+	if err != nil {
+		return nil, err.Error(), securityEventError(err)
+	}
+	return page, "Eventos de seguridad obtenidos exitosamente", nil
+}
+
+func securityEventError(err error) error {
+	if errors.Is(err, services.ErrSecurityEventFilter) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,96 @@
+// server/internal/handlers/service_account_handler.go
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/dto"
+	"server/internal/middlewares"
+	"server/internal/services"
+	"server/pkgs/logger"
+)
+
+type ServiceAccountHandler struct {
+	serviceAccountService *services.ServiceAccountService
+}
+
+func NewServiceAccountHandler(serviceAccountService *services.ServiceAccountService) *ServiceAccountHandler {
+	return &ServiceAccountHandler{serviceAccountService: serviceAccountService}
+}
+
+func (h *ServiceAccountHandler) List(c fiber.Ctx) (interface{}, string, error) {
+	accounts, err := h.serviceAccountService.List(c.Context(), middlewares.CurrentUserID(c))
+	if err != nil {
+		return nil, err.Error(), serviceAccountError(err)
+	}
+	return accounts, "Cuentas de servicio obtenidas exitosamente", nil
+}
+
+func (h *ServiceAccountHandler) Create(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.CreateServiceAccountRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	account, err := h.serviceAccountService.Create(c.Context(), req, auditMeta(c))
+	if err != nil {
+		logger.Log.Errorf("❌ Create service account failed: %v", err)
+		return nil, err.Error(), serviceAccountError(err)
+	}
+
+	return account, "Cuenta de servicio creada exitosamente", nil
+}
+
+func (h *ServiceAccountHandler) Deactivate(c fiber.Ctx) (interface{}, string, error) {
+	if err := h.serviceAccountService.Deactivate(c.Context(), c.Params("id"), auditMeta(c)); err != nil {
+		logger.Log.Errorf("❌ Deactivate service account failed: %v", err)
+		return nil, err.Error(), serviceAccountError(err)
+	}
+
+	return nil, "Cuenta de servicio desactivada y API keys revocadas", nil
+}
+
+// CreateKey devuelve la clave completa; no se vuelve a mostrar
+func (h *ServiceAccountHandler) CreateKey(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.CreateAPIKeyRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	key, err := h.serviceAccountService.CreateKey(c.Context(), c.Params("id"), req, auditMeta(c))
+	if err != nil {
+		logger.Log.Errorf("❌ Create API key failed: %v", err)
+		return nil, err.Error(), serviceAccountError(err)
+	}
+
+	logger.Log.Infof("🔑 API key %s issued for service account %s", key.Prefix, c.Params("id"))
+	return key, "API key creada. Guárdala ahora: no se volverá a mostrar", nil
+}
+
+func (h *ServiceAccountHandler) RevokeKey(c fiber.Ctx) (interface{}, string, error) {
+	if err := h.serviceAccountService.RevokeKey(c.Context(), c.Params("id"), c.Params("keyId"), auditMeta(c)); err != nil {
+		logger.Log.Errorf("❌ Revoke API key failed: %v", err)
+		return nil, err.Error(), serviceAccountError(err)
+	}
+
+	return nil, "API key revocada", nil
+}
+
+func serviceAccountError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrServiceAccountNotFound),
+		errors.Is(err, services.ErrAPIKeyNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrPermissionDenied),
+		errors.Is(err, services.ErrRoleAboveRequester),
+		errors.Is(err, services.ErrAPIKeyScopeInvalid):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrServiceAccountNameTaken),
+		errors.Is(err, services.ErrServiceAccountInactive),
+		errors.Is(err, services.ErrAPIKeyRevoked):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+}
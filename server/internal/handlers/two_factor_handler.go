@@ -0,0 +1,140 @@
+// server/internal/handlers/two_factor_handler.go
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/dto"
+	"server/internal/middlewares"
+	"server/internal/services"
+	"server/pkgs/logger"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService *services.TwoFactorService
+	sessionService   *services.SessionService
+}
+
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService, sessionService *services.SessionService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: twoFactorService, sessionService: sessionService}
+}
+
+func (h *TwoFactorHandler) Status(c fiber.Ctx) (interface{}, string, error) {
+	status, err := h.twoFactorService.Status(middlewares.CurrentUserID(c))
+	if err != nil {
+		return nil, err.Error(), twoFactorError(c, err)
+	}
+	return status, "Estado 2FA obtenido", nil
+}
+
+func (h *TwoFactorHandler) Enroll(c fiber.Ctx) (interface{}, string, error) {
+	data, err := h.twoFactorService.BeginEnrollment(c.Context(), middlewares.CurrentUserID(c))
+	if err != nil {
+		return nil, err.Error(), twoFactorError(c, err)
+	}
+	return data, "Escanea el código QR con tu aplicación autenticadora", nil
+}
+
+func (h *TwoFactorHandler) ConfirmEnroll(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Code == "" {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	sessionID, scope := middlewares.CurrentSession(c)
+	meta := services.LoginMeta{IP: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+
+	data, err := h.twoFactorService.ConfirmEnrollment(c.Context(), middlewares.CurrentUserID(c), sessionID, scope, req.Code, meta)
+	if err != nil {
+		logger.Log.Errorf("❌ 2FA enrollment failed: %v", err)
+		return nil, err.Error(), twoFactorError(c, err)
+	}
+
+	return data, "Autenticación en dos pasos activada. Guarda tus códigos de recuperación", nil
+}
+
+func (h *TwoFactorHandler) Disable(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Code == "" {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	if err := h.twoFactorService.Disable(c.Context(), middlewares.CurrentUserID(c), req.Code, c.IP()); err != nil {
+		return nil, err.Error(), twoFactorError(c, err)
+	}
+
+	return nil, "Autenticación en dos pasos desactivada", nil
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Code == "" {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Context(), middlewares.CurrentUserID(c), req.Code, c.IP())
+	if err != nil {
+		return nil, err.Error(), twoFactorError(c, err)
+	}
+
+	return fiber.Map{"recoveryCodes": codes}, "Códigos de recuperación regenerados", nil
+}
+
+func (h *TwoFactorHandler) Verify(c fiber.Ctx) (interface{}, string, error) {
+	logger.Log.Info("📥 2FA verify request received")
+
+	var req dto.TwoFactorVerifyRequest
+	if err := c.Bind().JSON(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	meta := services.LoginMeta{IP: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	session, err := h.twoFactorService.VerifyChallenge(c.Context(), req, meta)
+	if err != nil {
+		logger.Log.Errorf("❌ 2FA verify failed: %v", err)
+		return nil, err.Error(), twoFactorError(c, err)
+	}
+
+	logger.Log.Infof("✅ Signin successful for %s", session.Email)
+	return session, "Login successful", nil
+}
+
+func (h *TwoFactorHandler) GetPolicies(c fiber.Ctx) (interface{}, string, error) {
+	return h.twoFactorService.GetPolicies(), "Políticas 2FA obtenidas", nil
+}
+
+func (h *TwoFactorHandler) SetPolicy(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.TwoFactorPolicyRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	if err := h.twoFactorService.SetPolicy(c.Context(), middlewares.CurrentUserID(c), req); err != nil {
+		return nil, err.Error(), twoFactorError(c, err)
+	}
+
+	return h.twoFactorService.GetPolicies(), "Política 2FA actualizada", nil
+}
+
+func (h *TwoFactorHandler) Signout(c fiber.Ctx) (interface{}, string, error) {
+	sessionID, _ := middlewares.CurrentSession(c)
+	if err := h.sessionService.Revoke(sessionID); err != nil {
+		return nil, err.Error(), err
+	}
+	return nil, "Sesión cerrada", nil
+}
+
+func twoFactorError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrTooManyAttempts):
+		return lockoutError(c, err)
+	case errors.Is(err, services.ErrTwoFactorInvalidCode), errors.Is(err, services.ErrTwoFactorChallengeInvalid):
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	case errors.Is(err, services.ErrOnlyAdminPolicy), errors.Is(err, services.ErrTwoFactorRequiredByPolicy):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return fiber.NewError(fiber.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,57 @@
+// server/internal/handlers/user_impersonation_handler.go
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/dto"
+	"server/internal/middlewares"
+	"server/internal/services"
+	"server/pkgs/logger"
+)
+
+// StartImpersonation devuelve un token de acceso del usuario indicado operado
+// por el ADMIN autenticado
+func (h *UserManagementHandler) StartImpersonation(c fiber.Ctx) (interface{}, string, error) {
+	var req dto.StartImpersonationRequest
+	if len(c.Body()) > 0 {
+		if err := c.Bind().JSON(&req); err != nil {
+			return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+		}
+	}
+
+	login := services.LoginMeta{IP: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	response, err := h.userManagementService.StartImpersonation(c.Context(), c.Params("id"), req, auditMeta(c), login)
+	if err != nil {
+		logger.Log.Errorf("❌ Impersonation failed: %v", err)
+		return nil, err.Error(), impersonationError(err)
+	}
+
+	logger.Log.Warnf("🎭 User %s impersonated by %s", response.ID, middlewares.CurrentUserID(c))
+	return response, "Suplantación iniciada", nil
+}
+
+// EndImpersonation cierra la sesión de suplantación con la que se llama
+func (h *UserManagementHandler) EndImpersonation(c fiber.Ctx) (interface{}, string, error) {
+	sessionID, _ := middlewares.CurrentSession(c)
+	if err := h.userManagementService.EndImpersonation(c.Context(), sessionID, auditMeta(c)); err != nil {
+		logger.Log.Errorf("❌ End impersonation failed: %v", err)
+		return nil, err.Error(), impersonationError(err)
+	}
+
+	return nil, "Suplantación finalizada", nil
+}
+
+func impersonationError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrImpersonationNested):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrImpersonationInactive):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrNotImpersonating):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	default:
+		return userLifecycleError(err)
+	}
+}
@@ -0,0 +1,191 @@
+// server/internal/handlers/user_lifecycle_handler.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/audit"
+	"server/internal/dto"
+	"server/internal/middlewares"
+	"server/internal/services"
+	"server/pkgs/httpwrap"
+	"server/pkgs/logger"
+)
+
+func (h *UserManagementHandler) UpdateUser(c fiber.Ctx) (interface{}, string, error) {
+	logger.Log.Info("📥 Update user request received")
+
+	var req dto.UpdateUserRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	user, err := h.userManagementService.UpdateUser(c.Context(), c.Params("id"), req, auditMeta(c))
+	if err != nil {
+		logger.Log.Errorf("❌ Update user failed: %v", err)
+		return nil, err.Error(), userLifecycleError(err)
+	}
+
+	return user, "Usuario actualizado exitosamente", nil
+}
+
+func (h *UserManagementHandler) DeactivateUser(c fiber.Ctx) (interface{}, string, error) {
+	logger.Log.Info("📥 Deactivate user request received")
+
+	if err := h.userManagementService.DeactivateUser(c.Context(), c.Params("id"), auditMeta(c)); err != nil {
+		logger.Log.Errorf("❌ Deactivate user failed: %v", err)
+		return nil, err.Error(), userLifecycleError(err)
+	}
+
+	return nil, "Usuario desactivado exitosamente", nil
+}
+
+func (h *UserManagementHandler) ReactivateUser(c fiber.Ctx) (interface{}, string, error) {
+	logger.Log.Info("📥 Reactivate user request received")
+
+	if err := h.userManagementService.ReactivateUser(c.Context(), c.Params("id"), auditMeta(c)); err != nil {
+		logger.Log.Errorf("❌ Reactivate user failed: %v", err)
+		return nil, err.Error(), userLifecycleError(err)
+	}
+
+	return nil, "Usuario reactivado exitosamente", nil
+}
+
+func (h *UserManagementHandler) ResetUserPassword(c fiber.Ctx) (interface{}, string, error) {
+	logger.Log.Info("📥 Admin password reset request received")
+
+	if err := h.userManagementService.ResetUserPassword(c.Context(), c.Params("id"), auditMeta(c)); err != nil {
+		logger.Log.Errorf("❌ Admin password reset failed: %v", err)
+		return nil, err.Error(), userLifecycleError(err)
+	}
+
+	return nil, "Se envió al usuario un código para restablecer su contraseña", nil
+}
+
+func (h *UserManagementHandler) RequirePasswordChange(c fiber.Ctx) (interface{}, string, error) {
+	logger.Log.Info("📥 Require password change request received")
+
+	if err := h.userManagementService.RequirePasswordChange(c.Context(), c.Params("id"), auditMeta(c)); err != nil {
+		logger.Log.Errorf("❌ Require password change failed: %v", err)
+		return nil, err.Error(), userLifecycleError(err)
+	}
+
+	return nil, "El usuario deberá cambiar su contraseña en el próximo inicio de sesión", nil
+}
+
+func (h *UserManagementHandler) ListMemberships(c fiber.Ctx) (interface{}, string, error) {
+	memberships, err := h.userManagementService.ListMemberships(c.Context(), c.Params("id"), middlewares.CurrentUserID(c))
+	if err != nil {
+		return nil, err.Error(), userLifecycleError(err)
+	}
+
+	return memberships, "Oficinas del usuario", nil
+}
+
+func (h *UserManagementHandler) GrantMembership(c fiber.Ctx) (interface{}, string, error) {
+	logger.Log.Info("📥 Grant office membership request received")
+
+	var req dto.GrantOfficeMembershipRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	memberships, err := h.userManagementService.GrantMembership(c.Context(), c.Params("id"), req, auditMeta(c))
+	if err != nil {
+		logger.Log.Errorf("❌ Grant office membership failed: %v", err)
+		return nil, err.Error(), userLifecycleError(err)
+	}
+
+	return memberships, "Oficina asignada exitosamente", nil
+}
+
+func (h *UserManagementHandler) RevokeMembership(c fiber.Ctx) (interface{}, string, error) {
+	logger.Log.Info("📥 Revoke office membership request received")
+
+	memberships, err := h.userManagementService.RevokeMembership(c.Context(), c.Params("id"), c.Params("officeId"), auditMeta(c))
+	if err != nil {
+		logger.Log.Errorf("❌ Revoke office membership failed: %v", err)
+		return nil, err.Error(), userLifecycleError(err)
+	}
+
+	return memberships, "Oficina retirada exitosamente", nil
+}
+
+// auditMeta identifica al usuario autenticado que ejecuta el cambio; lo
+// adjuntan al contexto AuditContext y RequireAuth
+func auditMeta(c fiber.Ctx) services.AuditMeta {
+	return audit.MetaFrom(c.Context())
+}
+
+func userLifecycleError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrManagedUserNotFound),
+		errors.Is(err, services.ErrOffboardingNotFound),
+		errors.Is(err, services.ErrMembershipNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrCannotManageUser),
+		errors.Is(err, services.ErrCannotManageSelf),
+		errors.Is(err, services.ErrRoleAboveRequester):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrUserAlreadyActive),
+		errors.Is(err, services.ErrUserAlreadyInactive),
+		errors.Is(err, services.ErrUserHasCustody),
+		errors.Is(err, services.ErrMembershipExists),
+		errors.Is(err, services.ErrMembershipPrimary):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+}
+
+func (h *UserManagementHandler) OffboardingPreview(c fiber.Ctx) (interface{}, string, error) {
+	preview, err := h.userManagementService.OffboardingPreview(c.Context(), c.Params("id"), middlewares.CurrentUserID(c))
+	if err != nil {
+		logger.Log.Errorf("❌ Offboarding preview failed: %v", err)
+		return nil, err.Error(), userLifecycleError(err)
+	}
+
+	return preview, "Elementos a reasignar", nil
+}
+
+func (h *UserManagementHandler) Offboard(c fiber.Ctx) (interface{}, string, error) {
+	logger.Log.Info("📥 Offboarding request received")
+
+	var req dto.OffboardingRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return nil, "Datos inválidos", fiber.NewError(fiber.StatusBadRequest, "Datos inválidos")
+	}
+
+	result, err := h.userManagementService.Offboard(c.Context(), c.Params("id"), req, auditMeta(c))
+	if err != nil {
+		logger.Log.Errorf("❌ Offboarding failed: %v", err)
+
+		var incomplete *services.OffboardingIncompleteError
+		if errors.As(err, &incomplete) {
+			return nil, err.Error(), &httpwrap.DataError{
+				Code:    fiber.StatusUnprocessableEntity,
+				Message: err.Error(),
+				Data:    incomplete,
+			}
+		}
+		return nil, err.Error(), userLifecycleError(err)
+	}
+
+	logger.Log.Infof("✅ User %s offboarded", result.UserID)
+	return result, "Baja completada y acta de entrega generada", nil
+}
+
+// OffboardingDocument descarga la última acta de entrega en texto plano
+func (h *UserManagementHandler) OffboardingDocument(c fiber.Ctx) error {
+	record, err := h.userManagementService.OffboardingDocument(c.Context(), c.Params("id"), middlewares.CurrentUserID(c))
+	if err != nil {
+		return userLifecycleError(err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/plain; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="acta-entrega-%s.txt"`, record.CreatedAt.Format("20060102-150405")))
+	return c.SendString(record.Document)
+}
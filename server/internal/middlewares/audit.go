@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"server/internal/audit"
+)
+
+// AuditContext adjunta al contexto de la petición la IP y el identificador
+// de request con los que se registran sus cambios en la auditoría. RequireAuth
+// completa el autor. Debe ir después de requestid.
+func AuditContext() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		c.SetContext(audit.WithMeta(c.Context(), audit.Meta{
+			IP:        c.IP(),
+			RequestID: requestid.FromContext(c),
+		}))
+		return c.Next()
+	}
+}
+
+// attachActor agrega al contexto el usuario autenticado y, bajo suplantación,
+// el usuario real. Los servicios lo leen con db.WithContext(c.Context()).
+func attachActor(c fiber.Ctx) {
+	meta := audit.MetaFrom(c.Context())
+	meta.ActorID = CurrentUserID(c)
+	meta.ImpersonatorID = CurrentImpersonatorID(c)
+	if meta.IP == "" {
+		meta.IP = c.IP()
+	}
+	if meta.RequestID == "" {
+		meta.RequestID = requestid.FromContext(c)
+	}
+	c.SetContext(audit.WithMeta(c.Context(), meta))
+}
@@ -0,0 +1,163 @@
+package middlewares
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"server/internal/models"
+	"server/internal/services"
+)
+
+// Claves de c.Locals definidas por RequireAuth
+const (
+	LocalUserID    = "userID"
+	LocalUserRole  = "userRole"
+	LocalSessionID = "sessionID"
+	LocalScope     = "sessionScope"
+
+	// Usuario real cuando la sesión es una suplantación
+	LocalImpersonatorID = "impersonatorID"
+
+	// API key con la que se autenticó una cuenta de servicio y sus scopes
+	LocalAPIKeyID     = "apiKeyID"
+	LocalAPIKeyScopes = "apiKeyScopes"
+)
+
+// Cabeceras de respuesta que marcan una sesión de suplantación
+const (
+	HeaderImpersonatedBy       = "X-Impersonated-By"
+	HeaderImpersonationExpires = "X-Impersonation-Expires"
+)
+
+// RequireAuth valida el token Bearer de sesión y expone el usuario en c.Locals
+// y, como autor de los cambios auditados, en el contexto de la petición.
+// Las sesiones restringidas solo pueden acceder a las rutas permitidas para su scope.
+// Las de suplantación se marcan en las cabeceras. El token también puede ser
+// una API key de cuenta de servicio.
+func RequireAuth(sessions *services.SessionService) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+		if header == "" || token == header {
+			return fiber.NewError(fiber.StatusUnauthorized, "Usuario no autenticado")
+		}
+
+		if services.IsAPIKey(token) {
+			return requireAPIKey(c, sessions, token)
+		}
+
+		claims, err := sessions.Validate(token)
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		}
+
+		if !services.ScopeAllows(claims.Scope, c.Path()) {
+			return fiber.NewError(fiber.StatusForbidden, "Tu sesión no permite acceder a este recurso")
+		}
+
+		c.Locals(LocalUserID, claims.Subject)
+		c.Locals(LocalUserRole, claims.Role)
+		c.Locals(LocalSessionID, claims.SessionID)
+		c.Locals(LocalScope, claims.Scope)
+
+		if claims.Actor != nil {
+			c.Locals(LocalImpersonatorID, claims.Actor.Subject)
+			c.Set(HeaderImpersonatedBy, claims.Actor.Subject)
+			c.Set(HeaderImpersonationExpires, claims.ExpiresAt.UTC().Format(time.RFC3339))
+		}
+		attachActor(c)
+
+		return c.Next()
+	}
+}
+
+// requireAPIKey autentica una cuenta de servicio. La petición opera con el
+// rol de la cuenta, limitado a los scopes de la clave en RequirePermission.
+func requireAPIKey(c fiber.Ctx, sessions *services.SessionService, key string) error {
+	principal, err := sessions.ValidateAPIKey(key, c.IP())
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	c.Locals(LocalUserID, principal.UserID)
+	c.Locals(LocalUserRole, string(principal.Role))
+	c.Locals(LocalScope, services.SessionScopeFull)
+	c.Locals(LocalAPIKeyID, principal.KeyID)
+	c.Locals(LocalAPIKeyScopes, principal.Scopes)
+	// Los servicios leen los scopes del contexto al comprobar permisos
+	c.SetContext(services.WithAPIKeyScopes(c.Context(), principal.Scopes))
+	attachActor(c)
+
+	return c.Next()
+}
+
+// RequireNotImpersonating marca las rutas que cambian credenciales (propias o
+// ajenas) o emiten nuevas: una sesión de suplantación o una API key no puede
+// usarlas. Debe ir después de RequireAuth.
+func RequireNotImpersonating() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if CurrentImpersonatorID(c) != "" {
+			return fiber.NewError(fiber.StatusForbidden, "Una sesión de suplantación no puede cambiar credenciales")
+		}
+		if CurrentAPIKeyID(c) != "" {
+			return fiber.NewError(fiber.StatusForbidden, "Una API key no puede cambiar credenciales")
+		}
+		return c.Next()
+	}
+}
+
+// RequireFullSession rechaza las sesiones restringidas (cambio de contraseña
+// o enrolamiento 2FA pendiente) aunque la ruta quedara a su alcance. Debe ir
+// después de RequireAuth.
+func RequireFullSession() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if _, scope := CurrentSession(c); scope != services.SessionScopeFull {
+			return fiber.NewError(fiber.StatusForbidden, "Tu sesión no permite acceder a este recurso")
+		}
+		return c.Next()
+	}
+}
+
+// RequirePermission limita la ruta a los roles que tienen el permiso indicado.
+// Con API key, el permiso además debe estar entre los scopes de la clave.
+// Debe ir después de RequireAuth.
+func RequirePermission(authz *services.Authorizer, permission string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		role := models.Rol(fiber.Locals[string](c, LocalUserRole))
+		ok, err := authz.Can(role, permission)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		if ok && CurrentAPIKeyID(c) != "" {
+			ok = slices.Contains(fiber.Locals[[]string](c, LocalAPIKeyScopes), permission)
+		}
+		if !ok {
+			return fiber.NewError(fiber.StatusForbidden, "No tienes permisos para acceder a este recurso")
+		}
+		return c.Next()
+	}
+}
+
+// CurrentUserID devuelve el usuario autenticado por RequireAuth
+func CurrentUserID(c fiber.Ctx) string {
+	return fiber.Locals[string](c, LocalUserID)
+}
+
+// CurrentImpersonatorID devuelve el usuario real si la sesión es una
+// suplantación, o "" en una sesión normal
+func CurrentImpersonatorID(c fiber.Ctx) string {
+	return fiber.Locals[string](c, LocalImpersonatorID)
+}
+
+// CurrentAPIKeyID devuelve la API key de la petición, o "" si se autenticó
+// con una sesión
+func CurrentAPIKeyID(c fiber.Ctx) string {
+	return fiber.Locals[string](c, LocalAPIKeyID)
+}
+
+// CurrentSession devuelve el identificador y scope de la sesión actual
+func CurrentSession(c fiber.Ctx) (string, string) {
+	return fiber.Locals[string](c, LocalSessionID), fiber.Locals[string](c, LocalScope)
+}
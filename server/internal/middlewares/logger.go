@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -24,8 +25,20 @@ func LoggerMiddleware() fiber.Handler {
 			"latency":   latency,
 			"ip":        c.IP(),
 			"userAgent": c.Get("User-Agent"),
+			"requestId": requestid.FromContext(c),
 		})
 
+		// Usuario efectivo y, bajo suplantación, el usuario real
+		if userID := fiber.Locals[string](c, LocalUserID); userID != "" {
+			entry = entry.WithField("userId", userID)
+		}
+		if impersonatorID := fiber.Locals[string](c, LocalImpersonatorID); impersonatorID != "" {
+			entry = entry.WithField("impersonatorId", impersonatorID)
+		}
+		if apiKeyID := fiber.Locals[string](c, LocalAPIKeyID); apiKeyID != "" {
+			entry = entry.WithField("apiKeyId", apiKeyID)
+		}
+
 		if err != nil {
 			entry.Error(err)
 		} else {
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+	"server/pkgs/security"
+)
+
+func init() {
+	schema.RegisterSerializer("encrypted", EncryptedSerializer{})
+}
+
+var ErrEncryptedRowKey = errors.New("la fila no tiene clave primaria para autenticar el cifrado")
+
+// EncryptedSerializer cifra la columna con security.Fields al guardar y la
+// descifra al leer. Se usa con `gorm:"serializer:encrypted"` sobre campos
+// string o *string; la tabla, la columna y la clave primaria de la fila se
+// autentican junto al valor, así un valor no puede copiarse a otra fila. La
+// clave primaria debe existir antes de guardar y leerse antes que la columna.
+type EncryptedSerializer struct{}
+
+func (EncryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType)
+
+	if dbValue != nil {
+		var stored string
+		switch v := dbValue.(type) {
+		case []byte:
+			stored = string(v)
+		case string:
+			stored = v
+		default:
+			return fmt.Errorf("columna cifrada %s: tipo no soportado %T", field.DBName, dbValue)
+		}
+
+		if security.Fields == nil {
+			return security.ErrFieldCipherDisabled
+		}
+		rowContext, err := encryptionContext(ctx, field, dst)
+		if err != nil {
+			return fmt.Errorf("columna cifrada %s: %w", field.DBName, err)
+		}
+		plain, err := security.Fields.Decrypt(stored, rowContext)
+		if errors.Is(err, security.ErrFieldCiphertext) {
+			// Valor cifrado antes de ligarlo a la fila; cmd/reencrypt lo actualiza
+			plain, err = security.Fields.Decrypt(stored, LegacyFieldContext(field.Schema.Table, field.DBName))
+		}
+		if err != nil {
+			return fmt.Errorf("columna cifrada %s: %w", field.DBName, err)
+		}
+
+		if field.FieldType.Kind() == reflect.Ptr {
+			ptr := reflect.New(field.FieldType.Elem())
+			ptr.Elem().SetString(plain)
+			fieldValue.Elem().Set(ptr)
+		} else {
+			fieldValue.Elem().SetString(plain)
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+func (EncryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var plain string
+	switch v := fieldValue.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		plain = v
+	case *string:
+		if v == nil {
+			return nil, nil
+		}
+		plain = *v
+	default:
+		return nil, fmt.Errorf("columna cifrada %s: tipo no soportado %T", field.DBName, fieldValue)
+	}
+
+	if security.Fields == nil {
+		return nil, security.ErrFieldCipherDisabled
+	}
+	rowContext, err := encryptionContext(ctx, field, dst)
+	if err != nil {
+		return nil, fmt.Errorf("columna cifrada %s: %w", field.DBName, err)
+	}
+	return security.Fields.Encrypt(plain, rowContext)
+}
+
+// FieldContext es el dato autenticado junto a un valor cifrado: tabla,
+// columna y clave primaria de la fila
+func FieldContext(table, column string, id interface{}) string {
+	return fmt.Sprintf("%s.%s:%v", table, column, id)
+}
+
+// LegacyFieldContext es el dato autenticado de los valores cifrados antes de
+// incluir la clave primaria
+func LegacyFieldContext(table, column string) string {
+	return table + "." + column
+}
+
+// encryptionContext liga el cifrado a la tabla, la columna y la fila del campo
+func encryptionContext(ctx context.Context, field *schema.Field, dst reflect.Value) (string, error) {
+	pk := field.Schema.PrioritizedPrimaryField
+	if pk == nil {
+		return "", ErrEncryptedRowKey
+	}
+	id, zero := pk.ValueOf(ctx, dst)
+	if zero {
+		return "", ErrEncryptedRowKey
+	}
+	return FieldContext(field.Schema.Table, field.DBName, id), nil
+}
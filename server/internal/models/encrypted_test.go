@@ -0,0 +1,95 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+	"server/pkgs/security"
+)
+
+func accountField(t *testing.T, name string) *schema.Field {
+	t.Helper()
+	s, err := schema.Parse(&Account{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.LookUpField(name)
+}
+
+func initTestCipher(t *testing.T) {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	previous := security.Fields
+	t.Cleanup(func() { security.Fields = previous })
+	if err := security.InitFieldCipher(security.FieldKeyConfig{Keys: "1:" + base64.StdEncoding.EncodeToString(key)}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncryptedValueIsBoundToRow(t *testing.T) {
+	initTestCipher(t)
+	ctx := context.Background()
+	field := accountField(t, "AccessToken")
+	token := "access-token"
+
+	owner := &Account{ID: "11111111-1111-1111-1111-111111111111", AccessToken: &token}
+	stored, err := field.Serializer.Value(ctx, field, reflect.ValueOf(owner).Elem(), owner.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var read Account
+	read.ID = owner.ID
+	if err := field.Serializer.Scan(ctx, field, reflect.ValueOf(&read).Elem(), stored); err != nil {
+		t.Fatalf("la misma fila debe descifrar: %v", err)
+	}
+	if read.AccessToken == nil || *read.AccessToken != token {
+		t.Fatalf("valor descifrado inesperado: %v", read.AccessToken)
+	}
+
+	// Copiado a otra cuenta, el valor ya no autentica
+	other := Account{ID: "22222222-2222-2222-2222-222222222222"}
+	err = field.Serializer.Scan(ctx, field, reflect.ValueOf(&other).Elem(), stored)
+	if !errors.Is(err, security.ErrFieldCiphertext) {
+		t.Fatalf("se esperaba ErrFieldCiphertext al copiar el valor a otra fila, se obtuvo %v", err)
+	}
+}
+
+func TestEncryptedValueReadsLegacyContext(t *testing.T) {
+	initTestCipher(t)
+	ctx := context.Background()
+	field := accountField(t, "RefreshToken")
+
+	legacy, err := security.Fields.Encrypt("refresh-token", LegacyFieldContext("accounts", "refresh_token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := Account{ID: "33333333-3333-3333-3333-333333333333"}
+	if err := field.Serializer.Scan(ctx, field, reflect.ValueOf(&account).Elem(), legacy); err != nil {
+		t.Fatalf("los valores previos deben seguir leyéndose hasta re-cifrarlos: %v", err)
+	}
+	if account.RefreshToken == nil || *account.RefreshToken != "refresh-token" {
+		t.Fatalf("valor descifrado inesperado: %v", account.RefreshToken)
+	}
+}
+
+func TestEncryptedValueRequiresPrimaryKey(t *testing.T) {
+	initTestCipher(t)
+	field := accountField(t, "IDToken")
+	token := "id-token"
+	account := &Account{IDToken: &token}
+
+	_, err := field.Serializer.Value(context.Background(), field, reflect.ValueOf(account).Elem(), account.IDToken)
+	if !errors.Is(err, ErrEncryptedRowKey) {
+		t.Fatalf("sin ID se esperaba ErrEncryptedRowKey, se obtuvo %v", err)
+	}
+}
@@ -1,8 +1,10 @@
 package models
 
 import (
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -15,12 +17,64 @@ const (
 	RolEmployee Rol = "EMPLOYEE"
 )
 
-type Office string
+// Permisos del catálogo. Los roles los reciben por role_permissions y los
+// servicios los consultan en lugar de comparar el Rol.
+const (
+	PermUserCreate        = "user.create"
+	PermUserList          = "user.list"
+	PermUserUpdate        = "user.update"
+	PermUserRoleAssign    = "user.role.assign"
+	PermUserOfficeAssign  = "user.office.assign"
+	PermUserDeactivate    = "user.deactivate"
+	PermUserPasswordReset = "user.password.reset"
+	PermUserUnlock        = "user.unlock"
+	PermUserOffboard      = "user.offboard"
+	PermUserLookup        = "user.lookup"
+	PermUserManage        = "user.manage"
+	PermUserManageAll     = "user.manage.all"
+	PermUserImpersonate   = "user.impersonate"
+
+	PermServiceAccountManage = "service_account.manage"
+
+	PermAssetCreate          = "asset.create"
+	PermAssetUpdate          = "asset.update"
+	PermAssetDelete          = "asset.delete"
+	PermAssetTransfer        = "asset.transfer"
+	PermAssetTransferApprove = "asset.transfer.approve"
+
+	PermOfficeManage         = "office.manage"
+	PermRoleManage           = "role.manage"
+	PermSecurityPolicyManage = "security.policy.manage"
+	PermMetricsView          = "metrics.view"
+	PermSecurityEventRead    = "security_event.read"
+)
+
+type SecurityEventType string
+
+const (
+	SecurityEventAccountLocked    SecurityEventType = "ACCOUNT_LOCKED"
+	SecurityEventIPLocked         SecurityEventType = "IP_LOCKED"
+	SecurityEventAccountUnlocked  SecurityEventType = "ACCOUNT_UNLOCKED"
+	SecurityEventResetCodeLocked  SecurityEventType = "RESET_CODE_LOCKED"
+	SecurityEventTwoFactorOn      SecurityEventType = "TWO_FACTOR_ENABLED"
+	SecurityEventTwoFactorOff     SecurityEventType = "TWO_FACTOR_DISABLED"
+	SecurityEventRecoveryUsed     SecurityEventType = "RECOVERY_CODE_USED"
+	SecurityEventImpersonationOn  SecurityEventType = "IMPERSONATION_STARTED"
+	SecurityEventImpersonationOff SecurityEventType = "IMPERSONATION_ENDED"
+	SecurityEventLoginFailed      SecurityEventType = "LOGIN_FAILED"
+	SecurityEventLoginSucceeded   SecurityEventType = "LOGIN_SUCCEEDED"
+	SecurityEventNewDeviceLogin   SecurityEventType = "NEW_DEVICE_LOGIN"
+	SecurityEventPasswordChanged  SecurityEventType = "PASSWORD_CHANGED"
+	SecurityEventResetRequested   SecurityEventType = "PASSWORD_RESET_REQUESTED"
+	SecurityEventRoleChanged      SecurityEventType = "ROLE_CHANGED"
+)
+
+type InvitationStatus string
 
 const (
-	OfficeOTIC           Office = "OTIC"
-	OfficePatrimonio     Office = "PATRIMONIO"
-	OfficeAbastecimiento Office = "ABASTECIMIENTO"
+	InvitationPending  InvitationStatus = "PENDING"
+	InvitationAccepted InvitationStatus = "ACCEPTED"
+	InvitationRevoked  InvitationStatus = "REVOKED"
 )
 
 // ======= BASE =======
@@ -40,7 +94,7 @@ type User struct {
 	Image         *string
 	Password      *string
 	Rol           Rol     `gorm:"type:varchar(20);default:'EMPLOYEE'"`
-	Office        *Office `gorm:"type:varchar(50)"`
+	OfficeID      *string `gorm:"type:uuid;index"`
 	Phone         *string
 	IsActive      bool `gorm:"default:true"`
 	LastLogin     *time.Time
@@ -48,13 +102,35 @@ type User struct {
 	LastDevice    *string   `gorm:"type:varchar(255)"`
 	LastOS        *string   `gorm:"type:varchar(100)"`
 	LastLocation  *string   `gorm:"type:varchar(255)"`
+	LastCountry   *string   `gorm:"type:varchar(2)"`
 	CreatedAt     time.Time `gorm:"autoCreateTime"`
 	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
 
+	// 🔹 Autenticación en dos pasos (TOTP)
+	TwoFactorEnabled  bool `gorm:"default:false"`
+	TwoFactorSecret   *string
+	TwoFactorLastStep int64 `gorm:"default:0"`
+
+	// 🔹 Política de contraseña: cambio obligatorio y antigüedad
+	MustChangePassword bool `gorm:"default:false"`
+	PasswordChangedAt  *time.Time
+
+	// 🔹 Avatar subido por el usuario (prefijo de las variantes en el storage)
+	AvatarKey *string
+
+	// 🔹 Cuenta de servicio: sin contraseña, solo se autentica con API keys
+	IsServiceAccount bool `gorm:"default:false;index"`
+
 	// 🔹 Usuario que creó este registro
 	CreatedByID *string `gorm:"type:uuid;index"`
 	CreatedBy   *User   `gorm:"foreignKey:CreatedByID;constraint:OnDelete:SET NULL"`
 
+	// 🔹 Oficina a la que pertenece
+	Office *Office `gorm:"foreignKey:OfficeID;constraint:OnDelete:SET NULL"`
+
+	// 🔹 Oficinas adicionales en las que participa
+	Memberships []OfficeMembership `gorm:"foreignKey:UserID"`
+
 	// 🔹 Relaciones con otras tablas
 	Accounts            []Account
 	Sessions            []Session
@@ -63,41 +139,161 @@ type User struct {
 	ManagedEmployees    []User  `gorm:"foreignKey:CreatedByID"`
 }
 
+// BeforeSave guarda el correo en minúsculas y sin espacios; el índice único
+// sobre LOWER(email) impide además dos cuentas que solo difieran en mayúsculas
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+	return nil
+}
+
+// ======= OFFICE =======
+// Oficina o dependencia administrada por ADMIN. HeadUserID no declara la
+// relación para evitar el ciclo con users; la FK se crea en la migración.
+type Office struct {
+	ID         string    `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Code       string    `gorm:"type:varchar(50);uniqueIndex;not null"`
+	Name       string    `gorm:"type:varchar(150);not null"`
+	ParentID   *string   `gorm:"type:uuid;index"`
+	HeadUserID *string   `gorm:"type:uuid;index"`
+	IsActive   bool      `gorm:"default:true"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+
+	Parent *Office `gorm:"foreignKey:ParentID;constraint:OnDelete:SET NULL"`
+}
+
+// ======= ROLE =======
+// Rol configurable. Name es el valor guardado en User.Rol y en el token de
+// sesión; Level ordena la jerarquía (solo se gestiona a roles de nivel menor).
+type Role struct {
+	ID          string    `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name        Rol       `gorm:"type:varchar(20);uniqueIndex;not null"`
+	Description string    `gorm:"type:varchar(255)"`
+	Level       int       `gorm:"not null;default:0"`
+	IsSystem    bool      `gorm:"default:false"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+
+	Permissions []Permission `gorm:"many2many:role_permissions;constraint:OnDelete:CASCADE"`
+}
+
+// ======= PERMISSION =======
+// Entrada del catálogo de permisos; el código es la clave
+type Permission struct {
+	Code        string `gorm:"type:varchar(100);primaryKey"`
+	Description string `gorm:"type:varchar(255)"`
+}
+
+// ======= OFFICE MEMBERSHIP =======
+// Pertenencia de un usuario a una oficina distinta de la principal (User.OfficeID),
+// con el rol que cumple en ella. Un MANAGER puede supervisar varias oficinas.
+type OfficeMembership struct {
+	ID          string    `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID      string    `gorm:"type:uuid;not null;uniqueIndex:idx_membership_user_office"`
+	OfficeID    string    `gorm:"type:uuid;not null;uniqueIndex:idx_membership_user_office;index"`
+	Rol         Rol       `gorm:"type:varchar(20);not null"`
+	GrantedByID *string   `gorm:"type:uuid"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+
+	User      User   `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Office    Office `gorm:"foreignKey:OfficeID;constraint:OnDelete:CASCADE"`
+	GrantedBy *User  `gorm:"foreignKey:GrantedByID;constraint:OnDelete:SET NULL"`
+}
+
+// ======= API KEY =======
+// Clave de una cuenta de servicio. Solo se guarda el hash; Prefix es la parte
+// pública de la clave y permite localizarla. Permissions son los scopes: un
+// subconjunto de los permisos del rol de la cuenta.
+type APIKey struct {
+	ID          string     `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID      string     `gorm:"type:uuid;not null;index"`
+	Name        string     `gorm:"type:varchar(100);not null"`
+	Prefix      string     `gorm:"type:varchar(20);uniqueIndex;not null"`
+	KeyHash     string     `gorm:"type:varchar(64);not null"`
+	ExpiresAt   *time.Time `gorm:"index"`
+	LastUsedAt  *time.Time
+	LastUsedIP  *string `gorm:"type:varchar(50)"`
+	RevokedAt   *time.Time
+	CreatedByID *string   `gorm:"type:uuid"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+
+	User        User         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	CreatedBy   *User        `gorm:"foreignKey:CreatedByID;constraint:OnDelete:SET NULL"`
+	Permissions []Permission `gorm:"many2many:api_key_permissions;constraint:OnDelete:CASCADE"`
+}
+
 // ======= ACCOUNT =======
+// Los tokens del proveedor se guardan cifrados (ver EncryptedSerializer)
 type Account struct {
 	ID                string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	UserID            string `gorm:"type:uuid;not null"`
+	UserID            string `gorm:"type:uuid;not null;index"`
 	Type              string
-	Provider          string
-	ProviderAccountID string
-	RefreshToken      *string `gorm:"type:text"`
-	AccessToken       *string `gorm:"type:text"`
+	Provider          string  `gorm:"uniqueIndex:idx_account_provider"`
+	ProviderAccountID string  `gorm:"uniqueIndex:idx_account_provider"`
+	RefreshToken      *string `gorm:"type:text;serializer:encrypted"`
+	AccessToken       *string `gorm:"type:text;serializer:encrypted"`
 	ExpiresAt         *int
 	TokenType         *string
 	Scope             *string
-	IDToken           *string `gorm:"type:text"`
+	IDToken           *string `gorm:"type:text;serializer:encrypted"`
 	SessionState      *string
 
 	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
 
+// BeforeCreate asigna el ID antes de insertar: los tokens cifrados se ligan
+// a la clave primaria, así que no puede esperar al valor por defecto de la base
+func (a *Account) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.NewString()
+	}
+	return nil
+}
+
 // ======= SESSION =======
 type Session struct {
 	ID           string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 	SessionToken string `gorm:"uniqueIndex;not null"`
-	UserID       string `gorm:"type:uuid;not null"`
+	UserID       string `gorm:"type:uuid;not null;index"`
 	Expires      time.Time
-	User         User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Scope        string    `gorm:"type:varchar(30);default:'full'"`
+	IP           *string   `gorm:"type:varchar(50)"`
+	UserAgent    *string   `gorm:"type:varchar(255)"`
+	Location     *string   `gorm:"type:varchar(255)"`
+	Country      *string   `gorm:"type:varchar(2)"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	User         User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+
+	// 🔹 Suplantación: ADMIN que abrió la sesión en nombre del usuario
+	ImpersonatorID *string `gorm:"type:uuid;index"`
+	Impersonator   *User   `gorm:"foreignKey:ImpersonatorID;constraint:OnDelete:CASCADE"`
+}
+
+// ======= OAUTH STATE =======
+// Estado temporal del flujo authorization-code + PKCE
+type OAuthState struct {
+	ID           string  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	State        string  `gorm:"uniqueIndex;not null"`
+	Provider     string  `gorm:"type:varchar(50);not null"`
+	CodeVerifier string  `gorm:"not null"`
+	Nonce        string  `gorm:"not null"`
+	LinkUserID   *string `gorm:"type:uuid"`
+	Expires      time.Time
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
 }
 
 // ======= VERIFICATION TOKEN =======
+// Token guarda el hash SHA-256 del valor enviado por correo; se elimina al usarse
 type VerificationToken struct {
-	Identifier string `gorm:"not null"`
+	Identifier string `gorm:"not null;index"`
 	Token      string `gorm:"uniqueIndex;not null"`
 	Expires    time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
 }
 
 // ======= PASSWORD RESET TOKEN =======
+// Code y Token guardan solo el hash SHA-256 (ligado al usuario) del código
+// enviado por correo y del token entregado tras validarlo
 type PasswordResetToken struct {
 	ID          string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 	UserID      string `gorm:"type:uuid;not null"`
@@ -109,11 +305,131 @@ type PasswordResetToken struct {
 	CreatedAt   time.Time `gorm:"autoCreateTime"`
 	IsUsed      bool      `gorm:"default:false"`
 	IsValidated bool      `gorm:"default:false"`
+	Attempts    int       `gorm:"default:0"`
 	UsedAt      *time.Time
 
 	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
 
+// ======= RECOVERY CODE =======
+// Códigos de recuperación 2FA de un solo uso (solo se guarda el hash)
+type RecoveryCode struct {
+	ID        string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    string `gorm:"type:uuid;not null;index"`
+	CodeHash  string `gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// ======= TWO FACTOR POLICY =======
+// Política 2FA obligatoria por rol
+type TwoFactorPolicy struct {
+	Rol         Rol       `gorm:"type:varchar(20);primaryKey"`
+	Required    bool      `gorm:"default:false"`
+	UpdatedByID *string   `gorm:"type:uuid"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// ======= AUTH THROTTLE =======
+// Contador de intentos fallidos por cuenta (correo) o por IP
+type AuthThrottle struct {
+	ID            string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Scope         string `gorm:"type:varchar(20);not null;uniqueIndex:idx_throttle_scope_identifier"`
+	Identifier    string `gorm:"type:varchar(255);not null;uniqueIndex:idx_throttle_scope_identifier"`
+	Failures      int    `gorm:"default:0"`
+	LockoutCount  int    `gorm:"default:0"`
+	LastFailureAt *time.Time
+	LockedUntil   *time.Time
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+}
+
+// ======= SECURITY EVENT =======
+// Registro de eventos de seguridad (solo inserción)
+type SecurityEvent struct {
+	ID        string            `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Type      SecurityEventType `gorm:"type:varchar(50);not null;index"`
+	UserID    *string           `gorm:"type:uuid;index"`
+	Email     *string           `gorm:"type:varchar(255)"`
+	IP        *string           `gorm:"type:varchar(50)"`
+	UserAgent *string           `gorm:"type:varchar(255)"`
+	Details   *string           `gorm:"type:text"`
+	CreatedAt time.Time         `gorm:"autoCreateTime;index"`
+}
+
+// ======= INVITATION =======
+// Invitación emitida por un ADMIN o MANAGER; el usuario se crea al aceptarla
+type Invitation struct {
+	ID          string  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Email       string  `gorm:"type:varchar(255);not null;index"`
+	Name        *string `gorm:"type:varchar(100)"`
+	Rol         Rol     `gorm:"type:varchar(20);not null"`
+	OfficeID    *string `gorm:"type:uuid;index"`
+	Phone       *string
+	TokenHash   string           `gorm:"type:varchar(64);uniqueIndex;not null"`
+	Status      InvitationStatus `gorm:"type:varchar(20);default:'PENDING';index"`
+	Expires     time.Time
+	LastSentAt  time.Time
+	InvitedByID string  `gorm:"type:uuid;not null;index"`
+	UserID      *string `gorm:"type:uuid"`
+	AcceptedAt  *time.Time
+	RevokedAt   *time.Time
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+
+	InvitedBy User    `gorm:"foreignKey:InvitedByID;constraint:OnDelete:CASCADE"`
+	Office    *Office `gorm:"foreignKey:OfficeID;constraint:OnDelete:SET NULL"`
+}
+
+// ======= PASSWORD HISTORY =======
+// Últimos hashes de contraseña de cada usuario, para impedir su reutilización
+type PasswordHistory struct {
+	ID        string    `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    string    `gorm:"type:uuid;not null;index"`
+	Hash      string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// ======= AUDIT LOG =======
+// Registro de cambios: quién hizo qué sobre qué entidad. Es de solo inserción
+// y cada fila guarda el hash de la anterior (ver internal/audit).
+type AuditLog struct {
+	ID             string    `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ActorID        *string   `gorm:"type:uuid;index"`
+	ImpersonatorID *string   `gorm:"type:uuid;index"`
+	Action         string    `gorm:"type:varchar(50);not null;index"`
+	EntityType     string    `gorm:"type:varchar(50);not null;index:idx_audit_entity"`
+	EntityID       string    `gorm:"type:varchar(255);not null;index:idx_audit_entity"`
+	Changes        *string   `gorm:"type:text"`
+	Before         *string   `gorm:"type:text"`
+	After          *string   `gorm:"type:text"`
+	IP             *string   `gorm:"type:varchar(50)"`
+	RequestID      *string   `gorm:"type:varchar(64);index"`
+	CreatedAt      time.Time `gorm:"index"`
+
+	// 🔹 Cadena de hashes
+	Sequence *int64 `gorm:"uniqueIndex"`
+	PrevHash string `gorm:"type:varchar(64);not null;default:''"`
+	Hash     string `gorm:"type:varchar(64);not null;default:''"`
+}
+
+// ======= OFFBOARDING RECORD =======
+// Acta de entrega generada al dar de baja a un usuario
+type OffboardingRecord struct {
+	ID            string    `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID        string    `gorm:"type:uuid;not null;index"`
+	PerformedByID string    `gorm:"type:uuid;not null"`
+	Items         string    `gorm:"type:text"`
+	Document      string    `gorm:"type:text"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+
+	User        User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	PerformedBy User `gorm:"foreignKey:PerformedByID;constraint:OnDelete:RESTRICT"`
+}
+
 // ======= ASSET =======
 type Asset struct {
 	ID                  uint   `gorm:"primaryKey;autoIncrement"`
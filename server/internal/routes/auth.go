@@ -6,14 +6,21 @@ import (
 	"server/internal/handlers"
 	"server/internal/services"
 	"server/pkgs/httpwrap"
-	"server/pkgs/security"
+	"server/pkgs/ldapauth"
 
 	"github.com/gofiber/fiber/v3"
 )
 
 func RegisterAuthRoutes(app *fiber.App) {
-	argon := security.NewArgon2Service()
-	authSvc := services.NewAuthService(config.DB, argon)
+	argon := newArgon2Service()
+	backends := []services.AuthBackend{services.NewPasswordBackend(config.DB, argon)}
+	if ldapCfg := config.GetConfig().LDAP; ldapCfg.Enabled {
+		directory := ldapauth.NewClient(ldapCfg.Directory)
+		backends = append(backends, services.NewLDAPBackend(config.DB, directory, ldapCfg))
+	}
+
+	throttle := services.NewLoginThrottleService(config.DB, config.GetConfig().Throttle)
+	authSvc := services.NewAuthService(config.DB, argon, throttle, newSessionService(config.DB), newEmailVerificationService(config.DB), newPasswordPolicyService(config.DB, argon), backends...)
 	authH := handlers.NewAuthHandler(authSvc)
 
 	app.Post("/auth/signin", httpwrap.Wrap(authH.Signin))
@@ -4,18 +4,21 @@ package routes
 import (
 	"github.com/gofiber/fiber/v3"
 	"gorm.io/gorm"
+	"server/internal/config"
 	"server/internal/handlers"
+	"server/internal/middlewares"
 	"server/internal/services"
-	"server/pkgs/security"
 )
 
 func RegisterUserRoutes(app *fiber.App, db *gorm.DB) {
-	argon2Service := security.NewArgon2Service()
-	changePasswordService := services.NewChangePasswordService(db, argon2Service)
+	argon2Service := newArgon2Service()
+	throttle := services.NewLoginThrottleService(db, config.GetConfig().Throttle)
+	sessionService := newSessionService(db)
+	changePasswordService := services.NewChangePasswordService(db, argon2Service, throttle, sessionService, newPasswordPolicyService(db, argon2Service))
 	userHandler := handlers.NewUserHandler(changePasswordService)
 
-	userGroup := app.Group("/user")
+	userGroup := app.Group("/user", middlewares.RequireAuth(sessionService))
 	{
-		userGroup.Post("/update-password", userHandler.UpdatePasswordByID)
+		userGroup.Post("/update-password", middlewares.RequireNotImpersonating(), userHandler.UpdatePasswordByID)
 	}
 }
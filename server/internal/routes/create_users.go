@@ -4,22 +4,61 @@ package routes
 import (
 	"github.com/gofiber/fiber/v3"
 	"gorm.io/gorm"
+	"server/internal/config"
 	"server/internal/handlers"
+	"server/internal/middlewares"
+	"server/internal/models"
 	"server/internal/services"
 	"server/pkgs/httpwrap"
-	"server/pkgs/security"
+	"server/pkgs/mailer"
 )
 
 func RegisterUserManagementRoutes(app *fiber.App, db *gorm.DB) {
-	argon2Service := security.NewArgon2Service()
-	userManagementService := services.NewUserManagementService(db, argon2Service)
-	userManagementHandler := handlers.NewUserManagementHandler(userManagementService)
+	argon2Service := newArgon2Service()
+	invitationService := services.NewInvitationService(db, argon2Service, mailer.Mail, config.GetConfig().InvitationTTL, newPasswordPolicyService(db, argon2Service))
+	sessionService := newSessionService(db)
+	throttleService := services.NewLoginThrottleService(db, config.GetConfig().Throttle)
+	passwordResetService := newPasswordResetService(db)
+	userManagementService := services.NewUserManagementService(db, invitationService, sessionService, passwordResetService)
+	userManagementHandler := handlers.NewUserManagementHandler(userManagementService, throttleService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
 
-	userGroup := app.Group("/users")
+	// Los servicios validan permiso y jerarquía; la guarda de ruta además
+	// aplica los scopes cuando se llama con API key
+	authz := newAuthorizer(db)
+	can := func(permission string) fiber.Handler {
+		return middlewares.RequirePermission(authz, permission)
+	}
+
+	// Rutas que tocan credenciales ajenas o abren sesiones nuevas
+	notDelegated := middlewares.RequireNotImpersonating()
+
+	userGroup := app.Group("/users", middlewares.RequireAuth(sessionService))
 	{
-		userGroup.Post("/create", httpwrap.Wrap(userManagementHandler.CreateUser))
-		userGroup.Get("/list", httpwrap.Wrap(userManagementHandler.GetAllUsers))
+		userGroup.Post("/create", can(models.PermUserCreate), httpwrap.Wrap(userManagementHandler.CreateUser))
+		userGroup.Get("/list", can(models.PermUserList), httpwrap.Wrap(userManagementHandler.GetAllUsers))
+		userGroup.Post("/:id/unlock", notDelegated, can(models.PermUserUnlock), httpwrap.Wrap(userManagementHandler.UnlockUser))
+		userGroup.Post("/:id/require-password-change", notDelegated, can(models.PermUserPasswordReset), httpwrap.Wrap(userManagementHandler.RequirePasswordChange))
+		userGroup.Patch("/:id", can(models.PermUserUpdate), httpwrap.Wrap(userManagementHandler.UpdateUser))
+		userGroup.Post("/:id/deactivate", can(models.PermUserDeactivate), httpwrap.Wrap(userManagementHandler.DeactivateUser))
+		userGroup.Post("/:id/reactivate", can(models.PermUserDeactivate), httpwrap.Wrap(userManagementHandler.ReactivateUser))
+		userGroup.Post("/:id/reset-password", notDelegated, can(models.PermUserPasswordReset), httpwrap.Wrap(userManagementHandler.ResetUserPassword))
+		userGroup.Get("/:id/offboarding", can(models.PermUserOffboard), httpwrap.Wrap(userManagementHandler.OffboardingPreview))
+		userGroup.Post("/:id/offboarding", can(models.PermUserOffboard), httpwrap.Wrap(userManagementHandler.Offboard))
+		userGroup.Get("/:id/offboarding/document", can(models.PermUserOffboard), userManagementHandler.OffboardingDocument)
+		userGroup.Get("/:id/offices", can(models.PermUserList), httpwrap.Wrap(userManagementHandler.ListMemberships))
+		userGroup.Post("/:id/offices", can(models.PermUserOfficeAssign), httpwrap.Wrap(userManagementHandler.GrantMembership))
+		userGroup.Delete("/:id/offices/:officeId", can(models.PermUserOfficeAssign), httpwrap.Wrap(userManagementHandler.RevokeMembership))
+		userGroup.Post("/:id/impersonate", notDelegated, can(models.PermUserImpersonate), httpwrap.Wrap(userManagementHandler.StartImpersonation))
+		userGroup.Post("/invitations/:id/resend", can(models.PermUserCreate), httpwrap.Wrap(invitationHandler.Resend))
+		userGroup.Delete("/invitations/:id", can(models.PermUserCreate), httpwrap.Wrap(invitationHandler.Revoke))
 	}
 
+	// Se llama con el token de la suplantación
+	app.Post("/auth/impersonation/end", middlewares.RequireAuth(sessionService), httpwrap.Wrap(userManagementHandler.EndImpersonation))
+
+	// El invitado aún no tiene sesión
+	app.Post("/auth/invitations/accept", httpwrap.Wrap(invitationHandler.Accept))
+
 	println("✅ User management routes registered: POST /users/create")
 }
\ No newline at end of file
@@ -0,0 +1,17 @@
+// server/internal/routes/email_verification_routes.go
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+	"server/internal/handlers"
+	"server/pkgs/httpwrap"
+)
+
+func RegisterEmailVerificationRoutes(app *fiber.App, db *gorm.DB) {
+	verificationService := newEmailVerificationService(db)
+	verificationHandler := handlers.NewEmailVerificationHandler(verificationService)
+
+	app.Post("/auth/verify-email", httpwrap.Wrap(verificationHandler.VerifyEmail))
+	app.Post("/auth/verify-email/resend", httpwrap.Wrap(verificationHandler.ResendVerification))
+}
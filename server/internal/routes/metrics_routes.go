@@ -0,0 +1,20 @@
+// server/internal/routes/metrics_routes.go
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+	"server/internal/handlers"
+	"server/internal/middlewares"
+	"server/internal/models"
+	"server/pkgs/httpwrap"
+)
+
+func RegisterMetricsRoutes(app *fiber.App, db *gorm.DB) {
+	metricsHandler := handlers.NewMetricsHandler(newArgon2Service().Limiter())
+
+	metrics := app.Group("/metrics",
+		middlewares.RequireAuth(newSessionService(db)),
+		middlewares.RequirePermission(newAuthorizer(db), models.PermMetricsView))
+	metrics.Get("/hashing", httpwrap.Wrap(metricsHandler.Hashing))
+}
@@ -0,0 +1,27 @@
+// server/internal/routes/office_routes.go
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+	"server/internal/handlers"
+	"server/internal/middlewares"
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkgs/httpwrap"
+)
+
+func RegisterOfficeRoutes(app *fiber.App, db *gorm.DB) {
+	officeHandler := handlers.NewOfficeHandler(services.NewOfficeService(db))
+	canManage := middlewares.RequirePermission(newAuthorizer(db), models.PermOfficeManage)
+
+	// Cualquier usuario autenticado puede consultar el catálogo
+	offices := app.Group("/offices", middlewares.RequireAuth(newSessionService(db)))
+	{
+		offices.Get("/", httpwrap.Wrap(officeHandler.List))
+		offices.Get("/:id", httpwrap.Wrap(officeHandler.Get))
+		offices.Post("/", canManage, httpwrap.Wrap(officeHandler.Create))
+		offices.Patch("/:id", canManage, httpwrap.Wrap(officeHandler.Update))
+		offices.Delete("/:id", canManage, httpwrap.Wrap(officeHandler.Delete))
+	}
+}
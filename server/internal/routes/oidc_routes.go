@@ -0,0 +1,36 @@
+// server/internal/routes/oidc_routes.go
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+	"server/internal/config"
+	"server/internal/handlers"
+	"server/internal/middlewares"
+	"server/internal/services"
+	"server/pkgs/httpwrap"
+	"server/pkgs/oidc"
+)
+
+func RegisterOIDCRoutes(app *fiber.App, db *gorm.DB) {
+	registry := oidc.NewRegistry(config.GetConfig().OIDCProviders, &http.Client{Timeout: 10 * time.Second})
+	sessionService := newSessionService(db)
+	oidcService := services.NewOIDCService(db, registry, sessionService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService)
+	requireAuth := middlewares.RequireAuth(sessionService)
+	notDelegated := middlewares.RequireNotImpersonating()
+
+	oidcGroup := app.Group("/auth/oidc")
+	{
+		oidcGroup.Get("/providers", httpwrap.Wrap(oidcHandler.ListProviders))
+		oidcGroup.Get("/accounts", requireAuth, httpwrap.Wrap(oidcHandler.ListLinkedAccounts))
+		oidcGroup.Get("/:provider/authorize", httpwrap.Wrap(oidcHandler.Authorize))
+		oidcGroup.Get("/:provider/callback", httpwrap.Wrap(oidcHandler.Callback))
+		oidcGroup.Post("/:provider/callback", httpwrap.Wrap(oidcHandler.Callback))
+		oidcGroup.Post("/:provider/link", requireAuth, notDelegated, httpwrap.Wrap(oidcHandler.Link))
+		oidcGroup.Delete("/:provider/link", requireAuth, notDelegated, httpwrap.Wrap(oidcHandler.Unlink))
+	}
+}
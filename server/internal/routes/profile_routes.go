@@ -0,0 +1,31 @@
+// server/internal/routes/profile_routes.go
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/static"
+	"gorm.io/gorm"
+	"server/internal/handlers"
+	"server/internal/middlewares"
+	"server/internal/services"
+	"server/pkgs/httpwrap"
+	"server/pkgs/storage"
+)
+
+func RegisterProfileRoutes(app *fiber.App, db *gorm.DB) {
+	profileService := services.NewProfileService(db, storage.Files)
+	profileHandler := handlers.NewProfileHandler(profileService)
+
+	me := app.Group("/me", middlewares.RequireAuth(newSessionService(db)))
+	{
+		me.Get("/", httpwrap.Wrap(profileHandler.Get))
+		me.Patch("/", httpwrap.Wrap(profileHandler.Update))
+		me.Put("/avatar", httpwrap.Wrap(profileHandler.UploadAvatar))
+		me.Delete("/avatar", httpwrap.Wrap(profileHandler.DeleteAvatar))
+	}
+
+	// El backend local sirve los archivos desde el mismo servidor
+	if local, ok := storage.Files.(*storage.LocalStorage); ok {
+		app.Get(local.PublicURL()+"*", static.New(local.Dir()))
+	}
+}
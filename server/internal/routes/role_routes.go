@@ -0,0 +1,27 @@
+// server/internal/routes/role_routes.go
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+	"server/internal/handlers"
+	"server/internal/middlewares"
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkgs/httpwrap"
+)
+
+func RegisterRoleRoutes(app *fiber.App, db *gorm.DB) {
+	roleHandler := handlers.NewRoleHandler(services.NewRoleService(db))
+
+	roles := app.Group("/roles",
+		middlewares.RequireAuth(newSessionService(db)),
+		middlewares.RequirePermission(newAuthorizer(db), models.PermRoleManage))
+	{
+		roles.Get("/", httpwrap.Wrap(roleHandler.List))
+		roles.Get("/permissions", httpwrap.Wrap(roleHandler.Permissions))
+		roles.Post("/", httpwrap.Wrap(roleHandler.Create))
+		roles.Patch("/:id", httpwrap.Wrap(roleHandler.Update))
+		roles.Delete("/:id", httpwrap.Wrap(roleHandler.Delete))
+	}
+}
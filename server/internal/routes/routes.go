@@ -2,8 +2,15 @@
 package routes
 
 import (
+	"sync"
+
 	"github.com/gofiber/fiber/v3"
 	"gorm.io/gorm"
+	"server/internal/config"
+	"server/internal/services"
+	"server/pkgs/geoip"
+	"server/pkgs/mailer"
+	"server/pkgs/security"
 )
 
 func RegisterRoutes(app *fiber.App, db *gorm.DB) {
@@ -16,4 +23,74 @@ func RegisterRoutes(app *fiber.App, db *gorm.DB) {
 	RegisterPasswordResetRoutes(app, db)
 	RegisterUserRoutes(app, db)
 	RegisterUserManagementRoutes(app, db)
+	RegisterOIDCRoutes(app, db)
+	RegisterTwoFactorRoutes(app, db)
+	RegisterEmailVerificationRoutes(app, db)
+	RegisterMetricsRoutes(app, db)
+	RegisterProfileRoutes(app, db)
+	RegisterOfficeRoutes(app, db)
+	RegisterRoleRoutes(app, db)
+	RegisterServiceAccountRoutes(app, db)
+	RegisterSecurityEventRoutes(app, db)
+	RegisterWellKnownRoutes(app)
+}
+
+// newSessionService construye el servicio de sesiones con la configuración actual
+func newSessionService(db *gorm.DB) *services.SessionService {
+	cfg := config.GetConfig()
+	jwtService := security.NewJWTService(security.Keys, cfg.SessionTTL)
+	return services.NewSessionService(db, jwtService, mailer.Mail, geoip.Geo, cfg.SessionTTL, cfg.ImpersonationTTL, cfg.PasswordMaxAge)
+}
+
+var (
+	argon2Once   sync.Once
+	argon2Shared *security.Argon2Service
+)
+
+// newArgon2Service devuelve el hasher con los parámetros configurados. Es una
+// única instancia para que todas las rutas compartan el límite de concurrencia.
+func newArgon2Service() *security.Argon2Service {
+	argon2Once.Do(func() {
+		cfg := config.GetConfig()
+		params := cfg.Argon2
+		argon2Shared = security.NewArgon2ServiceWithParams(&params).
+			WithLimiter(security.NewHashLimiter(cfg.Argon2Limiter))
+	})
+	return argon2Shared
+}
+
+var (
+	passwordResetOnce   sync.Once
+	passwordResetShared *services.PasswordResetService
+)
+
+// newPasswordResetService comparte un único servicio de recuperación, así la
+// cola que emite los códigos es una sola para todo el servidor
+func newPasswordResetService(db *gorm.DB) *services.PasswordResetService {
+	passwordResetOnce.Do(func() {
+		cfg := config.GetConfig()
+		argon2Service := newArgon2Service()
+		// La clave ya se validó al arrancar
+		key, _ := cfg.PasswordReset.Key()
+		passwordResetShared = services.NewPasswordResetService(db, argon2Service,
+			services.NewLoginThrottleService(db, cfg.Throttle), cfg.Throttle.ResetCodeMaxAttempts,
+			key, mailer.Mail, newPasswordPolicyService(db, argon2Service)).
+			WithIssueQueue(cfg.PasswordReset.IssueWorkers, cfg.PasswordReset.IssueQueueSize)
+	})
+	return passwordResetShared
+}
+
+// newAuthorizer construye la consulta de permisos para los middlewares
+func newAuthorizer(db *gorm.DB) *services.Authorizer {
+	return services.NewAuthorizer(db)
+}
+
+// newPasswordPolicyService construye la política de contraseñas con la configuración actual
+func newPasswordPolicyService(db *gorm.DB, argon2Service *security.Argon2Service) *services.PasswordPolicyService {
+	return services.NewPasswordPolicyService(db, argon2Service, config.GetConfig().PasswordPolicy)
+}
+
+// newEmailVerificationService construye el servicio de verificación de correo
+func newEmailVerificationService(db *gorm.DB) *services.EmailVerificationService {
+	return services.NewEmailVerificationService(db, mailer.Mail, config.GetConfig().Verification)
 }
@@ -0,0 +1,21 @@
+// server/internal/routes/security_event_routes.go
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+	"server/internal/handlers"
+	"server/internal/middlewares"
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkgs/httpwrap"
+)
+
+func RegisterSecurityEventRoutes(app *fiber.App, db *gorm.DB) {
+	securityEventHandler := handlers.NewSecurityEventHandler(services.NewSecurityEventService(db))
+
+	events := app.Group("/security-events",
+		middlewares.RequireAuth(newSessionService(db)),
+		middlewares.RequirePermission(newAuthorizer(db), models.PermSecurityEventRead))
+	events.Get("/", httpwrap.Wrap(securityEventHandler.List))
+}
@@ -0,0 +1,30 @@
+// server/internal/routes/service_account_routes.go
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+	"server/internal/config"
+	"server/internal/handlers"
+	"server/internal/middlewares"
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkgs/httpwrap"
+)
+
+func RegisterServiceAccountRoutes(app *fiber.App, db *gorm.DB) {
+	serviceAccountService := services.NewServiceAccountService(db, config.GetConfig().APIKeyTTL)
+	serviceAccountHandler := handlers.NewServiceAccountHandler(serviceAccountService)
+
+	accounts := app.Group("/service-accounts",
+		middlewares.RequireAuth(newSessionService(db)),
+		middlewares.RequirePermission(newAuthorizer(db), models.PermServiceAccountManage))
+	notDelegated := middlewares.RequireNotImpersonating()
+	{
+		accounts.Get("/", httpwrap.Wrap(serviceAccountHandler.List))
+		accounts.Post("/", notDelegated, httpwrap.Wrap(serviceAccountHandler.Create))
+		accounts.Delete("/:id", notDelegated, httpwrap.Wrap(serviceAccountHandler.Deactivate))
+		accounts.Post("/:id/keys", notDelegated, httpwrap.Wrap(serviceAccountHandler.CreateKey))
+		accounts.Delete("/:id/keys/:keyId", notDelegated, httpwrap.Wrap(serviceAccountHandler.RevokeKey))
+	}
+}
@@ -0,0 +1,42 @@
+// server/internal/routes/two_factor_routes.go
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+	"server/internal/config"
+	"server/internal/handlers"
+	"server/internal/middlewares"
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkgs/httpwrap"
+)
+
+func RegisterTwoFactorRoutes(app *fiber.App, db *gorm.DB) {
+	cfg := config.GetConfig()
+	sessionService := newSessionService(db)
+	throttle := services.NewLoginThrottleService(db, cfg.Throttle)
+	twoFactorService := services.NewTwoFactorService(db, sessionService, throttle, cfg.TOTPIssuer)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService, sessionService)
+
+	requireAuth := middlewares.RequireAuth(sessionService)
+	notDelegated := middlewares.RequireNotImpersonating()
+	managePolicy := middlewares.RequirePermission(newAuthorizer(db), models.PermSecurityPolicyManage)
+
+	app.Post("/auth/signout", requireAuth, httpwrap.Wrap(twoFactorHandler.Signout))
+
+	twoFactorGroup := app.Group("/auth/2fa")
+	{
+		// Segundo paso del login: no requiere sesión
+		twoFactorGroup.Post("/verify", httpwrap.Wrap(twoFactorHandler.Verify))
+
+		twoFactorGroup.Get("/status", requireAuth, httpwrap.Wrap(twoFactorHandler.Status))
+		twoFactorGroup.Post("/enroll", requireAuth, notDelegated, httpwrap.Wrap(twoFactorHandler.Enroll))
+		twoFactorGroup.Post("/enroll/confirm", requireAuth, notDelegated, httpwrap.Wrap(twoFactorHandler.ConfirmEnroll))
+		twoFactorGroup.Post("/disable", requireAuth, notDelegated, httpwrap.Wrap(twoFactorHandler.Disable))
+		twoFactorGroup.Post("/recovery-codes", requireAuth, notDelegated, httpwrap.Wrap(twoFactorHandler.RegenerateRecoveryCodes))
+		twoFactorGroup.Get("/policy", requireAuth, managePolicy, httpwrap.Wrap(twoFactorHandler.GetPolicies))
+		twoFactorGroup.Put("/policy", requireAuth, middlewares.RequireFullSession(), notDelegated, managePolicy,
+			httpwrap.Wrap(twoFactorHandler.SetPolicy))
+	}
+}
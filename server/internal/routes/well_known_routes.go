@@ -0,0 +1,16 @@
+// server/internal/routes/well_known_routes.go
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"server/pkgs/security"
+)
+
+// RegisterWellKnownRoutes publica las claves públicas con las que otros
+// servicios verifican los tokens emitidos por el servidor
+func RegisterWellKnownRoutes(app *fiber.App) {
+	app.Get("/.well-known/jwks.json", func(c fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+		return c.JSON(security.Keys.JWKS())
+	})
+}
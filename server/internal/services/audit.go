@@ -0,0 +1,75 @@
+// server/internal/services/audit.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"gorm.io/gorm"
+	"server/internal/audit"
+	"server/pkgs/logger"
+)
+
+// Acciones registradas en el log de auditoría
+const (
+	AuditUserUpdated       = "USER_UPDATED"
+	AuditUserDeactivated   = "USER_DEACTIVATED"
+	AuditUserReactivated   = "USER_REACTIVATED"
+	AuditUserPasswordReset = "USER_PASSWORD_RESET"
+
+	auditEntityUser = "user"
+)
+
+// AuditMeta identifica a quién ejecuta un cambio y desde dónde. Bajo
+// suplantación, ActorID es el usuario suplantado e ImpersonatorID el real.
+type AuditMeta = audit.Meta
+
+// withAudit atribuye al autor los cambios hechos con la conexión devuelta:
+// los callbacks de auditoría registran cada fila a su nombre. Conserva el
+// contexto que ya tuviera db (scopes de API key).
+func withAudit(db *gorm.DB, meta AuditMeta) *gorm.DB {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return db.WithContext(audit.WithMeta(ctx, meta))
+}
+
+// asActor atribuye a userID los cambios de una petición sin sesión
+// (recuperación de contraseña, invitación, verificación de correo), donde el
+// usuario se identifica por el enlace o código. Conserva IP y request del
+// contexto de db.
+func asActor(db *gorm.DB, userID string) *gorm.DB {
+	meta := audit.MetaFrom(db.Statement.Context)
+	if meta.ActorID == "" {
+		meta.ActorID = userID
+	}
+	return withAudit(db, meta)
+}
+
+// recordAudit agrega a la cadena de auditoría una acción de negocio, además
+// de los cambios por fila que registran los callbacks. Al recibir la
+// transacción del cambio, el registro se guarda o se descarta junto con él.
+func recordAudit(tx *gorm.DB, meta AuditMeta, action, entityType, entityID string, changes map[string]interface{}) error {
+	entry := audit.NewEntry(meta, action, entityType, entityID)
+
+	if len(changes) > 0 {
+		raw, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		str := string(raw)
+		entry.Changes = &str
+	}
+
+	if err := audit.Append(tx, entry); err != nil {
+		return err
+	}
+
+	if meta.ImpersonatorID != "" {
+		logger.Log.Infof("📝 Audit %s %s/%s (actor=%s impersonator=%s)", action, entityType, entityID, meta.ActorID, meta.ImpersonatorID)
+		return nil
+	}
+	logger.Log.Infof("📝 Audit %s %s/%s (actor=%s)", action, entityType, entityID, meta.ActorID)
+	return nil
+}
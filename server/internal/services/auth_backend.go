@@ -0,0 +1,102 @@
+// server/internal/services/auth_backend.go
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+	"server/pkgs/logger"
+	"server/pkgs/security"
+)
+
+// AuthBackend valida un par correo/contraseña contra una fuente de identidad
+// y devuelve el usuario local correspondiente.
+type AuthBackend interface {
+	Name() string
+	Authenticate(email, password string) (*models.User, error)
+}
+
+// isBackendMiss indica que el backend no reconoce al usuario y se puede probar el siguiente
+func isBackendMiss(err error) bool {
+	return errors.Is(err, ErrUserNotFound) ||
+		errors.Is(err, ErrInvalidLoginMethod) ||
+		errors.Is(err, ErrInvalidCredentials)
+}
+
+// ======= PASSWORD BACKEND =======
+
+type passwordBackend struct {
+	db    *gorm.DB
+	argon *security.Argon2Service
+}
+
+func NewPasswordBackend(db *gorm.DB, argon *security.Argon2Service) AuthBackend {
+	return &passwordBackend{db: db, argon: argon}
+}
+
+func (b *passwordBackend) Name() string { return "password" }
+
+func (b *passwordBackend) Authenticate(email, password string) (*models.User, error) {
+	var user models.User
+	if err := b.db.Where("LOWER(email) = ?", normalizeEmail(email)).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+
+	if user.Password == nil || *user.Password == "" {
+		return nil, ErrInvalidLoginMethod
+	}
+
+	// Las cuentas administradas por el directorio no usan la contraseña local
+	var directoryAccounts int64
+	b.db.Model(&models.Account{}).
+		Where("user_id = ? AND provider = ?", user.ID, ldapProviderName).
+		Count(&directoryAccounts)
+	if directoryAccounts > 0 {
+		return nil, ErrInvalidLoginMethod
+	}
+
+	if err := b.argon.ComparePassword(*user.Password, password); err != nil {
+		if errors.Is(err, security.ErrHasherBusy) {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	b.rehashIfStale(&user, password)
+
+	return &user, nil
+}
+
+// rehashIfStale regenera el hash con los parámetros vigentes cuando fue creado
+// con otros. Se compara contra el hash leído para no pisar un cambio concurrente.
+func (b *passwordBackend) rehashIfStale(user *models.User, password string) {
+	if !b.argon.NeedsRehash(*user.Password) {
+		return
+	}
+
+	hashed, err := b.argon.HashPassword(password)
+	if err != nil {
+		logger.Log.Warnf("⚠️ No se pudo regenerar el hash de %s: %v", user.Email, err)
+		return
+	}
+
+	res := b.db.Model(&models.User{}).
+		Where("id = ? AND password = ?", user.ID, *user.Password).
+		UpdateColumn("password", hashed)
+	if res.Error != nil {
+		logger.Log.Warnf("⚠️ No se pudo guardar el hash actualizado de %s: %v", user.Email, res.Error)
+		return
+	}
+	if res.RowsAffected == 1 {
+		user.Password = &hashed
+		logger.Log.Infof("🔐 Hash de contraseña actualizado para %s", user.Email)
+	}
+}
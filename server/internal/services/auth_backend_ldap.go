@@ -0,0 +1,246 @@
+// server/internal/services/auth_backend_ldap.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"server/internal/config"
+	"server/internal/models"
+	"server/pkgs/ldapauth"
+	"server/pkgs/logger"
+)
+
+const ldapProviderName = "ldap"
+
+var (
+	ErrDirectoryUnavailable   = errors.New("el directorio institucional no está disponible")
+	ErrDirectoryLinkRequired  = errors.New("la cuenta local no está vinculada al directorio; solicita a un administrador que la vincule")
+	ErrDirectoryLinkInvalid   = errors.New("la cuenta no puede vincularse al directorio")
+	ErrDirectoryAlreadyLinked = errors.New("la cuenta ya está vinculada al directorio")
+)
+
+type ldapBackend struct {
+	db        *gorm.DB
+	directory ldapauth.Directory
+	cfg       config.LDAPConfig
+}
+
+func NewLDAPBackend(db *gorm.DB, directory ldapauth.Directory, cfg config.LDAPConfig) AuthBackend {
+	return &ldapBackend{db: db, directory: directory, cfg: cfg}
+}
+
+func (b *ldapBackend) Name() string { return ldapProviderName }
+
+// Authenticate valida contra el directorio y crea o actualiza el usuario local.
+// Solo inicia sesión en cuentas ya vinculadas al directorio o que crea en ese
+// momento: una cuenta local con el mismo correo requiere vincularse antes con
+// LinkDirectoryUser.
+func (b *ldapBackend) Authenticate(email, password string) (*models.User, error) {
+	entry, err := b.directory.Authenticate(email, password)
+	if err != nil {
+		switch {
+		case errors.Is(err, ldapauth.ErrUserNotFound):
+			return nil, ErrUserNotFound
+		case errors.Is(err, ldapauth.ErrInvalidCredentials):
+			return nil, ErrInvalidCredentials
+		}
+		logger.Log.Errorf("❌ LDAP error: %v", err)
+		return nil, ErrDirectoryUnavailable
+	}
+
+	if entry.Email == "" {
+		entry.Email = email
+	}
+
+	rol, mappedOffice, err := b.mapGroups(entry.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	err = b.db.Transaction(func(tx *gorm.DB) error {
+		var account models.Account
+		err := tx.Preload("User").
+			Where("provider = ? AND provider_account_id = ?", ldapProviderName, entry.ID).
+			First(&account).Error
+
+		created := false
+		switch {
+		case err == nil:
+			user = account.User
+
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			var existing int64
+			if err := tx.Model(&models.User{}).Where("LOWER(email) = ?", strings.ToLower(entry.Email)).Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				logger.Log.Warnf("⚠️ LDAP login for %s refused: local account not linked to the directory", entry.Email)
+				return ErrDirectoryLinkRequired
+			}
+			user = models.User{
+				Email:         entry.Email,
+				IsActive:      true,
+				EmailVerified: ptrTimeNow(),
+			}
+			created = true
+
+		default:
+			return err
+		}
+
+		if !user.IsActive {
+			return ErrUserInactive
+		}
+		if user.IsServiceAccount {
+			return ErrInvalidLoginMethod
+		}
+
+		if entry.Name != "" {
+			user.Name = &entry.Name
+		}
+		if entry.Phone != "" {
+			user.Phone = &entry.Phone
+		}
+		// Rol y oficina asignados a mano no cambian salvo LDAP_SYNC_GROUPS
+		if !created && !b.cfg.SyncGroups {
+			return tx.Omit("CreatedBy", "Office").Save(&user).Error
+		}
+		user.Rol = rol
+		if mappedOffice != nil {
+			// Un grupo mapeado a una oficina inexistente o inactiva no bloquea el acceso
+			office, err := findActiveOffice(tx, *mappedOffice)
+			switch {
+			case err == nil:
+				user.OfficeID = &office.ID
+			case errors.Is(err, ErrOfficeInvalid):
+				logger.Log.Warnf("⚠️ LDAP office %s not found in catalog", *mappedOffice)
+			default:
+				return err
+			}
+		}
+
+		if err := tx.Omit("CreatedBy", "Office").Save(&user).Error; err != nil {
+			return err
+		}
+
+		if account.ID == "" {
+			account = models.Account{
+				UserID:            user.ID,
+				Type:              ldapProviderName,
+				Provider:          ldapProviderName,
+				ProviderAccountID: entry.ID,
+			}
+			if err := tx.Omit("User").Create(&account).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Infof("🔐 LDAP user %s synced as %s", user.Email, user.Rol)
+	return &user, nil
+}
+
+// mapGroups traduce los grupos del directorio a Rol y código de oficina
+// locales. Si el usuario está en varios grupos gana el rol de mayor nivel.
+func (b *ldapBackend) mapGroups(groups []string) (models.Rol, *string, error) {
+	rol := models.Rol(b.cfg.DefaultRole)
+	grants, err := loadRoleGrants(b.db, rol)
+	if err != nil {
+		return "", nil, err
+	}
+	if !grants.exists {
+		rol = models.RolEmployee
+	}
+
+	var office *string
+	for _, group := range groups {
+		for _, key := range groupKeys(group) {
+			if mapped, ok := b.cfg.GroupRoles[key]; ok {
+				candidate := models.Rol(mapped)
+				known, err := loadRoleGrants(b.db, candidate)
+				if err != nil {
+					return "", nil, err
+				}
+				above, err := outranks(b.db, candidate, rol)
+				if err != nil {
+					return "", nil, err
+				}
+				if known.exists && above {
+					rol = candidate
+				}
+			}
+			if mapped, ok := b.cfg.GroupOffices[key]; ok && office == nil {
+				value := mapped
+				office = &value
+			}
+		}
+	}
+
+	return rol, office, nil
+}
+
+// groupKeys devuelve el DN completo y el CN del grupo en minúsculas
+func groupKeys(dn string) []string {
+	keys := []string{strings.ToLower(dn)}
+	first := strings.SplitN(dn, ",", 2)[0]
+	if strings.HasPrefix(strings.ToUpper(first), "CN=") {
+		keys = append(keys, strings.ToLower(first[3:]))
+	}
+	return keys
+}
+
+// LinkDirectoryUser vincula explícitamente una cuenta local existente con su
+// entrada del directorio. Desde entonces inicia sesión con la contraseña del
+// directorio y deja de usar la local. Rol y oficina no cambian.
+func LinkDirectoryUser(db *gorm.DB, directory ldapauth.Directory, email string) (*models.User, error) {
+	var user models.User
+	if err := db.Where("LOWER(email) = ?", normalizeEmail(email)).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.IsServiceAccount {
+		return nil, ErrDirectoryLinkInvalid
+	}
+
+	entry, err := directory.Lookup(user.Email)
+	if err != nil {
+		if errors.Is(err, ldapauth.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDirectoryUnavailable, err)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var linked int64
+		if err := tx.Model(&models.Account{}).
+			Where("provider = ? AND (user_id = ? OR provider_account_id = ?)", ldapProviderName, user.ID, entry.ID).
+			Count(&linked).Error; err != nil {
+			return err
+		}
+		if linked > 0 {
+			return ErrDirectoryAlreadyLinked
+		}
+		return tx.Omit("User").Create(&models.Account{
+			UserID:            user.ID,
+			Type:              ldapProviderName,
+			Provider:          ldapProviderName,
+			ProviderAccountID: entry.ID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Infof("🔗 User %s linked to directory entry %s", user.Email, entry.DN)
+	return &user, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+	"server/internal/config"
+	"server/internal/models"
+	"server/pkgs/ldapauth"
+	"server/pkgs/ldapauth/ldaptest"
+)
+
+const (
+	ldapTestBaseDN   = "ou=personal,dc=example,dc=gob,dc=pe"
+	ldapTestBindDN   = "cn=svc-inventario,ou=servicios,dc=example,dc=gob,dc=pe"
+	ldapTestBindPass = "svc-secret"
+	ldapTestPassword = "directorio-123"
+	ldapTestGroup    = "CN=Inventario-Jefes,OU=Grupos,DC=example,DC=gob,DC=pe"
+)
+
+// newLDAPTestBackend levanta un directorio en proceso con un usuario del
+// grupo de jefes y devuelve el backend apuntando a él
+func newLDAPTestBackend(t *testing.T, db *gorm.DB, email string, sync bool) (AuthBackend, ldapauth.Directory) {
+	t.Helper()
+	server, err := ldaptest.NewServer(ldapTestBindDN, ldapTestBindPass, ldaptest.Entry{
+		DN:       "cn=" + email + "," + ldapTestBaseDN,
+		Password: ldapTestPassword,
+		Attributes: map[string][]string{
+			"objectClass": {"user"},
+			"mail":        {email},
+			"objectGUID":  {email},
+			"displayName": {"Usuario del directorio"},
+			"memberOf":    {ldapTestGroup},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+
+	directory := ldapauth.NewClient(ldapauth.Config{
+		URL:          server.URL,
+		BindDN:       ldapTestBindDN,
+		BindPassword: ldapTestBindPass,
+		BaseDN:       ldapTestBaseDN,
+	})
+	cfg := config.LDAPConfig{
+		Enabled:      true,
+		GroupRoles:   map[string]string{"inventario-jefes": string(models.RolManager)},
+		GroupOffices: map[string]string{},
+		DefaultRole:  string(models.RolEmployee),
+		SyncGroups:   sync,
+	}
+	return NewLDAPBackend(db, directory, cfg), directory
+}
+
+func TestLDAPProvisionsNewUserFromGroups(t *testing.T) {
+	db := testDB(t)
+	email := uniqueEmail(t, "nuevo")
+	backend, _ := newLDAPTestBackend(t, db, email, false)
+
+	user, err := backend.Authenticate(email, ldapTestPassword)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.Rol != models.RolManager {
+		t.Fatalf("el usuario nuevo debe tomar el rol del grupo, tiene %s", user.Rol)
+	}
+
+	var links int64
+	db.Model(&models.Account{}).Where("user_id = ? AND provider = ?", user.ID, ldapProviderName).Count(&links)
+	if links != 1 {
+		t.Fatalf("se esperaba una cuenta ldap vinculada, hay %d", links)
+	}
+
+	if _, err := backend.Authenticate(email, "incorrecta"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("se esperaba ErrInvalidCredentials, se obtuvo %v", err)
+	}
+}
+
+func TestLDAPKeepsManualRoleUnlessSyncEnabled(t *testing.T) {
+	db := testDB(t)
+	email := uniqueEmail(t, "manual")
+	backend, _ := newLDAPTestBackend(t, db, email, false)
+
+	user, err := backend.Authenticate(email, ldapTestPassword)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	// Un administrador baja el rol a mano
+	db.Model(&models.User{}).Where("id = ?", user.ID).Update("rol", models.RolEmployee)
+
+	user, err = backend.Authenticate(email, ldapTestPassword)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.Rol != models.RolEmployee {
+		t.Fatalf("sin LDAP_SYNC_GROUPS el rol manual no debe cambiar, quedó %s", user.Rol)
+	}
+
+	synced, _ := newLDAPTestBackend(t, db, email, true)
+	user, err = synced.Authenticate(email, ldapTestPassword)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.Rol != models.RolManager {
+		t.Fatalf("con LDAP_SYNC_GROUPS el rol debe tomarse del grupo, quedó %s", user.Rol)
+	}
+}
+
+func TestLDAPRequiresExplicitLinkForLocalAccounts(t *testing.T) {
+	db := testDB(t)
+	email := uniqueEmail(t, "admin")
+	local := models.User{Email: email, IsActive: true, Rol: models.RolAdmin}
+	if err := db.Omit("CreatedBy", "Office").Create(&local).Error; err != nil {
+		t.Fatal(err)
+	}
+	backend, directory := newLDAPTestBackend(t, db, email, false)
+
+	if _, err := backend.Authenticate(email, ldapTestPassword); !errors.Is(err, ErrDirectoryLinkRequired) {
+		t.Fatalf("se esperaba ErrDirectoryLinkRequired, se obtuvo %v", err)
+	}
+
+	if _, err := LinkDirectoryUser(db, directory, email); err != nil {
+		t.Fatalf("LinkDirectoryUser: %v", err)
+	}
+	if _, err := LinkDirectoryUser(db, directory, email); !errors.Is(err, ErrDirectoryAlreadyLinked) {
+		t.Fatalf("se esperaba ErrDirectoryAlreadyLinked, se obtuvo %v", err)
+	}
+
+	user, err := backend.Authenticate(email, ldapTestPassword)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.ID != local.ID || user.Rol != models.RolAdmin {
+		t.Fatalf("el usuario vinculado debe conservar id y rol: %s %s", user.ID, user.Rol)
+	}
+}
+
+func TestLDAPNeverLinksServiceAccounts(t *testing.T) {
+	db := testDB(t)
+	email := uniqueEmail(t, "svc")
+	account := models.User{Email: email, IsActive: true, IsServiceAccount: true}
+	if err := db.Omit("CreatedBy", "Office").Create(&account).Error; err != nil {
+		t.Fatal(err)
+	}
+	backend, directory := newLDAPTestBackend(t, db, email, false)
+
+	if _, err := backend.Authenticate(email, ldapTestPassword); !errors.Is(err, ErrDirectoryLinkRequired) {
+		t.Fatalf("se esperaba ErrDirectoryLinkRequired, se obtuvo %v", err)
+	}
+	if _, err := LinkDirectoryUser(db, directory, email); !errors.Is(err, ErrDirectoryLinkInvalid) {
+		t.Fatalf("se esperaba ErrDirectoryLinkInvalid, se obtuvo %v", err)
+	}
+}
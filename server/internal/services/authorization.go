@@ -0,0 +1,173 @@
+// server/internal/services/authorization.go
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+var ErrPermissionDenied = errors.New("no tienes permisos para realizar esta acción")
+
+// roleGrants es lo que un rol puede hacer según la base de datos
+type roleGrants struct {
+	exists      bool
+	level       int
+	permissions map[string]bool
+}
+
+// roleCache evita consultar role_permissions en cada petición. RoleService la
+// vacía al editar un rol, así los cambios aplican sin reiniciar sesiones.
+var roleCache = struct {
+	sync.RWMutex
+	roles map[models.Rol]*roleGrants
+}{roles: map[models.Rol]*roleGrants{}}
+
+func invalidateRoleCache() {
+	roleCache.Lock()
+	roleCache.roles = map[models.Rol]*roleGrants{}
+	roleCache.Unlock()
+}
+
+// loadRoleGrants devuelve nivel y permisos del rol; un rol inexistente no
+// tiene permisos y su nivel es 0
+func loadRoleGrants(db *gorm.DB, rol models.Rol) (*roleGrants, error) {
+	roleCache.RLock()
+	grants, ok := roleCache.roles[rol]
+	roleCache.RUnlock()
+	if ok {
+		return grants, nil
+	}
+
+	var role models.Role
+	err := db.Preload("Permissions").Where("name = ?", rol).First(&role).Error
+	grants = &roleGrants{permissions: map[string]bool{}}
+	switch {
+	case err == nil:
+		grants.exists = true
+		grants.level = role.Level
+		for _, p := range role.Permissions {
+			grants.permissions[p.Code] = true
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	roleCache.Lock()
+	roleCache.roles[rol] = grants
+	roleCache.Unlock()
+	return grants, nil
+}
+
+// hasPermission indica si el rol tiene el permiso
+func hasPermission(db *gorm.DB, rol models.Rol, permission string) (bool, error) {
+	grants, err := loadRoleGrants(db, rol)
+	if err != nil {
+		return false, err
+	}
+	return grants.permissions[permission], nil
+}
+
+type apiKeyScopesKey struct{}
+
+// WithAPIKeyScopes marca el contexto de una petición hecha con API key. Las
+// consultas con db.WithContext(ctx) solo conceden los permisos que además
+// estén entre los scopes de la clave.
+func WithAPIKeyScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, apiKeyScopesKey{}, scopes)
+}
+
+// scopeAllows indica si los scopes de la petición (si es con API key) incluyen el permiso
+func scopeAllows(db *gorm.DB, permission string) bool {
+	if db.Statement.Context == nil {
+		return true
+	}
+	scopes, ok := db.Statement.Context.Value(apiKeyScopesKey{}).([]string)
+	return !ok || slices.Contains(scopes, permission)
+}
+
+// requesterCan indica si quien hace la petición tiene el permiso: su rol debe
+// tenerlo y, con API key, también los scopes de la clave
+func requesterCan(db *gorm.DB, user *models.User, permission string) (bool, error) {
+	ok, err := hasPermission(db, user.Rol, permission)
+	if err != nil || !ok {
+		return false, err
+	}
+	return scopeAllows(db, permission), nil
+}
+
+// requirePermission devuelve ErrPermissionDenied si quien hace la petición no
+// tiene el permiso (ver requesterCan)
+func requirePermission(db *gorm.DB, user *models.User, permission string) error {
+	ok, err := requesterCan(db, user, permission)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// outranks indica si el rol a está por encima del rol b en la jerarquía
+func outranks(db *gorm.DB, a, b models.Rol) (bool, error) {
+	ga, err := loadRoleGrants(db, a)
+	if err != nil {
+		return false, err
+	}
+	gb, err := loadRoleGrants(db, b)
+	if err != nil {
+		return false, err
+	}
+	return ga.level > gb.level, nil
+}
+
+// assignableRole valida que el rol exista y esté por debajo del de quien lo asigna
+func assignableRole(db *gorm.DB, assigner *models.User, rol models.Rol) error {
+	grants, err := loadRoleGrants(db, rol)
+	if err != nil {
+		return err
+	}
+	if !grants.exists {
+		return ErrCreateUserRoleInvalid
+	}
+	above, err := outranks(db, assigner.Rol, rol)
+	if err != nil {
+		return err
+	}
+	if !above {
+		return ErrRoleAboveRequester
+	}
+	return nil
+}
+
+// lowerRoles son los nombres de los roles con nivel menor al del usuario
+func lowerRoles(db *gorm.DB, user *models.User) ([]models.Rol, error) {
+	grants, err := loadRoleGrants(db, user.Rol)
+	if err != nil {
+		return nil, err
+	}
+	var names []models.Rol
+	if err := db.Model(&models.Role{}).Where("level < ?", grants.level).Pluck("name", &names).Error; err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// Authorizer expone la consulta de permisos a los middlewares
+type Authorizer struct {
+	db *gorm.DB
+}
+
+func NewAuthorizer(db *gorm.DB) *Authorizer {
+	return &Authorizer{db: db}
+}
+
+// Can indica si el rol tiene el permiso
+func (a *Authorizer) Can(rol models.Rol, permission string) (bool, error) {
+	return hasPermission(a.db, rol, permission)
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+func TestRequirePermissionIntersectsAPIKeyScopes(t *testing.T) {
+	db := testDB(t)
+	admin := &models.User{Rol: models.RolAdmin}
+
+	if err := requirePermission(db, admin, models.PermUserRoleAssign); err != nil {
+		t.Fatalf("con sesión manda el rol: %v", err)
+	}
+
+	scoped := db.WithContext(WithAPIKeyScopes(context.Background(), []string{models.PermUserUpdate}))
+	if err := requirePermission(scoped, admin, models.PermUserUpdate); err != nil {
+		t.Fatalf("el scope de la clave incluye el permiso: %v", err)
+	}
+	if err := requirePermission(scoped, admin, models.PermUserRoleAssign); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("sin el scope se esperaba ErrPermissionDenied, se obtuvo %v", err)
+	}
+
+	// Una clave sin scopes no concede nada aunque el rol sea ADMIN
+	empty := db.WithContext(WithAPIKeyScopes(context.Background(), nil))
+	if err := requirePermission(empty, admin, models.PermUserList); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("se esperaba ErrPermissionDenied, se obtuvo %v", err)
+	}
+
+	// Los scopes se conservan dentro de una transacción auditada
+	err := withAudit(scoped, AuditMeta{ActorID: "x"}).Transaction(func(tx *gorm.DB) error {
+		return requirePermission(tx, admin, models.PermUserRoleAssign)
+	})
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("withAudit no debe perder los scopes, se obtuvo %v", err)
+	}
+}
+
+func TestManagedOfficesRequireManageScope(t *testing.T) {
+	db := testDB(t)
+	officeID := "office-scope-test"
+	manager := &models.User{ID: "manager-scope-test", Rol: models.RolManager, OfficeID: &officeID}
+
+	ids, err := managedOfficeIDs(db, manager)
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("con sesión supervisa su oficina: %v %v", ids, err)
+	}
+
+	scoped := db.WithContext(WithAPIKeyScopes(context.Background(), []string{models.PermUserList}))
+	ids, err = managedOfficeIDs(scoped, manager)
+	if err != nil || len(ids) != 0 {
+		t.Fatalf("sin el scope user.manage no supervisa oficinas: %v %v", ids, err)
+	}
+}
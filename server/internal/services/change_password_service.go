@@ -2,47 +2,103 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"gorm.io/gorm"
+	"server/internal/dto"
 	"server/internal/models"
 	"server/pkgs/security"
+	"time"
 )
 
 type ChangePasswordService struct {
 	db            *gorm.DB
 	argon2Service *security.Argon2Service
+	throttle      *LoginThrottleService
+	sessions      *SessionService
+	policy        *PasswordPolicyService
 }
 
-func NewChangePasswordService(db *gorm.DB, argon2Service *security.Argon2Service) *ChangePasswordService {
-	return &ChangePasswordService{db: db, argon2Service: argon2Service}
+func NewChangePasswordService(db *gorm.DB, argon2Service *security.Argon2Service, throttle *LoginThrottleService, sessions *SessionService, policy *PasswordPolicyService) *ChangePasswordService {
+	return &ChangePasswordService{db: db, argon2Service: argon2Service, throttle: throttle, sessions: sessions, policy: policy}
 }
 
-func (s *ChangePasswordService) UpdatePasswordWithVerification(userID string, currentPassword string, newPassword string) error {
+// forRequest devuelve una copia del servicio cuyas consultas llevan el
+// contexto de la petición, con el autor que adjuntó RequireAuth
+func (s *ChangePasswordService) forRequest(ctx context.Context) *ChangePasswordService {
+	bound := *s
+	bound.db = s.db.WithContext(ctx)
+	return &bound
+}
+
+// UpdatePasswordWithVerification cambia la contraseña y limpia el cambio obligatorio.
+// Si la sesión actual era restringida por ese motivo, se reemplaza y se devuelve la nueva.
+func (s *ChangePasswordService) UpdatePasswordWithVerification(ctx context.Context, userID, sessionID, sessionScope, currentPassword, newPassword string, meta LoginMeta) (*dto.AuthResponse, error) {
+	s = s.forRequest(ctx)
 	var user models.User
 
 	if err := s.db.Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("usuario no encontrado o inactivo")
+			return nil, errors.New("usuario no encontrado o inactivo")
 		}
-		return err
+		return nil, err
 	}
 
 	if user.Password == nil || *user.Password == "" {
-		return errors.New("usuario no tiene contraseña configurada")
+		return nil, errors.New("usuario no tiene contraseña configurada")
+	}
+
+	if err := s.throttle.Check(user.Email, meta.IP); err != nil {
+		return nil, err
 	}
 
 	if err := s.argon2Service.ComparePassword(*user.Password, currentPassword); err != nil {
-		return errors.New("la contraseña actual es incorrecta")
+		if errors.Is(err, security.ErrHasherBusy) {
+			return nil, err
+		}
+		s.throttle.RegisterFailure(user.Email, meta.IP, &user.ID)
+		return nil, errors.New("la contraseña actual es incorrecta")
+	}
+
+	s.throttle.RegisterSuccess(user.Email, meta.IP)
+
+	if err := s.policy.Validate(newPassword, &user); err != nil {
+		return nil, err
 	}
 
 	hashedPassword, err := s.argon2Service.HashPassword(newPassword)
 	if err != nil {
-		return errors.New("error al hashear la nueva contraseña")
+		if errors.Is(err, security.ErrHasherBusy) {
+			return nil, err
+		}
+		return nil, errors.New("error al hashear la nueva contraseña")
 	}
 
-	if err := s.db.Model(&user).Update("password", hashedPassword).Error; err != nil {
-		return err
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password":             hashedPassword,
+			"must_change_password": false,
+			"password_changed_at":  now,
+		}).Error; err != nil {
+			return err
+		}
+		return s.policy.Remember(tx, user.ID, hashedPassword)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recordClientSecurityEvent(s.db, models.SecurityEventPasswordChanged, &user.ID, user.Email, meta,
+		map[string]interface{}{"method": "change"})
+
+	if sessionScope != SessionScopePasswordChange {
+		return nil, nil
 	}
 
-	return nil
+	user.Password = &hashedPassword
+	user.MustChangePassword = false
+	user.PasswordChangedAt = &now
+	_ = s.sessions.Revoke(sessionID)
+	return s.sessions.Issue(&user, s.sessions.ScopeFor(&user), meta)
 }
@@ -0,0 +1,160 @@
+// server/internal/services/email_verification_service.go
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/config"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/pkgs/logger"
+	"server/pkgs/mailer"
+)
+
+var (
+	ErrEmailNotVerified         = errors.New("debes verificar tu correo antes de iniciar sesión")
+	ErrVerificationTokenInvalid = errors.New("el enlace de verificación es inválido o expiró")
+)
+
+type EmailVerificationService struct {
+	db   *gorm.DB
+	mail *mailer.Mailer
+	cfg  config.VerificationConfig
+}
+
+func NewEmailVerificationService(db *gorm.DB, mail *mailer.Mailer, cfg config.VerificationConfig) *EmailVerificationService {
+	return &EmailVerificationService{db: db, mail: mail, cfg: cfg}
+}
+
+// forRequest devuelve una copia del servicio cuyas consultas llevan el
+// contexto de la petición
+func (s *EmailVerificationService) forRequest(ctx context.Context) *EmailVerificationService {
+	bound := *s
+	bound.db = s.db.WithContext(ctx)
+	return &bound
+}
+
+// SendVerification invalida los tokens previos del usuario y envía uno nuevo.
+// Solo se guarda el hash; el valor en claro viaja únicamente en el correo.
+func (s *EmailVerificationService) SendVerification(user *models.User) error {
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	identifier := normalizeEmail(user.Email)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("identifier = ?", identifier).Delete(&models.VerificationToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.VerificationToken{
+			Identifier: identifier,
+			Token:      hashVerificationToken(token),
+			Expires:    time.Now().Add(s.cfg.TokenTTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mail.SendTemplate(user.Email, "verification", map[string]interface{}{
+		"Name":  deref(user.Name),
+		"Token": token,
+		"Hours": int(s.cfg.TokenTTL.Hours()),
+	})
+}
+
+// Verify consume el token (un solo uso) y marca el correo como verificado. El
+// cambio se audita a nombre del dueño del correo.
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) (*dto.VerifyEmailResponse, error) {
+	s = s.forRequest(ctx)
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrVerificationTokenInvalid
+	}
+
+	var user models.User
+	verifiedAt := time.Now()
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var record models.VerificationToken
+		if err := tx.Where("token = ? AND expires > ?", hashVerificationToken(token), time.Now()).
+			First(&record).Error; err != nil {
+			return ErrVerificationTokenInvalid
+		}
+
+		// El borrado condicional evita que dos peticiones usen el mismo token
+		res := tx.Where("token = ?", record.Token).Delete(&models.VerificationToken{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrVerificationTokenInvalid
+		}
+
+		if err := tx.Where("LOWER(email) = ?", record.Identifier).First(&user).Error; err != nil {
+			return ErrVerificationTokenInvalid
+		}
+		if user.EmailVerified != nil {
+			verifiedAt = *user.EmailVerified
+			return nil
+		}
+		return asActor(tx, user.ID).Model(&user).Update("email_verified", verifiedAt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.VerifyEmailResponse{
+		Email:      user.Email,
+		VerifiedAt: verifiedAt.Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// Resend reenvía el correo si la cuenta existe, no está verificada y pasó el
+// tiempo de espera. El llamador recibe siempre la misma respuesta: ni la
+// espera ni un fallo de envío deben revelar que el correo está registrado.
+func (s *EmailVerificationService) Resend(ctx context.Context, email string) {
+	s = s.forRequest(ctx)
+	identifier := normalizeEmail(email)
+	if identifier == "" {
+		return
+	}
+
+	var user models.User
+	if err := s.db.Where("LOWER(email) = ? AND is_active = ?", identifier, true).First(&user).Error; err != nil {
+		return
+	}
+	if user.EmailVerified != nil {
+		return
+	}
+
+	var last models.VerificationToken
+	if err := s.db.Where("identifier = ?", identifier).Order("created_at DESC").First(&last).Error; err == nil {
+		if time.Since(last.CreatedAt) < s.cfg.ResendCooldown {
+			return
+		}
+	}
+
+	if err := s.SendVerification(&user); err != nil {
+		logger.Log.Errorf("❌ No se pudo reenviar la verificación a %s: %v", user.Email, err)
+	}
+}
+
+// CheckSignin rechaza el login si la política exige correo verificado
+func (s *EmailVerificationService) CheckSignin(user *models.User) error {
+	if s.cfg.Required && user.EmailVerified == nil {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,6 +2,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"regexp"
 	"server/internal/dto"
@@ -14,18 +15,33 @@ import (
 
 type AuthService interface {
 	Signin(req dto.SigninRequest) (*dto.AuthResponse, error)
-	Signup(req dto.SignupRequest) (*dto.AuthResponse, error)
+	Signup(ctx context.Context, req dto.SignupRequest) (*dto.AuthResponse, error)
 }
 
 type authServiceImpl struct {
-	db    *gorm.DB
-	argon *security.Argon2Service
+	db       *gorm.DB
+	argon    *security.Argon2Service
+	throttle *LoginThrottleService
+	sessions *SessionService
+	verify   *EmailVerificationService
+	policy   *PasswordPolicyService
+	backends []AuthBackend
 }
 
-func NewAuthService(db *gorm.DB, argon *security.Argon2Service) AuthService {
+// NewAuthService recibe los backends de autenticación en orden de prioridad;
+// si no se indica ninguno se usa solo la contraseña local.
+func NewAuthService(db *gorm.DB, argon *security.Argon2Service, throttle *LoginThrottleService, sessions *SessionService, verify *EmailVerificationService, policy *PasswordPolicyService, backends ...AuthBackend) AuthService {
+	if len(backends) == 0 {
+		backends = []AuthBackend{NewPasswordBackend(db, argon)}
+	}
 	return &authServiceImpl{
-		db:    db,
-		argon: argon,
+		db:       db,
+		argon:    argon,
+		throttle: throttle,
+		sessions: sessions,
+		verify:   verify,
+		policy:   policy,
+		backends: backends,
 	}
 }
 
@@ -36,7 +52,7 @@ var (
 	ErrInvalidCredentials     = errors.New("invalid credentials")
 	ErrUserInactive           = errors.New("user is inactive")
 	ErrInvalidLoginMethod     = errors.New("invalid login method, use OAuth provider")
-	ErrGoogleUserNotRegistered = errors.New("google user not registered")
+	ErrProviderSigninUnsupported = errors.New("provider sign-in must use /auth/oidc/{provider}/authorize")
 
 	ErrSignupEmailRequired            = errors.New("email is required")
 	ErrSignupEmailInvalid             = errors.New("email is invalid")
@@ -48,19 +64,14 @@ var (
 
 var emailRx = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
 
-func (s *authServiceImpl) buildAuthResponse(u *models.User) *dto.AuthResponse {
-	var office *string
-	if u.Office != nil {
-		str := string(*u.Office)
-		office = &str
-	}
+func buildAuthResponse(u *models.User) *dto.AuthResponse {
 	return &dto.AuthResponse{
 		ID:     u.ID,
 		Email:  u.Email,
 		Name:   u.Name,
 		Image:  u.Image,
 		Role:   string(u.Rol),
-		Office: office,
+		Office: officeCode(u.Office),
 	}
 }
 
@@ -5,8 +5,6 @@ import (
 	"errors"
 	"server/internal/dto"
 	"server/internal/models"
-
-	"gorm.io/gorm"
 )
 
 func (s *authServiceImpl) Signin(req dto.SigninRequest) (*dto.AuthResponse, error) {
@@ -17,51 +15,67 @@ func (s *authServiceImpl) Signin(req dto.SigninRequest) (*dto.AuthResponse, erro
 		return nil, ErrSigninEmailInvalid
 	}
 
+	// Google se configura como proveedor OIDC y entra por /auth/oidc/google,
+	// donde se valida el id_token; aquí solo llegaría un correo sin prueba
 	if req.Provider == "google" {
-		var user models.User
-		if err := s.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return nil, ErrGoogleUserNotRegistered
-			}
-			return nil, err
-		}
-
-		if !user.IsActive {
-			return nil, errors.New("user inactive")
-		}
-
-		user.LastLogin = ptrTimeNow()
-		s.db.Save(&user)
-
-		return s.buildAuthResponse(&user), nil
+		return nil, ErrProviderSigninUnsupported
 	}
 
 	if req.Password == "" {
 		return nil, ErrSigninPasswordRequired
 	}
 
-	var user models.User
-	if err := s.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrUserNotFound
-		}
+	if err := s.throttle.Check(req.Email, req.IP); err != nil {
 		return nil, err
 	}
 
-	if !user.IsActive {
-		return nil, ErrUserInactive
+	user, err := s.authenticate(req.Email, req.Password)
+	if err != nil {
+		if isBackendMiss(err) {
+			s.throttle.RegisterFailure(req.Email, req.IP, nil)
+			s.recordFailedSignin(req, err)
+		}
+		return nil, err
 	}
 
-	if user.Password == nil || *user.Password == "" {
-		return nil, ErrInvalidLoginMethod
+	s.throttle.RegisterSuccess(req.Email, req.IP)
+
+	if err := s.verify.CheckSignin(user); err != nil {
+		return nil, err
 	}
 
-	if err := s.argon.ComparePassword(*user.Password, req.Password); err != nil {
-		return nil, ErrInvalidCredentials
+	return s.sessions.CompleteLogin(user, LoginMeta{IP: req.IP, UserAgent: req.UserAgent})
+}
+
+// authenticate prueba cada backend en orden; si ninguno reconoce al usuario
+// devuelve el error más específico obtenido.
+func (s *authServiceImpl) authenticate(email, password string) (*models.User, error) {
+	lastErr := ErrUserNotFound
+	for _, backend := range s.backends {
+		user, err := backend.Authenticate(email, password)
+		if err == nil {
+			return user, nil
+		}
+		if !isBackendMiss(err) {
+			return nil, err
+		}
+		if !errors.Is(err, ErrUserNotFound) {
+			lastErr = err
+		}
 	}
+	return nil, lastErr
+}
 
-	user.LastLogin = ptrTimeNow()
-	s.db.Save(&user)
+// recordFailedSignin guarda el intento fallido en el registro de seguridad,
+// ligado al usuario si el correo corresponde a una cuenta
+func (s *authServiceImpl) recordFailedSignin(req dto.SigninRequest, cause error) {
+	var userID *string
+	var user models.User
+	if err := s.db.Select("id").Where("LOWER(email) = ?", normalizeEmail(req.Email)).First(&user).Error; err == nil {
+		userID = &user.ID
+	}
 
-	return s.buildAuthResponse(&user), nil
-}
\ No newline at end of file
+	recordClientSecurityEvent(s.db, models.SecurityEventLoginFailed, userID, normalizeEmail(req.Email),
+		LoginMeta{IP: req.IP, UserAgent: req.UserAgent},
+		map[string]interface{}{"reason": cause.Error()})
+}
@@ -2,18 +2,17 @@
 package services
 
 import (
-	"crypto/rand"
+	"context"
 	"errors"
-	"math/big"
 	"server/internal/dto"
 	"server/internal/models"
-	"server/pkgs/security"
+	"server/pkgs/logger"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-func (s *authServiceImpl) Signup(req dto.SignupRequest) (*dto.AuthResponse, error) {
+func (s *authServiceImpl) Signup(ctx context.Context, req dto.SignupRequest) (*dto.AuthResponse, error) {
 	if req.Email == "" {
 		return nil, ErrSignupEmailRequired
 	}
@@ -25,7 +24,7 @@ func (s *authServiceImpl) Signup(req dto.SignupRequest) (*dto.AuthResponse, erro
 	}
 
 	var existing models.User
-	if err := s.db.Where("email = ?", req.Email).First(&existing).Error; err == nil {
+	if err := s.db.Where("LOWER(email) = ?", normalizeEmail(req.Email)).First(&existing).Error; err == nil {
 		return nil, ErrSignupEmailTaken
 	}
 
@@ -33,6 +32,10 @@ func (s *authServiceImpl) Signup(req dto.SignupRequest) (*dto.AuthResponse, erro
 		return nil, ErrSignupPasswordOrProviderNeeded
 	}
 
+	if err := s.policy.Validate(req.Password, &models.User{Email: req.Email}); err != nil {
+		return nil, err
+	}
+
 	hashed, err := s.argon.HashPassword(req.Password)
 	if err != nil {
 		return nil, err
@@ -40,67 +43,86 @@ func (s *authServiceImpl) Signup(req dto.SignupRequest) (*dto.AuthResponse, erro
 
 	now := time.Now()
 	user := models.User{
-		Email:         req.Email,
-		Name:          req.Name,
-		Image:         req.Image,
-		Rol:           models.RolEmployee,
-		IsActive:      true,
-		Password:      &hashed,
-		EmailVerified: &now,
-		CreatedAt:     now,
-		UpdatedAt:     now,
-	}
-
-	if err := s.db.Create(&user).Error; err != nil {
+		Email:     normalizeEmail(req.Email),
+		Name:      req.Name,
+		Image:     req.Image,
+		Rol:       models.RolEmployee,
+		IsActive:  true,
+		Password:  &hashed,
+		CreatedAt: now,
+		UpdatedAt: now,
+
+		PasswordChangedAt: &now,
+	}
+
+	// Sin sesión, el alta queda auditada con la IP y el request de la petición
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return s.policy.Remember(tx, user.ID, hashed)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return s.buildAuthResponse(&user), nil
+	// La cuenta queda sin verificar hasta que se use el enlace del correo
+	if err := s.verify.SendVerification(&user); err != nil {
+		logger.Log.Errorf("❌ No se pudo enviar la verificación a %s: %v", user.Email, err)
+	}
+
+	return buildAuthResponse(&user), nil
 }
 
 type UserManagementService struct {
-	db            *gorm.DB
-	argon2Service *security.Argon2Service
+	db          *gorm.DB
+	invitations *InvitationService
+	sessions    *SessionService
+	resets      *PasswordResetService
+}
+
+func NewUserManagementService(db *gorm.DB, invitations *InvitationService, sessions *SessionService, resets *PasswordResetService) *UserManagementService {
+	return &UserManagementService{db: db, invitations: invitations, sessions: sessions, resets: resets}
 }
 
-func NewUserManagementService(db *gorm.DB, argon2Service *security.Argon2Service) *UserManagementService {
-	return &UserManagementService{db: db, argon2Service: argon2Service}
+// forRequest devuelve una copia del servicio cuyas consultas llevan el
+// contexto de la petición: scopes de la API key y autor de los cambios
+func (s *UserManagementService) forRequest(ctx context.Context) *UserManagementService {
+	bound := *s
+	bound.db = s.db.WithContext(ctx)
+	bound.invitations = s.invitations.forRequest(ctx)
+	bound.resets = s.resets.forRequest(ctx)
+	return &bound
 }
 
 var (
-	ErrCreateUserEmailRequired      = errors.New("email is required")
-	ErrCreateUserEmailInvalid       = errors.New("email is invalid")
-	ErrCreateUserEmailTaken         = errors.New("el correo ya está registrado")
-	ErrCreateUserRoleInvalid        = errors.New("role must be MANAGER or EMPLOYEE")
-	ErrCreateUserOfficeRequired     = errors.New("office is required")
-	ErrManagerCanOnlyCreateEmployee = errors.New("manager can only create employees")
-	ErrUnauthorizedAccess           = errors.New("no tienes permisos para acceder a esta información")
-	ErrEmployeeCannotList           = errors.New("los empleados no tienen acceso a esta funcionalidad")
+	ErrCreateUserEmailRequired  = errors.New("email is required")
+	ErrCreateUserEmailInvalid   = errors.New("email is invalid")
+	ErrCreateUserEmailTaken     = errors.New("el correo ya está registrado")
+	ErrCreateUserRoleInvalid    = errors.New("el rol no existe")
+	ErrCreateUserOfficeRequired = errors.New("office is required")
+	ErrUnauthorizedAccess       = errors.New("no tienes permisos para acceder a esta información")
+	ErrEmployeeCannotList       = errors.New("los empleados no tienen acceso a esta funcionalidad")
+	ErrCannotManageUser         = errors.New("no tienes permisos sobre este usuario")
+	ErrUserHasNoLocalPassword   = errors.New("el usuario no usa contraseña local")
+	ErrManagedUserNotFound      = errors.New("usuario no encontrado")
+	ErrCannotManageSelf         = errors.New("no puedes realizar esta acción sobre tu propia cuenta")
+	ErrOfficeInvalid            = errors.New("oficina inválida")
+	ErrUserAlreadyInactive      = errors.New("el usuario ya está desactivado")
+	ErrUserAlreadyActive        = errors.New("el usuario ya está activo")
+	ErrUserInactiveCannotReset  = errors.New("el usuario está desactivado")
 )
 
-func generateRandomPassword(length int) (string, error) {
-	const chars = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghjkmnpqrstuvwxyz23456789"
-	password := make([]byte, length)
-	for i := range password {
-		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
-		if err != nil {
-			return "", err
-		}
-		password[i] = chars[num.Int64()]
-	}
-	return string(password), nil
-}
-
-func (s *UserManagementService) CreateUser(req dto.CreateUserRequest, createdByID string) (*dto.CreateUserResponse, error) {
+// CreateUser emite una invitación; la cuenta se crea cuando el invitado
+// define su contraseña desde el enlace recibido por correo.
+func (s *UserManagementService) CreateUser(ctx context.Context, req dto.CreateUserRequest, createdByID string) (*dto.InvitationResponse, error) {
+	s = s.forRequest(ctx)
 	if req.Email == "" {
 		return nil, ErrCreateUserEmailRequired
 	}
 	if !emailRx.MatchString(req.Email) {
 		return nil, ErrCreateUserEmailInvalid
 	}
-	if req.Role != "MANAGER" && req.Role != "EMPLOYEE" {
-		return nil, ErrCreateUserRoleInvalid
-	}
 	if req.Office == nil || *req.Office == "" {
 		return nil, ErrCreateUserOfficeRequired
 	}
@@ -110,109 +132,151 @@ func (s *UserManagementService) CreateUser(req dto.CreateUserRequest, createdByI
 		return nil, errors.New("usuario creador no encontrado")
 	}
 
-	if creator.Rol == models.RolManager && req.Role != "EMPLOYEE" {
-		return nil, ErrManagerCanOnlyCreateEmployee
+	if err := requirePermission(s.db, &creator, models.PermUserCreate); err != nil {
+		return nil, err
+	}
+	if err := assignableRole(s.db, &creator, models.Rol(req.Role)); err != nil {
+		return nil, err
 	}
 
 	var existingUser models.User
-	if err := s.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+	if err := s.db.Where("LOWER(email) = ?", normalizeEmail(req.Email)).First(&existingUser).Error; err == nil {
 		return nil, ErrCreateUserEmailTaken
 	}
 
-	randomPassword, err := generateRandomPassword(12)
+	office, err := findActiveOffice(s.db, *req.Office)
 	if err != nil {
-		return nil, errors.New("error al generar contraseña")
+		return nil, err
 	}
-
-	hashedPassword, err := s.argon2Service.HashPassword(randomPassword)
+	all, err := requesterCan(s.db, &creator, models.PermUserManageAll)
 	if err != nil {
-		return nil, errors.New("error al hashear la contraseña")
+		return nil, err
+	}
+	if !all {
+		managed, err := managedOfficeIDs(s.db, &creator)
+		if err != nil {
+			return nil, err
+		}
+		if !containsOffice(managed, &office.ID) {
+			return nil, ErrCannotManageUser
+		}
 	}
 
-	now := time.Now()
-	office := models.Office(*req.Office)
+	invitation := models.Invitation{
+		Name:     req.Name,
+		Email:    normalizeEmail(req.Email),
+		Rol:      models.Rol(req.Role),
+		OfficeID: &office.ID,
+		Phone:    req.Phone,
+	}
 
-	user := models.User{
-		Name:          req.Name,
-		Email:         req.Email,
-		Password:      &hashedPassword,
-		Rol:           models.Rol(req.Role),
-		Office:        &office,
-		Phone:         req.Phone,
-		IsActive:      true,
-		EmailVerified: &now,
-		CreatedByID:   &createdByID,
-		CreatedAt:     now,
-		UpdatedAt:     now,
-	}
-
-	if err := s.db.Create(&user).Error; err != nil {
+	if err := s.invitations.Create(&invitation, &creator); err != nil {
 		return nil, err
 	}
 
-	officeStr := string(*user.Office)
-
-	return &dto.CreateUserResponse{
-		ID:                user.ID,
-		Name:              user.Name,
-		Email:             user.Email,
-		Role:              string(user.Rol),
-		Office:            &officeStr,
-		Phone:             user.Phone,
-		GeneratedPassword: &randomPassword,
-	}, nil
+	invitation.Office = office
+	return buildInvitationResponse(&invitation), nil
 }
 
-func (s *UserManagementService) GetAllUsers(requestedByID string) ([]dto.UserListResponse, error) {
+func (s *UserManagementService) GetAllUsers(ctx context.Context, requestedByID string) ([]dto.UserListResponse, error) {
+	s = s.forRequest(ctx)
 	var requester models.User
 	if err := s.db.Where("id = ?", requestedByID).First(&requester).Error; err != nil {
 		return nil, errors.New("usuario solicitante no encontrado")
 	}
 
+	// Se incluyen los desactivados para poder reactivarlos
 	var users []models.User
-	query := s.db.Where("is_active = ?", true)
+	query := s.db.Model(&models.User{}).Preload("Office").Preload("Memberships.Office")
+	invitations := s.db.Preload("Office").Where("status = ? AND expires > ?", models.InvitationPending, time.Now())
 
-	switch requester.Rol {
-	case models.RolEmployee:
+	if err := requirePermission(s.db, &requester, models.PermUserList); err != nil {
 		return nil, ErrEmployeeCannotList
+	}
 
-	case models.RolManager:
-		if requester.Office == nil {
-			return nil, errors.New("manager sin oficina asignada")
-		}
-		// Solo trae EMPLOYEES de su misma oficina, excluyéndose a sí mismo
-		query = query.Where("office = ? AND rol = ? AND id != ?", *requester.Office, models.RolEmployee, requestedByID)
-
-	case models.RolAdmin:
-		query = query.Where("rol IN (?, ?)", models.RolManager, models.RolEmployee)
-
-	default:
+	// Solo se listan usuarios de roles con menor jerarquía
+	lower, err := lowerRoles(s.db, &requester)
+	if err != nil {
+		return nil, err
+	}
+	if len(lower) == 0 {
 		return nil, ErrUnauthorizedAccess
 	}
+	query = query.Where("rol IN ? AND id != ? AND is_service_account = ?", lower, requestedByID, false)
+	invitations = invitations.Where("rol IN ?", lower)
+
+	all, err := requesterCan(s.db, &requester, models.PermUserManageAll)
+	if err != nil {
+		return nil, err
+	}
+	if !all {
+		managed, err := managedOfficeIDs(s.db, &requester)
+		if err != nil {
+			return nil, err
+		}
+		if len(managed) == 0 {
+			return nil, errors.New("no supervisas ninguna oficina")
+		}
+		// Usuarios de las oficinas que supervisa, como principal o adicional
+		members := s.db.Model(&models.OfficeMembership{}).Select("user_id").Where("office_id IN ?", managed)
+		query = query.Where("office_id IN ? OR id IN (?)", managed, members)
+		invitations = invitations.Where("office_id IN ?", managed)
+	}
 
 	if err := query.Order("created_at DESC").Find(&users).Error; err != nil {
 		return nil, err
 	}
 
-	var response []dto.UserListResponse
-	for _, user := range users {
-		var officeStr *string
-		if user.Office != nil {
-			str := string(*user.Office)
-			officeStr = &str
-		}
+	var pending []models.Invitation
+	if err := invitations.Order("created_at DESC").Find(&pending).Error; err != nil {
+		return nil, err
+	}
 
+	var response []dto.UserListResponse
+	for _, invitation := range pending {
+		item := buildInvitationResponse(&invitation)
 		response = append(response, dto.UserListResponse{
-			ID:        user.ID,
-			Name:      user.Name,
-			Email:     user.Email,
-			Role:      string(user.Rol),
-			Office:    officeStr,
-			Phone:     user.Phone,
-			IsActive:  user.IsActive,
-			CreatedAt: user.CreatedAt.Format("2006-01-02 15:04:05"),
+			ID:                  item.ID,
+			Name:                item.Name,
+			Email:               item.Email,
+			Role:                item.Role,
+			Office:              item.Office,
+			Phone:               item.Phone,
+			IsActive:            false,
+			CreatedAt:           invitation.CreatedAt.Format("2006-01-02 15:04:05"),
+			Status:              "INVITED",
+			InvitationExpiresAt: &item.ExpiresAt,
 		})
 	}
 
+	for _, user := range users {
+		response = append(response, buildUserListItem(&user))
+	}
+
 	return response, nil
 }
+
+func buildUserListItem(user *models.User) dto.UserListResponse {
+	status := "ACTIVE"
+	if !user.IsActive {
+		status = "INACTIVE"
+	}
+
+	var offices []string
+	for _, membership := range user.Memberships {
+		offices = append(offices, membership.Office.Code)
+	}
+
+	return dto.UserListResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      string(user.Rol),
+		Office:    officeCode(user.Office),
+		Phone:     user.Phone,
+		IsActive:  user.IsActive,
+		CreatedAt: user.CreatedAt.Format("2006-01-02 15:04:05"),
+		Status:    status,
+		Offices:   offices,
+	}
+}
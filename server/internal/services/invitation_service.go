@@ -0,0 +1,304 @@
+// server/internal/services/invitation_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/pkgs/mailer"
+	"server/pkgs/security"
+)
+
+const invitationResendCooldown = time.Minute
+
+var (
+	ErrInvitationNotFound      = errors.New("invitación no encontrada")
+	ErrInvitationPending       = errors.New("ya existe una invitación pendiente para este correo")
+	ErrInvitationNotPending    = errors.New("la invitación ya fue aceptada o revocada")
+	ErrInvitationInvalid       = errors.New("el enlace de invitación es inválido o expiró")
+	ErrInvitationResendTooSoon = errors.New("debes esperar antes de reenviar la invitación")
+	ErrInvitationForbidden     = errors.New("no tienes permisos sobre esta invitación")
+)
+
+type InvitationService struct {
+	db            *gorm.DB
+	argon2Service *security.Argon2Service
+	mail          *mailer.Mailer
+	ttl           time.Duration
+	policy        *PasswordPolicyService
+}
+
+func NewInvitationService(db *gorm.DB, argon2Service *security.Argon2Service, mail *mailer.Mailer, ttl time.Duration, policy *PasswordPolicyService) *InvitationService {
+	return &InvitationService{db: db, argon2Service: argon2Service, mail: mail, ttl: ttl, policy: policy}
+}
+
+// forRequest devuelve una copia del servicio cuyas consultas llevan el
+// contexto de la petición
+func (s *InvitationService) forRequest(ctx context.Context) *InvitationService {
+	bound := *s
+	bound.db = s.db.WithContext(ctx)
+	return &bound
+}
+
+// Create registra la invitación y envía el enlace. Las invitaciones pendientes
+// ya vencidas para el mismo correo se revocan.
+func (s *InvitationService) Create(invitation *models.Invitation, inviter *models.User) error {
+	email := normalizeEmail(invitation.Email)
+
+	var pending models.Invitation
+	if err := s.db.Where("LOWER(email) = ? AND status = ? AND expires > ?", email, models.InvitationPending, time.Now()).
+		First(&pending).Error; err == nil {
+		return ErrInvitationPending
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	invitation.InvitedByID = inviter.ID
+	invitation.TokenHash = hashVerificationToken(token)
+	invitation.Status = models.InvitationPending
+	invitation.Expires = now.Add(s.ttl)
+	invitation.LastSentAt = now
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Invitation{}).
+			Where("LOWER(email) = ? AND status = ?", email, models.InvitationPending).
+			Updates(map[string]interface{}{"status": models.InvitationRevoked, "revoked_at": now}).Error; err != nil {
+			return err
+		}
+		return tx.Omit("InvitedBy").Create(invitation).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.send(invitation, inviter, token)
+}
+
+// Resend genera un enlace nuevo (el anterior deja de servir) y extiende la vigencia
+func (s *InvitationService) Resend(ctx context.Context, invitationID, requestedByID string) (*dto.InvitationResponse, error) {
+	s = s.forRequest(ctx)
+	invitation, requester, err := s.loadForRequester(invitationID, requestedByID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Status != models.InvitationPending {
+		return nil, ErrInvitationNotPending
+	}
+	if time.Since(invitation.LastSentAt) < invitationResendCooldown {
+		return nil, ErrInvitationResendTooSoon
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.db.Model(invitation).Updates(map[string]interface{}{
+		"token_hash":   hashVerificationToken(token),
+		"expires":      now.Add(s.ttl),
+		"last_sent_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	var inviter models.User
+	if err := s.db.Where("id = ?", invitation.InvitedByID).First(&inviter).Error; err != nil {
+		inviter = *requester
+	}
+
+	if err := s.send(invitation, &inviter, token); err != nil {
+		return nil, err
+	}
+
+	return buildInvitationResponse(invitation), nil
+}
+
+// Revoke anula una invitación pendiente
+func (s *InvitationService) Revoke(ctx context.Context, invitationID, requestedByID string) error {
+	s = s.forRequest(ctx)
+	invitation, _, err := s.loadForRequester(invitationID, requestedByID)
+	if err != nil {
+		return err
+	}
+	if invitation.Status != models.InvitationPending {
+		return ErrInvitationNotPending
+	}
+
+	res := s.db.Model(&models.Invitation{}).
+		Where("id = ? AND status = ?", invitation.ID, models.InvitationPending).
+		Updates(map[string]interface{}{"status": models.InvitationRevoked, "revoked_at": time.Now()})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvitationNotPending
+	}
+	return nil
+}
+
+// Accept crea la cuenta con la contraseña elegida por el invitado.
+// El correo queda verificado porque el enlace llegó a esa dirección.
+func (s *InvitationService) Accept(ctx context.Context, req dto.AcceptInvitationRequest) (*dto.AuthResponse, error) {
+	s = s.forRequest(ctx)
+	token := strings.TrimSpace(req.Token)
+	if token == "" {
+		return nil, ErrInvitationInvalid
+	}
+	tokenHash := hashVerificationToken(token)
+
+	var pending models.Invitation
+	if err := s.db.Where("token_hash = ? AND status = ? AND expires > ?",
+		tokenHash, models.InvitationPending, time.Now()).
+		First(&pending).Error; err != nil {
+		return nil, ErrInvitationInvalid
+	}
+	if err := s.policy.Validate(req.Password, &models.User{Email: pending.Email}); err != nil {
+		return nil, err
+	}
+
+	hashed, err := s.argon2Service.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("error al hashear la contraseña: %w", err)
+	}
+
+	var user models.User
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var invitation models.Invitation
+		if err := tx.Preload("Office").Where("token_hash = ? AND status = ? AND expires > ?",
+			tokenHash, models.InvitationPending, time.Now()).
+			First(&invitation).Error; err != nil {
+			return ErrInvitationInvalid
+		}
+
+		var existing int64
+		tx.Model(&models.User{}).Where("LOWER(email) = ?", normalizeEmail(invitation.Email)).Count(&existing)
+		if existing > 0 {
+			return ErrCreateUserEmailTaken
+		}
+
+		now := time.Now()
+		user = models.User{
+			Name:          invitation.Name,
+			Email:         invitation.Email,
+			Password:      &hashed,
+			Rol:           invitation.Rol,
+			OfficeID:      invitation.OfficeID,
+			Phone:         invitation.Phone,
+			IsActive:      true,
+			EmailVerified: &now,
+			CreatedByID:   &invitation.InvitedByID,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+
+			PasswordChangedAt: &now,
+		}
+		if err := tx.Omit("CreatedBy").Create(&user).Error; err != nil {
+			return err
+		}
+		user.Office = invitation.Office
+		if err := s.policy.Remember(tx, user.ID, hashed); err != nil {
+			return err
+		}
+
+		// La actualización condicional evita aceptar dos veces la misma invitación
+		res := tx.Model(&models.Invitation{}).
+			Where("id = ? AND status = ?", invitation.ID, models.InvitationPending).
+			Updates(map[string]interface{}{
+				"status":      models.InvitationAccepted,
+				"accepted_at": now,
+				"user_id":     user.ID,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvitationInvalid
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buildAuthResponse(&user), nil
+}
+
+// loadForRequester aplica la jerarquía: se gestionan invitaciones de roles
+// menores y, sin user.manage.all, solo las de las oficinas que se supervisan.
+func (s *InvitationService) loadForRequester(invitationID, requestedByID string) (*models.Invitation, *models.User, error) {
+	var requester models.User
+	if err := s.db.Where("id = ? AND is_active = ?", requestedByID, true).First(&requester).Error; err != nil {
+		return nil, nil, errors.New("usuario solicitante no encontrado")
+	}
+
+	var invitation models.Invitation
+	if err := s.db.Preload("Office").Where("id = ?", invitationID).First(&invitation).Error; err != nil {
+		return nil, nil, ErrInvitationNotFound
+	}
+
+	if err := requirePermission(s.db, &requester, models.PermUserCreate); err != nil {
+		return nil, nil, ErrInvitationForbidden
+	}
+	above, err := outranks(s.db, requester.Rol, invitation.Rol)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !above {
+		return nil, nil, ErrInvitationForbidden
+	}
+
+	all, err := requesterCan(s.db, &requester, models.PermUserManageAll)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !all {
+		managed, err := managedOfficeIDs(s.db, &requester)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !containsOffice(managed, invitation.OfficeID) {
+			return nil, nil, ErrInvitationForbidden
+		}
+	}
+
+	return &invitation, &requester, nil
+}
+
+func (s *InvitationService) send(invitation *models.Invitation, inviter *models.User, token string) error {
+	inviterName := inviter.Email
+	if inviter.Name != nil && *inviter.Name != "" {
+		inviterName = *inviter.Name
+	}
+
+	return s.mail.SendTemplate(invitation.Email, "invitation", map[string]interface{}{
+		"Name":        deref(invitation.Name),
+		"InviterName": inviterName,
+		"Role":        string(invitation.Rol),
+		"Token":       token,
+		"Hours":       int(s.ttl.Hours()),
+	})
+}
+
+func buildInvitationResponse(invitation *models.Invitation) *dto.InvitationResponse {
+	return &dto.InvitationResponse{
+		ID:        invitation.ID,
+		Name:      invitation.Name,
+		Email:     invitation.Email,
+		Role:      string(invitation.Rol),
+		Office:    officeCode(invitation.Office),
+		Phone:     invitation.Phone,
+		Status:    string(invitation.Status),
+		ExpiresAt: invitation.Expires.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,60 @@
+// server/internal/services/login_alert.go
+package services
+
+import (
+	"time"
+
+	"server/internal/models"
+	"server/pkgs/logger"
+)
+
+// loginHistory resume los inicios de sesión anteriores de un usuario
+type loginHistory struct {
+	Total      int64
+	SameIP     int64
+	SameDevice int64
+}
+
+// detectNewLogin registra el inicio de sesión y, si la IP o el dispositivo
+// no aparecen en los anteriores del usuario, lo avisa por correo. El primer
+// inicio de sesión registrado no genera aviso.
+func (s *SessionService) detectNewLogin(user *models.User, meta LoginMeta) {
+	ip := truncate(meta.IP, 50)
+	device := truncate(meta.UserAgent, 255)
+
+	var history loginHistory
+	if err := s.db.Model(&models.SecurityEvent{}).
+		Select(`COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE ip = ?) AS same_ip,
+			COUNT(*) FILTER (WHERE user_agent = ?) AS same_device`, ip, device).
+		Where("user_id = ? AND type = ?", user.ID, models.SecurityEventLoginSucceeded).
+		Scan(&history).Error; err != nil {
+		logger.Log.Warnf("⚠️ No se pudo consultar el historial de inicios de sesión de %s: %v", user.Email, err)
+	}
+
+	recordClientSecurityEvent(s.db, models.SecurityEventLoginSucceeded, &user.ID, user.Email, meta, nil)
+
+	newIP := ip != "" && history.SameIP == 0
+	newDevice := device != "" && history.SameDevice == 0
+	if history.Total == 0 || (!newIP && !newDevice) {
+		return
+	}
+
+	location, _ := s.locate(meta.IP)
+	recordClientSecurityEvent(s.db, models.SecurityEventNewDeviceLogin, &user.ID, user.Email, meta, map[string]interface{}{
+		"newIp":     newIP,
+		"newDevice": newDevice,
+		"location":  location,
+	})
+
+	if err := s.mail.SendTemplate(user.Email, "new_login", map[string]interface{}{
+		"Name":     user.Name,
+		"IP":       ip,
+		"Location": deref(location),
+		"OS":       detectOS(meta.UserAgent),
+		"Device":   device,
+		"Time":     time.Now().Format("02/01/2006 15:04"),
+	}); err != nil {
+		logger.Log.Warnf("⚠️ No se pudo avisar del nuevo inicio de sesión a %s: %v", user.Email, err)
+	}
+}
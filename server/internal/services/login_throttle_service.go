@@ -0,0 +1,226 @@
+// server/internal/services/login_throttle_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"server/internal/config"
+	"server/internal/models"
+)
+
+const (
+	ThrottleScopeAccount = "account"
+	ThrottleScopeIP      = "ip"
+)
+
+var (
+	ErrTooManyAttempts = errors.New("demasiados intentos fallidos, intenta nuevamente más tarde")
+	ErrOnlyAdminUnlock = errors.New("no tienes permisos para desbloquear cuentas")
+)
+
+// LockoutError indica hasta cuándo está bloqueada la cuenta o IP
+type LockoutError struct {
+	Until time.Time
+}
+
+func (e *LockoutError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *LockoutError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+// RetryAfter devuelve los segundos restantes de bloqueo (mínimo 1)
+func (e *LockoutError) RetryAfter() int {
+	secs := int(math.Ceil(time.Until(e.Until).Seconds()))
+	if secs < 1 {
+		return 1
+	}
+	return secs
+}
+
+type LoginThrottleService struct {
+	db  *gorm.DB
+	cfg config.ThrottleConfig
+}
+
+func NewLoginThrottleService(db *gorm.DB, cfg config.ThrottleConfig) *LoginThrottleService {
+	return &LoginThrottleService{db: db, cfg: cfg}
+}
+
+// Check devuelve un *LockoutError si la cuenta o la IP están bloqueadas
+func (s *LoginThrottleService) Check(email, ip string) error {
+	keys := []struct{ scope, identifier string }{
+		{ThrottleScopeAccount, normalizeEmail(email)},
+		{ThrottleScopeIP, ip},
+	}
+
+	now := time.Now()
+	for _, k := range keys {
+		if k.identifier == "" {
+			continue
+		}
+		var throttle models.AuthThrottle
+		err := s.db.Where("scope = ? AND identifier = ?", k.scope, k.identifier).First(&throttle).Error
+		if err != nil {
+			continue
+		}
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+			return &LockoutError{Until: *throttle.LockedUntil}
+		}
+	}
+	return nil
+}
+
+// RegisterFailure suma un intento fallido para la cuenta y la IP
+func (s *LoginThrottleService) RegisterFailure(email, ip string, userID *string) {
+	if email != "" {
+		s.registerFailure(ThrottleScopeAccount, normalizeEmail(email), s.cfg.AccountMaxAttempts, userID, ip)
+	}
+	if ip != "" {
+		s.registerFailure(ThrottleScopeIP, ip, s.cfg.IPMaxAttempts, nil, ip)
+	}
+}
+
+// RegisterSuccess reinicia los contadores de la cuenta y los fallos de la IP
+func (s *LoginThrottleService) RegisterSuccess(email, ip string) {
+	if email != "" {
+		s.db.Model(&models.AuthThrottle{}).
+			Where("scope = ? AND identifier = ?", ThrottleScopeAccount, normalizeEmail(email)).
+			Updates(map[string]interface{}{"failures": 0, "lockout_count": 0, "locked_until": nil})
+	}
+	// La IP conserva su historial de bloqueos; solo se limpian los fallos
+	if ip != "" {
+		s.db.Model(&models.AuthThrottle{}).
+			Where("scope = ? AND identifier = ?", ThrottleScopeIP, ip).
+			Update("failures", 0)
+	}
+}
+
+func (s *LoginThrottleService) registerFailure(scope, identifier string, maxAttempts int, userID *string, ip string) {
+	if maxAttempts <= 0 {
+		return
+	}
+
+	var lockedUntil *time.Time
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.AuthThrottle{Scope: scope, Identifier: identifier})
+
+		var throttle models.AuthThrottle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("scope = ? AND identifier = ?", scope, identifier).
+			First(&throttle).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		// Fallos fuera de la ventana no se acumulan
+		if throttle.LastFailureAt != nil && now.Sub(*throttle.LastFailureAt) > s.cfg.AttemptWindow {
+			throttle.Failures = 0
+		}
+
+		throttle.Failures++
+		throttle.LastFailureAt = &now
+
+		if throttle.Failures >= maxAttempts {
+			throttle.LockoutCount++
+			throttle.Failures = 0
+			until := now.Add(s.lockoutDuration(throttle.LockoutCount))
+			throttle.LockedUntil = &until
+			lockedUntil = &until
+		}
+
+		return tx.Save(&throttle).Error
+	})
+	if err != nil || lockedUntil == nil {
+		return
+	}
+
+	eventType := models.SecurityEventAccountLocked
+	var email *string
+	if scope == ThrottleScopeIP {
+		eventType = models.SecurityEventIPLocked
+	} else {
+		email = &identifier
+	}
+
+	var ipPtr *string
+	if ip != "" {
+		ipPtr = &ip
+	}
+
+	recordSecurityEvent(s.db, eventType, userID, email, ipPtr, map[string]interface{}{
+		"scope":       scope,
+		"lockedUntil": lockedUntil.Format(time.RFC3339),
+	})
+}
+
+// lockoutDuration crece exponencialmente: base, 2*base, 4*base... hasta el máximo
+func (s *LoginThrottleService) lockoutDuration(lockoutCount int) time.Duration {
+	if lockoutCount < 1 {
+		lockoutCount = 1
+	}
+	if lockoutCount > 30 {
+		return s.cfg.LockoutMax
+	}
+	d := s.cfg.LockoutBase * time.Duration(1<<uint(lockoutCount-1))
+	if d > s.cfg.LockoutMax || d <= 0 {
+		return s.cfg.LockoutMax
+	}
+	return d
+}
+
+// UnlockUser permite a quien tenga user.unlock levantar el bloqueo de una
+// cuenta que puede administrar: de rol inferior y, sin user.manage.all, de
+// una oficina que supervisa.
+func (s *LoginThrottleService) UnlockUser(adminID, userID, ip string) error {
+	var admin models.User
+	if err := s.db.Where("id = ? AND is_active = ?", adminID, true).First(&admin).Error; err != nil {
+		return errors.New("usuario solicitante no encontrado")
+	}
+	if err := requirePermission(s.db, &admin, models.PermUserUnlock); err != nil {
+		return ErrOnlyAdminUnlock
+	}
+	if adminID == userID {
+		return ErrCannotManageSelf
+	}
+
+	var user models.User
+	if err := s.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrManagedUserNotFound
+		}
+		return err
+	}
+	if err := checkManageable(s.db, &admin, &user, models.PermUserUnlock); err != nil {
+		return err
+	}
+
+	if err := s.db.Model(&models.AuthThrottle{}).
+		Where("scope = ? AND identifier = ?", ThrottleScopeAccount, normalizeEmail(user.Email)).
+		Updates(map[string]interface{}{"failures": 0, "lockout_count": 0, "locked_until": nil}).Error; err != nil {
+		return fmt.Errorf("error al desbloquear la cuenta: %w", err)
+	}
+
+	var ipPtr *string
+	if ip != "" {
+		ipPtr = &ip
+	}
+	recordSecurityEvent(s.db, models.SecurityEventAccountUnlocked, &user.ID, &user.Email, ipPtr, map[string]interface{}{
+		"unlockedBy": admin.ID,
+	})
+
+	return nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server/internal/config"
+	"server/internal/models"
+)
+
+func TestUnlockUserRespectsHierarchy(t *testing.T) {
+	db := testDB(t)
+
+	// Rol de soporte al nivel de MANAGER que sí puede desbloquear
+	var permissions []models.Permission
+	if err := db.Where("code IN ?", []string{models.PermUserUnlock, models.PermUserManageAll}).Find(&permissions).Error; err != nil {
+		t.Fatal(err)
+	}
+	support := models.Role{Name: "SOPORTE_TEST", Description: "Soporte", Level: 50}
+	if err := db.Where("name = ?", support.Name).FirstOrCreate(&support).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&support).Association("Permissions").Replace(permissions); err != nil {
+		t.Fatal(err)
+	}
+	invalidateRoleCache()
+	t.Cleanup(invalidateRoleCache)
+
+	requester := createTestUser(t, db, "Soporte", uniqueEmail(t, "soporte"), support.Name)
+	admin := createTestUser(t, db, "Admin", uniqueEmail(t, "admin"), models.RolAdmin)
+	manager := createTestUser(t, db, "Jefe", uniqueEmail(t, "jefe"), models.RolManager)
+	employee := createTestUser(t, db, "Empleado", uniqueEmail(t, "empleado"), models.RolEmployee)
+
+	throttle := NewLoginThrottleService(db, config.ThrottleConfig{})
+	cases := []struct {
+		name   string
+		target string
+		want   error
+	}{
+		{"rol superior", admin.ID, ErrCannotManageUser},
+		{"mismo nivel", manager.ID, ErrCannotManageUser},
+		{"propia cuenta", requester.ID, ErrCannotManageSelf},
+		{"rol inferior", employee.ID, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := throttle.UnlockUser(requester.ID, tc.target, "203.0.113.7"); !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, se esperaba %v", err, tc.want)
+			}
+		})
+	}
+
+	if err := throttle.UnlockUser(manager.ID, employee.ID, ""); !errors.Is(err, ErrOnlyAdminUnlock) {
+		t.Fatalf("MANAGER sin user.unlock: err = %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+// server/internal/services/office_membership.go
+package services
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"server/internal/dto"
+	"server/internal/models"
+)
+
+// Acciones de auditoría sobre las oficinas adicionales de un usuario
+const (
+	AuditUserOfficeGranted = "USER_OFFICE_GRANTED"
+	AuditUserOfficeRevoked = "USER_OFFICE_REVOKED"
+)
+
+var (
+	ErrMembershipNotFound    = errors.New("el usuario no pertenece a esa oficina")
+	ErrMembershipExists      = errors.New("el usuario ya pertenece a esa oficina")
+	ErrMembershipPrimary     = errors.New("es la oficina principal del usuario; cámbiala desde la edición del usuario")
+	ErrMembershipRoleInvalid = errors.New("el rol en la oficina debe existir y no puede superar el rol del usuario")
+)
+
+// ListMemberships devuelve la oficina principal y las adicionales del usuario
+func (s *UserManagementService) ListMemberships(ctx context.Context, userID, requesterID string) ([]dto.OfficeMembershipResponse, error) {
+	s = s.forRequest(ctx)
+	_, user, err := s.loadManageable(requesterID, userID, models.PermUserList)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildMemberships(user)
+}
+
+// GrantMembership agrega al usuario a una oficina adicional con el rol indicado
+func (s *UserManagementService) GrantMembership(ctx context.Context, userID string, req dto.GrantOfficeMembershipRequest, meta AuditMeta) ([]dto.OfficeMembershipResponse, error) {
+	s = s.forRequest(ctx)
+	_, user, err := s.loadManageable(meta.ActorID, userID, models.PermUserOfficeAssign)
+	if err != nil {
+		return nil, err
+	}
+
+	rol := models.Rol(req.Role)
+	grants, err := loadRoleGrants(s.db, rol)
+	if err != nil {
+		return nil, err
+	}
+	above, err := outranks(s.db, rol, user.Rol)
+	if err != nil {
+		return nil, err
+	}
+	if !grants.exists || above {
+		return nil, ErrMembershipRoleInvalid
+	}
+
+	office, err := findActiveOffice(s.db, req.Office)
+	if err != nil {
+		return nil, err
+	}
+	if user.OfficeID != nil && *user.OfficeID == office.ID {
+		return nil, ErrMembershipPrimary
+	}
+
+	err = withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.OfficeMembership{}).
+			Where("user_id = ? AND office_id = ?", user.ID, office.ID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrMembershipExists
+		}
+
+		membership := models.OfficeMembership{
+			UserID:      user.ID,
+			OfficeID:    office.ID,
+			Rol:         rol,
+			GrantedByID: optionalString(meta.ActorID),
+		}
+		if err := tx.Omit("User", "Office", "GrantedBy").Create(&membership).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditUserOfficeGranted, auditEntityUser, user.ID, map[string]interface{}{
+			"office": office.Code,
+			"rol":    rol,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recordSecurityEvent(s.db, models.SecurityEventRoleChanged, &user.ID, &user.Email, optionalString(meta.IP), map[string]interface{}{
+		"office":    office.Code,
+		"to":        rol,
+		"changedBy": meta.ActorID,
+	})
+
+	return s.buildMemberships(user)
+}
+
+// RevokeMembership quita al usuario de una oficina adicional
+func (s *UserManagementService) RevokeMembership(ctx context.Context, userID, officeID string, meta AuditMeta) ([]dto.OfficeMembershipResponse, error) {
+	s = s.forRequest(ctx)
+	_, user, err := s.loadManageable(meta.ActorID, userID, models.PermUserOfficeAssign)
+	if err != nil {
+		return nil, err
+	}
+	if user.OfficeID != nil && *user.OfficeID == officeID {
+		return nil, ErrMembershipPrimary
+	}
+
+	var membership models.OfficeMembership
+	err = withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Office").
+			Where("user_id = ? AND office_id = ?", user.ID, officeID).
+			First(&membership).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMembershipNotFound
+			}
+			return err
+		}
+
+		if err := tx.Delete(&membership).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditUserOfficeRevoked, auditEntityUser, user.ID, map[string]interface{}{
+			"office": membership.Office.Code,
+			"rol":    membership.Rol,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recordSecurityEvent(s.db, models.SecurityEventRoleChanged, &user.ID, &user.Email, optionalString(meta.IP), map[string]interface{}{
+		"office":    membership.Office.Code,
+		"from":      membership.Rol,
+		"changedBy": meta.ActorID,
+	})
+
+	return s.buildMemberships(user)
+}
+
+func (s *UserManagementService) buildMemberships(user *models.User) ([]dto.OfficeMembershipResponse, error) {
+	var memberships []models.OfficeMembership
+	if err := s.db.Preload("Office").Where("user_id = ?", user.ID).
+		Order("created_at ASC").Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.OfficeMembershipResponse, 0, len(memberships)+1)
+	if user.Office != nil {
+		response = append(response, dto.OfficeMembershipResponse{
+			OfficeID: user.Office.ID,
+			Code:     user.Office.Code,
+			Name:     user.Office.Name,
+			Role:     string(user.Rol),
+			Primary:  true,
+		})
+	}
+	for _, membership := range memberships {
+		grantedAt := membership.CreatedAt.Format("2006-01-02 15:04:05")
+		response = append(response, dto.OfficeMembershipResponse{
+			OfficeID:  membership.OfficeID,
+			Code:      membership.Office.Code,
+			Name:      membership.Office.Name,
+			Role:      string(membership.Rol),
+			GrantedAt: &grantedAt,
+		})
+	}
+	return response, nil
+}
+
+// managedOfficeIDs son las oficinas que el usuario supervisa: la principal si
+// su rol tiene user.manage y las adicionales cuyo rol de pertenencia lo tiene.
+// Con una API key sin el scope user.manage no supervisa ninguna.
+func managedOfficeIDs(db *gorm.DB, user *models.User) ([]string, error) {
+	if !scopeAllows(db, models.PermUserManage) {
+		return nil, nil
+	}
+
+	var memberships []models.OfficeMembership
+	if err := db.Where("user_id = ?", user.ID).Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, membership := range memberships {
+		ok, err := hasPermission(db, membership.Rol, models.PermUserManage)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			ids = append(ids, membership.OfficeID)
+		}
+	}
+
+	if user.OfficeID != nil {
+		ok, err := hasPermission(db, user.Rol, models.PermUserManage)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			ids = append(ids, *user.OfficeID)
+		}
+	}
+	return ids, nil
+}
+
+// memberOfAny indica si el usuario pertenece, como principal o adicional, a
+// alguna de las oficinas indicadas
+func memberOfAny(db *gorm.DB, user *models.User, officeIDs []string) (bool, error) {
+	if len(officeIDs) == 0 {
+		return false, nil
+	}
+	for _, id := range officeIDs {
+		if user.OfficeID != nil && *user.OfficeID == id {
+			return true, nil
+		}
+	}
+
+	var count int64
+	if err := db.Model(&models.OfficeMembership{}).
+		Where("user_id = ? AND office_id IN ?", user.ID, officeIDs).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// containsOffice indica si officeID está entre las oficinas indicadas
+func containsOffice(officeIDs []string, officeID *string) bool {
+	if officeID == nil {
+		return false
+	}
+	for _, id := range officeIDs {
+		if id == *officeID {
+			return true
+		}
+	}
+	return false
+}
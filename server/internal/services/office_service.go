@@ -0,0 +1,335 @@
+// server/internal/services/office_service.go
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"server/internal/dto"
+	"server/internal/models"
+)
+
+// Acciones de auditoría sobre el catálogo de oficinas
+const (
+	AuditOfficeCreated = "OFFICE_CREATED"
+	AuditOfficeUpdated = "OFFICE_UPDATED"
+	AuditOfficeDeleted = "OFFICE_DELETED"
+
+	auditEntityOffice = "office"
+)
+
+var (
+	ErrOfficeNotFound      = errors.New("oficina no encontrada")
+	ErrOfficeCodeRequired  = errors.New("el código de la oficina es obligatorio")
+	ErrOfficeNameRequired  = errors.New("el nombre de la oficina es obligatorio")
+	ErrOfficeCodeTaken     = errors.New("ya existe una oficina con ese código")
+	ErrOfficeParentInvalid = errors.New("la oficina padre no existe o genera un ciclo")
+	ErrOfficeHeadInvalid   = errors.New("el responsable debe ser un usuario activo")
+	ErrOfficeInUse         = errors.New("la oficina tiene usuarios o invitaciones asignadas; desactívala en su lugar")
+)
+
+type OfficeService struct {
+	db *gorm.DB
+}
+
+func NewOfficeService(db *gorm.DB) *OfficeService {
+	return &OfficeService{db: db}
+}
+
+// List devuelve el catálogo ordenado por código. Los inactivos solo se
+// incluyen si se piden explícitamente.
+func (s *OfficeService) List(includeInactive bool) ([]dto.OfficeResponse, error) {
+	query := s.db.Preload("Parent").Order("code ASC")
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var offices []models.Office
+	if err := query.Find(&offices).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.OfficeResponse, 0, len(offices))
+	for i := range offices {
+		response = append(response, buildOfficeResponse(&offices[i]))
+	}
+	return response, nil
+}
+
+func (s *OfficeService) Get(officeID string) (*dto.OfficeResponse, error) {
+	office, err := s.load(s.db, officeID)
+	if err != nil {
+		return nil, err
+	}
+	response := buildOfficeResponse(office)
+	return &response, nil
+}
+
+func (s *OfficeService) Create(req dto.CreateOfficeRequest, meta AuditMeta) (*dto.OfficeResponse, error) {
+	code := normalizeOfficeCode(req.Code)
+	name := strings.TrimSpace(req.Name)
+	if code == "" {
+		return nil, ErrOfficeCodeRequired
+	}
+	if name == "" {
+		return nil, ErrOfficeNameRequired
+	}
+
+	office := models.Office{
+		Code:       code,
+		Name:       name,
+		ParentID:   optionalString(deref(req.ParentID)),
+		HeadUserID: optionalString(deref(req.HeadUserID)),
+		IsActive:   true,
+	}
+
+	err := withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		if err := s.ensureCodeFree(tx, code, ""); err != nil {
+			return err
+		}
+		if office.ParentID != nil {
+			if err := s.ensureParent(tx, "", *office.ParentID); err != nil {
+				return err
+			}
+		}
+		if office.HeadUserID != nil {
+			if err := s.ensureHead(tx, *office.HeadUserID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&office).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditOfficeCreated, auditEntityOffice, office.ID, map[string]interface{}{
+			"code":       office.Code,
+			"name":       office.Name,
+			"parentId":   office.ParentID,
+			"headUserId": office.HeadUserID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(office.ID)
+}
+
+// Update aplica solo los campos enviados y registra el antes y el después
+func (s *OfficeService) Update(officeID string, req dto.UpdateOfficeRequest, meta AuditMeta) (*dto.OfficeResponse, error) {
+	err := withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		office, err := s.load(tx, officeID)
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		changes := map[string]interface{}{}
+		track := func(column string, from, to interface{}) {
+			updates[column] = to
+			changes[column] = map[string]interface{}{"from": from, "to": to}
+		}
+
+		if req.Code != nil {
+			code := normalizeOfficeCode(*req.Code)
+			if code == "" {
+				return ErrOfficeCodeRequired
+			}
+			if code != office.Code {
+				if err := s.ensureCodeFree(tx, code, office.ID); err != nil {
+					return err
+				}
+				track("code", office.Code, code)
+			}
+		}
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" {
+				return ErrOfficeNameRequired
+			}
+			if name != office.Name {
+				track("name", office.Name, name)
+			}
+		}
+		if req.ParentID != nil && strings.TrimSpace(*req.ParentID) != deref(office.ParentID) {
+			parentID := optionalString(strings.TrimSpace(*req.ParentID))
+			if parentID != nil {
+				if err := s.ensureParent(tx, office.ID, *parentID); err != nil {
+					return err
+				}
+			}
+			track("parent_id", office.ParentID, parentID)
+		}
+		if req.HeadUserID != nil && strings.TrimSpace(*req.HeadUserID) != deref(office.HeadUserID) {
+			headUserID := optionalString(strings.TrimSpace(*req.HeadUserID))
+			if headUserID != nil {
+				if err := s.ensureHead(tx, *headUserID); err != nil {
+					return err
+				}
+			}
+			track("head_user_id", office.HeadUserID, headUserID)
+		}
+		if req.IsActive != nil && *req.IsActive != office.IsActive {
+			track("is_active", office.IsActive, *req.IsActive)
+		}
+
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := tx.Model(office).Updates(updates).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditOfficeUpdated, auditEntityOffice, office.ID, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(officeID)
+}
+
+// Delete elimina una oficina sin usuarios ni invitaciones. Las oficinas hijas
+// quedan sin padre por la restricción ON DELETE SET NULL.
+func (s *OfficeService) Delete(officeID string, meta AuditMeta) error {
+	return withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		office, err := s.load(tx, officeID)
+		if err != nil {
+			return err
+		}
+
+		var users, invitations, memberships int64
+		if err := tx.Model(&models.User{}).Where("office_id = ?", office.ID).Count(&users).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Invitation{}).Where("office_id = ?", office.ID).Count(&invitations).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.OfficeMembership{}).Where("office_id = ?", office.ID).Count(&memberships).Error; err != nil {
+			return err
+		}
+		if users > 0 || invitations > 0 || memberships > 0 {
+			return ErrOfficeInUse
+		}
+
+		if err := tx.Delete(office).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditOfficeDeleted, auditEntityOffice, office.ID, map[string]interface{}{
+			"code": office.Code,
+			"name": office.Name,
+		})
+	})
+}
+
+func (s *OfficeService) load(db *gorm.DB, officeID string) (*models.Office, error) {
+	var office models.Office
+	if err := db.Preload("Parent").Where("id = ?", officeID).First(&office).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOfficeNotFound
+		}
+		return nil, err
+	}
+	return &office, nil
+}
+
+func (s *OfficeService) ensureCodeFree(tx *gorm.DB, code, exceptID string) error {
+	query := tx.Model(&models.Office{}).Where("code = ?", code)
+	if exceptID != "" {
+		query = query.Where("id != ?", exceptID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrOfficeCodeTaken
+	}
+	return nil
+}
+
+// ensureParent comprueba que el padre exista y que no sea la propia oficina ni
+// una de sus descendientes, recorriendo la cadena de padres hacia arriba.
+func (s *OfficeService) ensureParent(tx *gorm.DB, officeID, parentID string) error {
+	seen := map[string]bool{}
+	current := parentID
+	for current != "" {
+		if current == officeID || seen[current] {
+			return ErrOfficeParentInvalid
+		}
+		seen[current] = true
+
+		var parent models.Office
+		if err := tx.Select("id", "parent_id").Where("id = ?", current).First(&parent).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOfficeParentInvalid
+			}
+			return err
+		}
+		current = deref(parent.ParentID)
+	}
+	return nil
+}
+
+func (s *OfficeService) ensureHead(tx *gorm.DB, userID string) error {
+	var count int64
+	if err := tx.Model(&models.User{}).Where("id = ? AND is_active = ?", userID, true).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrOfficeHeadInvalid
+	}
+	return nil
+}
+
+// findActiveOffice resuelve el código que envía el cliente a una oficina activa
+func findActiveOffice(db *gorm.DB, code string) (*models.Office, error) {
+	var office models.Office
+	err := db.Where("code = ? AND is_active = ?", normalizeOfficeCode(code), true).First(&office).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOfficeInvalid
+		}
+		return nil, err
+	}
+	return &office, nil
+}
+
+// officeCode es el código que se expone en las respuestas (nil sin oficina)
+func officeCode(office *models.Office) *string {
+	if office == nil {
+		return nil
+	}
+	code := office.Code
+	return &code
+}
+
+func normalizeOfficeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func buildOfficeResponse(office *models.Office) dto.OfficeResponse {
+	return dto.OfficeResponse{
+		ID:         office.ID,
+		Code:       office.Code,
+		Name:       office.Name,
+		ParentID:   office.ParentID,
+		ParentCode: officeCode(office.Parent),
+		HeadUserID: office.HeadUserID,
+		IsActive:   office.IsActive,
+		CreatedAt:  office.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:  office.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// loadUserOffice carga la oficina del usuario si aún no viene precargada
+func loadUserOffice(db *gorm.DB, user *models.User) error {
+	if user.OfficeID == nil || user.Office != nil {
+		return nil
+	}
+	var office models.Office
+	if err := db.Where("id = ?", *user.OfficeID).First(&office).Error; err != nil {
+		return err
+	}
+	user.Office = &office
+	return nil
+}
@@ -0,0 +1,314 @@
+// server/internal/services/oidc_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/pkgs/oidc"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+var (
+	ErrOIDCProviderNotFound      = errors.New("proveedor OIDC no configurado")
+	ErrOIDCStateInvalid          = errors.New("estado de autenticación inválido o expirado")
+	ErrOIDCAccountNotLinked      = errors.New("la identidad externa no está vinculada a ningún usuario")
+	ErrOIDCAccountLinkedToOther  = errors.New("la identidad externa ya está vinculada a otro usuario")
+	ErrOIDCProviderAlreadyLinked = errors.New("ya tienes una cuenta vinculada con este proveedor")
+	ErrOIDCLinkNotFound          = errors.New("no existe una cuenta vinculada con este proveedor")
+	ErrOIDCUnlinkLastMethod      = errors.New("no puedes desvincular tu único método de inicio de sesión")
+)
+
+type OIDCService struct {
+	db       *gorm.DB
+	registry *oidc.Registry
+	sessions *SessionService
+}
+
+func NewOIDCService(db *gorm.DB, registry *oidc.Registry, sessions *SessionService) *OIDCService {
+	return &OIDCService{db: db, registry: registry, sessions: sessions}
+}
+
+// forRequest devuelve una copia del servicio cuyas consultas llevan el
+// contexto de la petición
+func (s *OIDCService) forRequest(ctx context.Context) *OIDCService {
+	bound := *s
+	bound.db = s.db.WithContext(ctx)
+	return &bound
+}
+
+func (s *OIDCService) ListProviders() []dto.OIDCProviderResponse {
+	providers := make([]dto.OIDCProviderResponse, 0)
+	for _, p := range s.registry.List() {
+		providers = append(providers, dto.OIDCProviderResponse{
+			Name:        p.Name(),
+			DisplayName: p.Config().DisplayName,
+		})
+	}
+	return providers
+}
+
+// BeginAuth genera state, nonce y code_verifier y devuelve la URL de autorización.
+// Si linkUserID no es nil el flujo vincula la identidad al usuario autenticado.
+func (s *OIDCService) BeginAuth(ctx context.Context, providerName string, linkUserID *string) (*dto.OIDCAuthorizeResponse, error) {
+	s = s.forRequest(ctx)
+	provider, ok := s.registry.Get(providerName)
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	if linkUserID != nil {
+		var count int64
+		s.db.Model(&models.Account{}).
+			Where("user_id = ? AND provider = ?", *linkUserID, providerName).
+			Count(&count)
+		if count > 0 {
+			return nil, ErrOIDCProviderAlreadyLinked
+		}
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.GenerateVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.S256Challenge(verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	// Limpiar estados vencidos antes de registrar uno nuevo
+	s.db.Where("expires < ?", time.Now()).Delete(&models.OAuthState{})
+
+	oauthState := models.OAuthState{
+		State:        state,
+		Provider:     providerName,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		LinkUserID:   linkUserID,
+		Expires:      time.Now().Add(oidcStateTTL),
+	}
+	if err := s.db.Create(&oauthState).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.OIDCAuthorizeResponse{
+		AuthorizationURL: authURL,
+		State:            state,
+	}, nil
+}
+
+// CompleteAuth procesa el callback: canjea el código, valida el id_token
+// y según el estado inicia sesión o vincula la identidad.
+func (s *OIDCService) CompleteAuth(ctx context.Context, providerName string, req dto.OIDCCallbackRequest, meta LoginMeta) (*dto.OIDCCallbackResponse, error) {
+	s = s.forRequest(ctx)
+	provider, ok := s.registry.Get(providerName)
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	var oauthState models.OAuthState
+	if err := s.db.Where("state = ? AND provider = ? AND expires > ?", req.State, providerName, time.Now()).
+		First(&oauthState).Error; err != nil {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	// El estado es de un solo uso
+	if res := s.db.Delete(&oauthState); res.Error != nil || res.RowsAffected == 0 {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	tokens, err := provider.Exchange(ctx, req.Code, oauthState.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, tokens.IDToken, oauthState.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if oauthState.LinkUserID != nil {
+		user, err := s.linkAccount(*oauthState.LinkUserID, providerName, claims, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if err := loadUserOffice(s.db, user); err != nil {
+			return nil, err
+		}
+		return &dto.OIDCCallbackResponse{Linked: true, User: buildAuthResponse(user)}, nil
+	}
+
+	user, err := s.loginWithAccount(provider.Config(), claims, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.sessions.CompleteLogin(user, meta)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.OIDCCallbackResponse{Linked: false, User: session}, nil
+}
+
+func (s *OIDCService) loginWithAccount(cfg oidc.Config, claims *oidc.IDTokenClaims, tokens *oidc.TokenResponse) (*models.User, error) {
+	var account models.Account
+	err := s.db.Preload("User").
+		Where("provider = ? AND provider_account_id = ?", cfg.Name, claims.Subject).
+		First(&account).Error
+
+	var user models.User
+	switch {
+	case err == nil:
+		user = account.User
+		applyOIDCTokens(&account, tokens)
+		if err := asActor(s.db, user.ID).Omit("User").Save(&account).Error; err != nil {
+			return nil, err
+		}
+
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// Solo se vincula por correo si el proveedor es confiable y el correo está verificado
+		if !cfg.TrustEmail || !claims.EmailVerified || claims.Email == "" {
+			return nil, ErrOIDCAccountNotLinked
+		}
+		if err := s.db.Where("LOWER(email) = ?", strings.ToLower(claims.Email)).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrOIDCAccountNotLinked
+			}
+			return nil, err
+		}
+		if _, err := s.createAccount(user.ID, cfg.Name, claims, tokens); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+
+	return &user, nil
+}
+
+func (s *OIDCService) linkAccount(userID, providerName string, claims *oidc.IDTokenClaims, tokens *oidc.TokenResponse) (*models.User, error) {
+	var user models.User
+	if err := s.db.Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
+		return nil, errors.New("usuario no encontrado o inactivo")
+	}
+
+	var existing models.Account
+	err := s.db.Where("provider = ? AND provider_account_id = ?", providerName, claims.Subject).First(&existing).Error
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, ErrOIDCAccountLinkedToOther
+		}
+		applyOIDCTokens(&existing, tokens)
+		if err := asActor(s.db, userID).Omit("User").Save(&existing).Error; err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if _, err := s.createAccount(userID, providerName, claims, tokens); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *OIDCService) createAccount(userID, providerName string, claims *oidc.IDTokenClaims, tokens *oidc.TokenResponse) (*models.Account, error) {
+	account := models.Account{
+		UserID:            userID,
+		Type:              "oidc",
+		Provider:          providerName,
+		ProviderAccountID: claims.Subject,
+	}
+	applyOIDCTokens(&account, tokens)
+
+	// El callback llega sin sesión: la vinculación se atribuye al dueño
+	if err := asActor(s.db, userID).Omit("User").Create(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Unlink elimina la vinculación siempre que el usuario conserve otro método de acceso
+func (s *OIDCService) Unlink(ctx context.Context, userID, providerName string) error {
+	s = s.forRequest(ctx)
+	var user models.User
+	if err := s.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return errors.New("usuario no encontrado")
+	}
+
+	var account models.Account
+	if err := s.db.Where("user_id = ? AND provider = ?", userID, providerName).First(&account).Error; err != nil {
+		return ErrOIDCLinkNotFound
+	}
+
+	var others int64
+	s.db.Model(&models.Account{}).Where("user_id = ? AND id != ?", userID, account.ID).Count(&others)
+
+	hasPassword := user.Password != nil && *user.Password != ""
+	if !hasPassword && others == 0 {
+		return ErrOIDCUnlinkLastMethod
+	}
+
+	return s.db.Delete(&account).Error
+}
+
+func (s *OIDCService) ListLinkedAccounts(userID string) ([]dto.LinkedAccountResponse, error) {
+	var accounts []models.Account
+	if err := s.db.Where("user_id = ?", userID).Order("provider").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.LinkedAccountResponse, 0, len(accounts))
+	for _, a := range accounts {
+		response = append(response, dto.LinkedAccountResponse{
+			Provider:          a.Provider,
+			ProviderAccountID: a.ProviderAccountID,
+			Type:              a.Type,
+		})
+	}
+	return response, nil
+}
+
+func applyOIDCTokens(account *models.Account, tokens *oidc.TokenResponse) {
+	account.AccessToken = optionalString(tokens.AccessToken)
+	account.IDToken = optionalString(tokens.IDToken)
+	account.TokenType = optionalString(tokens.TokenType)
+	account.Scope = optionalString(tokens.Scope)
+	// Algunos proveedores no reenvían el refresh token en cada login
+	if tokens.RefreshToken != "" {
+		account.RefreshToken = &tokens.RefreshToken
+	}
+	if tokens.ExpiresIn > 0 {
+		expiresAt := int(time.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second).Unix())
+		account.ExpiresAt = &expiresAt
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
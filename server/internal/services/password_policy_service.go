@@ -0,0 +1,124 @@
+// server/internal/services/password_policy_service.go
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"server/internal/config"
+	"server/internal/models"
+	"server/pkgs/passpolicy"
+	"server/pkgs/security"
+)
+
+// PasswordPolicyService aplica la política de contraseñas (composición,
+// historial y lista local de filtraciones) y guarda el historial.
+type PasswordPolicyService struct {
+	db            *gorm.DB
+	argon2Service *security.Argon2Service
+	// composition son las reglas baratas; engine las que leen disco o
+	// comparan hashes Argon2id y solo corren si composition pasó
+	composition *passpolicy.Engine
+	engine      *passpolicy.Engine
+	historySize int
+}
+
+func NewPasswordPolicyService(db *gorm.DB, argon2Service *security.Argon2Service, cfg config.PasswordPolicyConfig) *PasswordPolicyService {
+	s := &PasswordPolicyService{
+		db:            db,
+		argon2Service: argon2Service,
+		historySize:   cfg.HistorySize,
+	}
+
+	s.composition = passpolicy.NewEngine(passpolicy.CompositionRules{
+		MinLength:      cfg.MinLength,
+		MaxLength:      cfg.MaxLength,
+		RequireUpper:   cfg.RequireUpper,
+		RequireLower:   cfg.RequireLower,
+		RequireDigit:   cfg.RequireDigit,
+		RequireSymbol:  cfg.RequireSymbol,
+		RejectEmailUse: true,
+	})
+	s.engine = passpolicy.NewEngine()
+	if cfg.BreachedDir != "" {
+		s.engine.Use(passpolicy.NewBreachedList(cfg.BreachedDir))
+	}
+	if cfg.HistorySize > 0 {
+		s.engine.Use(passpolicy.RuleFunc(s.checkHistory))
+	}
+
+	return s
+}
+
+// Validate evalúa la contraseña para el usuario indicado. Devuelve
+// *passpolicy.PolicyError con las infracciones encontradas. Si falla la
+// composición se responde de inmediato, sin consultar filtraciones ni pagar
+// las comparaciones Argon2id del historial.
+func (s *PasswordPolicyService) Validate(password string, user *models.User) error {
+	subject := passpolicy.Subject{}
+	if user != nil {
+		subject.UserID = user.ID
+		subject.Email = user.Email
+	}
+	if err := s.composition.Validate(password, subject); err != nil {
+		return err
+	}
+	return s.engine.Validate(password, subject)
+}
+
+// Remember guarda el hash recién asignado y recorta el historial a N entradas
+func (s *PasswordPolicyService) Remember(tx *gorm.DB, userID, hash string) error {
+	if s.historySize <= 0 {
+		return nil
+	}
+
+	if err := tx.Omit("User").Create(&models.PasswordHistory{UserID: userID, Hash: hash}).Error; err != nil {
+		return err
+	}
+
+	var keep []string
+	if err := tx.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(s.historySize).
+		Pluck("id", &keep).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("user_id = ? AND id NOT IN ?", userID, keep).Delete(&models.PasswordHistory{}).Error
+}
+
+// checkHistory compara contra la contraseña actual y las últimas N del historial
+func (s *PasswordPolicyService) checkHistory(password string, subject passpolicy.Subject) ([]passpolicy.Violation, error) {
+	if subject.UserID == "" {
+		return nil, nil
+	}
+
+	var hashes []string
+	if err := s.db.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", subject.UserID).
+		Order("created_at DESC").
+		Limit(s.historySize).
+		Pluck("hash", &hashes).Error; err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.db.Select("password").Where("id = ?", subject.UserID).First(&user).Error; err == nil &&
+		user.Password != nil && *user.Password != "" {
+		hashes = append(hashes, *user.Password)
+	}
+
+	for _, hash := range hashes {
+		err := s.argon2Service.ComparePassword(hash, password)
+		if err == nil {
+			return []passpolicy.Violation{
+				passpolicy.NewViolation(passpolicy.CodeReused, map[string]int{"count": s.historySize}),
+			}, nil
+		}
+		if errors.Is(err, security.ErrHasherBusy) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server/internal/config"
+	"server/internal/models"
+	"server/pkgs/passpolicy"
+)
+
+func TestPasswordPolicyStopsAtComposition(t *testing.T) {
+	// Sin base ni hasher: si se llegara al historial, checkHistory fallaría
+	s := NewPasswordPolicyService(nil, nil, config.PasswordPolicyConfig{
+		MinLength:    12,
+		RequireDigit: true,
+		HistorySize:  5,
+		BreachedDir:  t.TempDir(),
+	})
+
+	err := s.Validate("corta", &models.User{ID: "user-1", Email: "ana@example.com"})
+	var policyErr *passpolicy.PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("err = %v, se esperaba *passpolicy.PolicyError", err)
+	}
+	for _, v := range policyErr.Violations {
+		if v.Code == passpolicy.CodeReused || v.Code == passpolicy.CodeBreached {
+			t.Fatalf("se evaluó %s con la composición ya rechazada", v.Code)
+		}
+	}
+	if len(policyErr.Violations) != 2 {
+		t.Fatalf("infracciones = %+v, se esperaban longitud y dígito", policyErr.Violations)
+	}
+}
@@ -1,33 +1,94 @@
-//internal/services/password_reset_service.go
+// internal/services/password_reset_service.go
 package services
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"math/big"
-	"os"
+	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"server/internal/dto"
 	"server/internal/models"
+	"server/pkgs/logger"
+	"server/pkgs/mailer"
 	"server/pkgs/security"
 )
 
+const (
+	passwordResetCodeTTL        = 15 * time.Minute
+	passwordResetTokenTTL       = 30 * time.Minute
+	passwordResetResendCooldown = 60 // segundos
+)
+
+var (
+	ErrResetCodeInvalid  = errors.New("código inválido, ya utilizado o expirado")
+	ErrResetTokenInvalid = errors.New("token inválido, ya utilizado o expirado")
+)
+
 type PasswordResetService struct {
-	db            *gorm.DB
-	argon2Service *security.Argon2Service
+	db              *gorm.DB
+	argon2Service   *security.Argon2Service
+	throttle        *LoginThrottleService
+	maxCodeAttempts int
+	key             security.SecretKey
+	mail            *mailer.Mailer
+	policy          *PasswordPolicyService
+	issue           chan resetRequest
+}
+
+// resetRequest es una solicitud de código pendiente de emitir; ctx conserva
+// la IP y el request de origen para la auditoría
+type resetRequest struct {
+	ctx   context.Context
+	email string
+	meta  LoginMeta
 }
 
-func NewPasswordResetService(db *gorm.DB, argon2Service *security.Argon2Service) *PasswordResetService {
+func NewPasswordResetService(db *gorm.DB, argon2Service *security.Argon2Service, throttle *LoginThrottleService, maxCodeAttempts int, key security.SecretKey, mail *mailer.Mailer, policy *PasswordPolicyService) *PasswordResetService {
 	return &PasswordResetService{
-		db:            db,
-		argon2Service: argon2Service,
+		db:              db,
+		argon2Service:   argon2Service,
+		throttle:        throttle,
+		maxCodeAttempts: maxCodeAttempts,
+		key:             key,
+		mail:            mail,
+		policy:          policy,
 	}
 }
 
+// WithIssueQueue emite los códigos con un número fijo de workers y una cola
+// acotada, para que una ráfaga de solicitudes no abra goroutines sin límite
+func (s *PasswordResetService) WithIssueQueue(workers, size int) *PasswordResetService {
+	if workers < 1 {
+		workers = 1
+	}
+	s.issue = make(chan resetRequest, size)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for req := range s.issue {
+				if err := s.forRequest(req.ctx).issueResetCode(req.email, req.meta); err != nil {
+					logger.Log.Errorf("❌ No se pudo emitir el código de recuperación: %v", err)
+				}
+			}
+		}()
+	}
+	return s
+}
+
+// forRequest devuelve una copia del servicio cuyas consultas llevan el
+// contexto de la petición. Comparte la cola de emisión con el original.
+func (s *PasswordResetService) forRequest(ctx context.Context) *PasswordResetService {
+	bound := *s
+	bound.db = s.db.WithContext(ctx)
+	return &bound
+}
+
 var ErrUserNotFound = errors.New("no existe una cuenta para el correo ingresado")
 
 type UserService struct {
@@ -40,14 +101,14 @@ func NewUserService(db *gorm.DB) *UserService {
 
 func (s *UserService) CheckUserExists(email string) (*models.User, error) {
 	var user models.User
-	
-	if err := s.db.Where("email = ? AND is_active = ?", email, true).First(&user).Error; err != nil {
+
+	if err := s.db.Where("LOWER(email) = ? AND is_active = ?", normalizeEmail(email), true).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
-	
+
 	return &models.User{
 		ID:    user.ID,
 		Email: user.Email,
@@ -55,131 +116,163 @@ func (s *UserService) CheckUserExists(email string) (*models.User, error) {
 	}, nil
 }
 
-func (s *PasswordResetService) RequestPasswordReset(req dto.PasswordResetRequestDTO) (map[string]interface{}, error) {
+// RequestPasswordReset responde siempre lo mismo, exista o no la cuenta. El
+// código se genera y envía en segundo plano para que el tiempo de respuesta
+// tampoco revele si el correo está registrado. Si la cola está llena la
+// solicitud se descarta con la misma respuesta.
+func (s *PasswordResetService) RequestPasswordReset(ctx context.Context, req dto.PasswordResetRequestDTO) map[string]interface{} {
+	request := resetRequest{
+		ctx:   context.WithoutCancel(ctx),
+		email: normalizeEmail(req.Email),
+		meta:  LoginMeta{IP: req.IP, UserAgent: req.UserAgent},
+	}
+	select {
+	case s.issue <- request:
+	default:
+		logger.Log.Warnf("⚠️ Cola de recuperación llena, se descarta la solicitud desde %s", req.IP)
+	}
+
+	return map[string]interface{}{
+		"expires": time.Now().Add(passwordResetCodeTTL),
+	}
+}
+
+// issueResetCode crea y envía el código. Si no hay cuenta activa o el último
+// envío es reciente no hace nada; el solicitante no se entera en ningún caso.
+func (s *PasswordResetService) issueResetCode(email string, meta LoginMeta) error {
 	var user models.User
-	if err := s.db.Where("email = ? AND is_active = ?", req.Email, true).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, errors.New("no existe una cuenta para el correo ingresado")
+	if err := s.db.Where("LOWER(email) = ? AND is_active = ?", email, true).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
 		}
-		return nil, err
+		return err
 	}
 
 	var lastToken models.PasswordResetToken
-	if err := s.db.Where("email = ?", req.Email).Order("created_at DESC").First(&lastToken).Error; err == nil {
-		if lastToken.LastSentAt != nil {
-			timeSince := time.Now().Unix() - *lastToken.LastSentAt
-			if timeSince < 60 {
-				return nil, errors.New("debes esperar antes de solicitar un nuevo código")
-			}
+	if err := s.db.Where("user_id = ?", user.ID).Order("created_at DESC").First(&lastToken).Error; err == nil {
+		if lastToken.LastSentAt != nil && time.Now().Unix()-*lastToken.LastSentAt < passwordResetResendCooldown {
+			return nil
 		}
 	}
 
+	recordClientSecurityEvent(s.db, models.SecurityEventResetRequested, &user.ID, user.Email, meta,
+		map[string]interface{}{"source": "self"})
+	return s.SendResetCode(&user)
+}
+
+// SendResetCode reemplaza cualquier código pendiente del usuario y le envía
+// uno nuevo. También lo usa un administrador para forzar la recuperación.
+func (s *PasswordResetService) SendResetCode(user *models.User) error {
 	s.db.Where("user_id = ?", user.ID).Delete(&models.PasswordResetToken{})
 
 	code := generateCode(6)
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId": user.ID,
-		"email":  user.Email,
-		"type":   "password_reset_initial",
-		"exp":    time.Now().Add(15 * time.Minute).Unix(),
-	})
-
-	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
-	tokenString, err := token.SignedString(jwtSecret)
-	if err != nil {
-		return nil, err
-	}
-
-	expires := time.Now().Add(15 * time.Minute)
 	lastSentAt := time.Now().Unix()
 	resetToken := models.PasswordResetToken{
 		UserID:     user.ID,
 		Email:      user.Email,
-		Code:       code,
-		Token:      tokenString,
-		Expires:    expires,
+		Code:       s.hashResetSecret(user.ID, code),
+		Expires:    time.Now().Add(passwordResetCodeTTL),
 		LastSentAt: &lastSentAt,
 	}
-
-	if err := s.db.Create(&resetToken).Error; err != nil {
-		return nil, err
+	if err := s.db.Omit("User").Create(&resetToken).Error; err != nil {
+		return err
 	}
 
-	return map[string]interface{}{
-		"code":    code,
-		"token":   tokenString,
-		"expires": expires,
-		"userId":  user.ID,
-		"name":    user.Name,
-	}, nil
+	// El código solo viaja por correo; nunca se devuelve en la respuesta
+	if err := s.mail.SendTemplate(user.Email, "password_reset", map[string]interface{}{
+		"Name":    user.Name,
+		"Code":    code,
+		"Minutes": int(passwordResetCodeTTL.Minutes()),
+	}); err != nil {
+		s.db.Delete(&resetToken)
+		return fmt.Errorf("no se pudo enviar el correo de recuperación: %w", err)
+	}
+	return nil
 }
 
-func (s *PasswordResetService) ValidateResetCode(req dto.ValidateCodeDTO) (map[string]interface{}, error) {
+// ValidateResetCode canjea el código por un token de un solo uso para fijar
+// la nueva contraseña. Correo desconocido y código incorrecto dan el mismo error.
+func (s *PasswordResetService) ValidateResetCode(ctx context.Context, req dto.ValidateCodeDTO, ip string) (map[string]interface{}, error) {
+	s = s.forRequest(ctx)
+	if err := s.throttle.Check("", ip); err != nil {
+		return nil, err
+	}
+
 	var resetToken models.PasswordResetToken
-	if err := s.db.Where("email = ? AND code = ? AND expires > ? AND is_used = ?",
-		req.Email, req.Code, time.Now(), false).
+	if err := s.db.Where("LOWER(email) = ? AND expires > ? AND is_used = ? AND is_validated = ?",
+		normalizeEmail(req.Email), time.Now(), false, false).
 		Order("created_at DESC").First(&resetToken).Error; err != nil {
-		return nil, errors.New("código inválido, ya utilizado o expirado")
+		// Se compara igual contra un valor ficticio para no acortar la respuesta
+		compareResetSecret(s.hashResetSecret("", req.Code), "")
+		s.throttle.RegisterFailure("", ip, nil)
+		return nil, ErrResetCodeInvalid
 	}
 
-	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
-	_, err := jwt.Parse(resetToken.Token, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+	// El intento se reserva antes de comparar: las conjeturas en paralelo
+	// no pueden superar el límite
+	attempts, ok, err := s.claimCodeAttempt(&resetToken)
 	if err != nil {
-		return nil, errors.New("token inválido o expirado")
+		return nil, err
+	}
+	if !ok {
+		s.throttle.RegisterFailure("", ip, nil)
+		return nil, ErrResetCodeInvalid
 	}
 
-	newToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId": resetToken.UserID,
-		"email":  resetToken.Email,
-		"type":   "password_reset_final",
-		"exp":    time.Now().Add(30 * time.Minute).Unix(),
-	})
+	if !compareResetSecret(s.hashResetSecret(resetToken.UserID, req.Code), resetToken.Code) {
+		s.registerWrongCode(&resetToken, attempts, ip)
+		return nil, ErrResetCodeInvalid
+	}
 
-	newTokenString, err := newToken.SignedString(jwtSecret)
+	token, err := randomToken(32)
 	if err != nil {
 		return nil, err
 	}
 
-	s.db.Model(&resetToken).Update("is_validated", true)
-	s.db.Model(&models.PasswordResetToken{}).
-		Where("user_id = ? AND id != ?", resetToken.UserID, resetToken.ID).
-		Update("expires", time.Unix(0, 0))
+	// El código queda consumido y la fila pasa a guardar el hash del token final
+	res := s.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND is_validated = ?", resetToken.ID, false).
+		Updates(map[string]interface{}{
+			"is_validated": true,
+			"code":         "",
+			"token":        s.hashResetSecret(resetToken.UserID, token),
+			"expires":      time.Now().Add(passwordResetTokenTTL),
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrResetCodeInvalid
+	}
 
 	return map[string]interface{}{
 		"success": true,
 		"email":   resetToken.Email,
-		"token":   newTokenString,
+		"token":   token,
 	}, nil
 }
 
-func (s *PasswordResetService) ResetPassword(req dto.ResetPasswordDTO) error {
-	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
-	token, err := jwt.Parse(req.Token, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
-
-	if err != nil || !token.Valid {
-		return errors.New("token inválido o expirado")
+// ResetPassword fija la nueva contraseña con el token canjeado. El cambio se
+// audita a nombre del dueño de la cuenta, que se identificó con el código.
+func (s *PasswordResetService) ResetPassword(ctx context.Context, req dto.ResetPasswordDTO) error {
+	s = s.forRequest(ctx)
+	var resetToken models.PasswordResetToken
+	if err := s.db.Where("LOWER(email) = ? AND is_validated = ? AND is_used = ? AND expires > ?",
+		normalizeEmail(req.Email), true, false, time.Now()).
+		Order("created_at DESC").First(&resetToken).Error; err != nil {
+		compareResetSecret(s.hashResetSecret("", req.Token), "")
+		return ErrResetTokenInvalid
 	}
-
-	claims := token.Claims.(jwt.MapClaims)
-	if claims["type"] != "password_reset_final" || claims["email"] != req.Email {
-		return errors.New("token no válido")
+	if !compareResetSecret(s.hashResetSecret(resetToken.UserID, req.Token), resetToken.Token) {
+		return ErrResetTokenInvalid
 	}
 
-	userID, ok := claims["userId"].(string)
-	if !ok {
-		return errors.New("token no contiene un ID de usuario válido")
+	var user models.User
+	if err := s.db.Where("id = ? AND is_active = ?", resetToken.UserID, true).First(&user).Error; err != nil {
+		return ErrResetTokenInvalid
 	}
-
-	var resetToken models.PasswordResetToken
-	if err := s.db.Where("user_id = ? AND email = ? AND is_validated = ? AND is_used = ? AND expires > ?",
-		userID, req.Email, true, false, time.Now()).
-		First(&resetToken).Error; err != nil {
-		return errors.New("token no validado, ya utilizado o expirado")
+	if err := s.policy.Validate(req.NewPassword, &user); err != nil {
+		return err
 	}
 
 	hashedStr, err := s.argon2Service.HashPassword(req.NewPassword)
@@ -187,20 +280,95 @@ func (s *PasswordResetService) ResetPassword(req dto.ResetPasswordDTO) error {
 		return fmt.Errorf("error al hashear la contraseña: %w", err)
 	}
 
-	if err := s.db.Model(&models.User{}).Where("email = ?", req.Email).
-		Update("password", hashedStr).Error; err != nil {
+	now := time.Now()
+	err = asActor(s.db, user.ID).Transaction(func(tx *gorm.DB) error {
+		// La marca condicional impide usar el mismo token dos veces en paralelo
+		res := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND is_used = ?", resetToken.ID, false).
+			Updates(map[string]interface{}{"is_used": true, "used_at": now, "token": ""})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrResetTokenInvalid
+		}
+
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password":             hashedStr,
+			"must_change_password": false,
+			"password_changed_at":  now,
+		}).Error; err != nil {
+			return err
+		}
+		return s.policy.Remember(tx, user.ID, hashedStr)
+	})
+	if err != nil {
 		return err
 	}
 
-	now := time.Now()
-	s.db.Model(&resetToken).Updates(map[string]interface{}{
-		"is_used": true,
-		"used_at": now,
-	})
+	recordClientSecurityEvent(s.db, models.SecurityEventPasswordChanged, &user.ID, user.Email,
+		LoginMeta{IP: req.IP, UserAgent: req.UserAgent},
+		map[string]interface{}{"method": "reset"})
+
+	if err := s.mail.SendTemplate(user.Email, "password_changed", nil); err != nil {
+		logger.Log.Warnf("⚠️ No se pudo notificar el cambio de contraseña a %s: %v", user.Email, err)
+	}
 
 	return nil
 }
 
+// claimCodeAttempt suma un intento de forma atómica y devuelve el total. ok
+// es false si el código ya agotó sus intentos.
+func (s *PasswordResetService) claimCodeAttempt(resetToken *models.PasswordResetToken) (int, bool, error) {
+	var claimed models.PasswordResetToken
+	query := s.db.Model(&claimed).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}}}).
+		Where("id = ?", resetToken.ID)
+	if s.maxCodeAttempts > 0 {
+		query = query.Where("attempts < ?", s.maxCodeAttempts)
+	}
+
+	res := query.UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		return 0, false, res.Error
+	}
+	return claimed.Attempts, res.RowsAffected == 1, nil
+}
+
+// registerWrongCode registra el fallo y anula el código si el intento
+// reservado fue el último permitido
+func (s *PasswordResetService) registerWrongCode(resetToken *models.PasswordResetToken, attempts int, ip string) {
+	s.throttle.RegisterFailure("", ip, nil)
+
+	if s.maxCodeAttempts <= 0 || attempts < s.maxCodeAttempts {
+		return
+	}
+
+	s.db.Model(&models.PasswordResetToken{}).
+		Where("id = ?", resetToken.ID).
+		Update("expires", time.Unix(0, 0))
+
+	var ipPtr *string
+	if ip != "" {
+		ipPtr = &ip
+	}
+	recordSecurityEvent(s.db, models.SecurityEventResetCodeLocked, &resetToken.UserID, &resetToken.Email, ipPtr, map[string]interface{}{
+		"attempts": attempts,
+	})
+}
+
+// hashResetSecret guarda códigos y tokens como HMAC-SHA256 con la clave del
+// servidor, ligado al usuario. Sin la clave, un volcado de la tabla no permite
+// probar el millón de códigos posibles.
+func (s *PasswordResetService) hashResetSecret(userID, secret string) string {
+	return s.key.Sum(userID + ":" + strings.TrimSpace(secret))
+}
+
+// compareResetSecret compara dos hashes en tiempo constante
+func compareResetSecret(candidate, stored string) bool {
+	return subtle.ConstantTimeCompare([]byte(candidate), []byte(stored)) == 1 && stored != ""
+}
+
 func generateCode(length int) string {
 	const digits = "0123456789"
 	code := make([]byte, length)
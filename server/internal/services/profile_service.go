@@ -0,0 +1,225 @@
+// server/internal/services/profile_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/pkgs/imaging"
+	"server/pkgs/logger"
+	"server/pkgs/storage"
+)
+
+const (
+	// avatarMaxSide limita las dimensiones de la imagen original
+	avatarMaxSide = 4096
+	avatarFile    = "%d.png"
+)
+
+// avatarSizes son las variantes cuadradas que se generan de cada avatar
+var avatarSizes = []int{64, 128, 256}
+
+var (
+	ErrProfileNotFound   = errors.New("usuario no encontrado o inactivo")
+	ErrAvatarEmpty       = errors.New("no se recibió ninguna imagen")
+	ErrStorageNotEnabled = storage.ErrStorageDisabled
+)
+
+type ProfileService struct {
+	db      *gorm.DB
+	storage storage.Storage
+}
+
+func NewProfileService(db *gorm.DB, files storage.Storage) *ProfileService {
+	return &ProfileService{db: db, storage: files}
+}
+
+// forRequest devuelve una copia que opera con el contexto de la petición:
+// los cambios quedan auditados a nombre de su autor
+func (s *ProfileService) forRequest(ctx context.Context) *ProfileService {
+	bound := *s
+	bound.db = s.db.WithContext(ctx)
+	return &bound
+}
+
+// Get devuelve el perfil del usuario autenticado con su último acceso
+func (s *ProfileService) Get(userID string) (*dto.ProfileResponse, error) {
+	user, err := s.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildProfile(user), nil
+}
+
+// Update cambia nombre y teléfono; los demás datos los gestiona un administrador
+func (s *ProfileService) Update(ctx context.Context, userID string, req dto.UpdateProfileRequest) (*dto.ProfileResponse, error) {
+	s = s.forRequest(ctx)
+	user, err := s.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		updates["name"] = optionalString(strings.TrimSpace(*req.Name))
+	}
+	if req.Phone != nil {
+		updates["phone"] = optionalString(strings.TrimSpace(*req.Phone))
+	}
+	if len(updates) > 0 {
+		if err := s.db.Model(user).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+		if err := s.db.Where("id = ?", user.ID).First(user).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return s.buildProfile(user), nil
+}
+
+// UploadAvatar valida la imagen, genera las variantes de tamaño fijo y
+// reemplaza el avatar anterior. User.Image apunta a la variante más grande.
+func (s *ProfileService) UploadAvatar(ctx context.Context, userID string, data []byte) (*dto.ProfileResponse, error) {
+	s = s.forRequest(ctx)
+	if s.storage == nil {
+		return nil, ErrStorageNotEnabled
+	}
+	if len(data) == 0 {
+		return nil, ErrAvatarEmpty
+	}
+
+	user, err := s.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := imaging.Decode(data, avatarMaxSide)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cada subida usa un prefijo nuevo para que los clientes no vean la versión en caché
+	version, err := randomToken(9)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("avatars/%s/%s", user.ID, version)
+
+	for _, size := range avatarSizes {
+		encoded, err := imaging.EncodePNG(imaging.Square(img, size))
+		if err != nil {
+			return nil, err
+		}
+		if err := s.storage.Put(ctx, avatarVariant(key, size), encoded, "image/png"); err != nil {
+			s.deleteAvatar(ctx, key)
+			return nil, err
+		}
+	}
+
+	previous := user.AvatarKey
+	image := s.storage.URL(avatarVariant(key, avatarSizes[len(avatarSizes)-1]))
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"avatar_key": key,
+		"image":      image,
+	}).Error; err != nil {
+		s.deleteAvatar(ctx, key)
+		return nil, err
+	}
+	user.AvatarKey = &key
+	user.Image = &image
+
+	if previous != nil {
+		s.deleteAvatar(ctx, *previous)
+	}
+
+	return s.buildProfile(user), nil
+}
+
+// DeleteAvatar elimina el avatar subido y deja el perfil sin imagen
+func (s *ProfileService) DeleteAvatar(ctx context.Context, userID string) (*dto.ProfileResponse, error) {
+	s = s.forRequest(ctx)
+	user, err := s.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.AvatarKey == nil {
+		return s.buildProfile(user), nil
+	}
+
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"avatar_key": nil,
+		"image":      nil,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if s.storage != nil {
+		s.deleteAvatar(ctx, *user.AvatarKey)
+	}
+	user.AvatarKey = nil
+	user.Image = nil
+
+	return s.buildProfile(user), nil
+}
+
+func (s *ProfileService) deleteAvatar(ctx context.Context, key string) {
+	for _, size := range avatarSizes {
+		if err := s.storage.Delete(ctx, avatarVariant(key, size)); err != nil {
+			logger.Log.Warnf("⚠️ No se pudo eliminar el avatar %s: %v", key, err)
+		}
+	}
+}
+
+func (s *ProfileService) buildProfile(user *models.User) *dto.ProfileResponse {
+	profile := &dto.ProfileResponse{
+		ID:               user.ID,
+		Name:             user.Name,
+		Email:            user.Email,
+		EmailVerified:    user.EmailVerified != nil,
+		Phone:            user.Phone,
+		Role:             string(user.Rol),
+		Office:           officeCode(user.Office),
+		Image:            user.Image,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		CreatedAt:        user.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+
+	if user.AvatarKey != nil && s.storage != nil {
+		profile.Avatar = make(map[string]string, len(avatarSizes))
+		for _, size := range avatarSizes {
+			profile.Avatar[strconv.Itoa(size)] = s.storage.URL(avatarVariant(*user.AvatarKey, size))
+		}
+	}
+
+	if user.LastLogin != nil {
+		at := user.LastLogin.Format("2006-01-02 15:04:05")
+		profile.LastLogin = &dto.LastLoginInfo{
+			At:       &at,
+			IP:       user.LastIP,
+			Device:   user.LastDevice,
+			OS:       user.LastOS,
+			Location: user.LastLocation,
+			Country:  user.LastCountry,
+		}
+	}
+
+	return profile
+}
+
+func (s *ProfileService) activeUser(userID string) (*models.User, error) {
+	var user models.User
+	if err := s.db.Preload("Office").Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
+		return nil, ErrProfileNotFound
+	}
+	return &user, nil
+}
+
+func avatarVariant(key string, size int) string {
+	return key + "/" + fmt.Sprintf(avatarFile, size)
+}
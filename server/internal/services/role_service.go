@@ -0,0 +1,363 @@
+// server/internal/services/role_service.go
+package services
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"server/internal/dto"
+	"server/internal/models"
+)
+
+// Acciones de auditoría sobre roles y permisos
+const (
+	AuditRoleCreated = "ROLE_CREATED"
+	AuditRoleUpdated = "ROLE_UPDATED"
+	AuditRoleDeleted = "ROLE_DELETED"
+
+	auditEntityRole = "role"
+)
+
+var (
+	ErrRoleNotFound       = errors.New("rol no encontrado")
+	ErrRoleNameInvalid    = errors.New("el nombre del rol debe tener entre 2 y 20 caracteres en mayúsculas, números o guion bajo")
+	ErrRoleNameTaken      = errors.New("ya existe un rol con ese nombre")
+	ErrRoleLevelInvalid   = errors.New("el nivel del rol debe ser mayor que 0 y menor que el de tu rol")
+	ErrRoleSystem         = errors.New("los roles del sistema no se pueden eliminar ni cambiar de nivel")
+	ErrRoleInUse          = errors.New("el rol está asignado a usuarios, invitaciones u oficinas")
+	ErrPermissionUnknown  = errors.New("permiso desconocido")
+	ErrPermissionNotHeld  = errors.New("no puedes otorgar permisos que tu rol no tiene")
+	ErrRoleManageLockout  = errors.New("el rol ADMIN debe conservar el permiso role.manage")
+	ErrRoleAboveRequester = errors.New("solo puedes asignar roles de menor jerarquía que el tuyo")
+	ErrRoleOutOfReach     = errors.New("solo puedes modificar roles de menor jerarquía que el tuyo")
+)
+
+var roleNameRx = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,19}$`)
+
+type RoleService struct {
+	db *gorm.DB
+}
+
+func NewRoleService(db *gorm.DB) *RoleService {
+	return &RoleService{db: db}
+}
+
+// ListRoles devuelve los roles de mayor a menor nivel
+func (s *RoleService) ListRoles() ([]dto.RoleResponse, error) {
+	var roles []models.Role
+	if err := s.db.Preload("Permissions").Order("level DESC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.RoleResponse, 0, len(roles))
+	for i := range roles {
+		response = append(response, buildRoleResponse(&roles[i]))
+	}
+	return response, nil
+}
+
+// ListPermissions devuelve el catálogo de permisos
+func (s *RoleService) ListPermissions() ([]dto.PermissionResponse, error) {
+	var permissions []models.Permission
+	if err := s.db.Order("code ASC").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.PermissionResponse, 0, len(permissions))
+	for _, p := range permissions {
+		response = append(response, dto.PermissionResponse{Code: p.Code, Description: p.Description})
+	}
+	return response, nil
+}
+
+func (s *RoleService) CreateRole(req dto.CreateRoleRequest, meta AuditMeta) (*dto.RoleResponse, error) {
+	requester, err := s.requester(meta.ActorID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.ToUpper(strings.TrimSpace(req.Name))
+	if !roleNameRx.MatchString(name) {
+		return nil, ErrRoleNameInvalid
+	}
+	if err := s.checkLevel(requester, req.Level); err != nil {
+		return nil, err
+	}
+
+	role := models.Role{
+		Name:        models.Rol(name),
+		Description: strings.TrimSpace(req.Description),
+		Level:       req.Level,
+	}
+
+	err = withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Role{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrRoleNameTaken
+		}
+
+		permissions, err := s.resolvePermissions(tx, requester, req.Permissions)
+		if err != nil {
+			return err
+		}
+		if err := tx.Omit("Permissions").Create(&role).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditRoleCreated, auditEntityRole, role.ID, map[string]interface{}{
+			"name":        role.Name,
+			"level":       role.Level,
+			"permissions": permissionCodes(permissions),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateRoleCache()
+	return s.get(role.ID)
+}
+
+// UpdateRole cambia descripción, nivel o permisos. Los cambios aplican de
+// inmediato a todos los usuarios con el rol.
+func (s *RoleService) UpdateRole(roleID string, req dto.UpdateRoleRequest, meta AuditMeta) (*dto.RoleResponse, error) {
+	requester, err := s.requester(meta.ActorID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		role, err := s.load(tx, roleID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkReach(requester, role); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		changes := map[string]interface{}{}
+
+		if req.Description != nil {
+			description := strings.TrimSpace(*req.Description)
+			if description != role.Description {
+				updates["description"] = description
+				changes["description"] = map[string]interface{}{"from": role.Description, "to": description}
+			}
+		}
+		if req.Level != nil && *req.Level != role.Level {
+			if role.IsSystem {
+				return ErrRoleSystem
+			}
+			if err := s.checkLevel(requester, *req.Level); err != nil {
+				return err
+			}
+			updates["level"] = *req.Level
+			changes["level"] = map[string]interface{}{"from": role.Level, "to": *req.Level}
+		}
+		if req.Permissions != nil {
+			permissions, err := s.resolvePermissions(tx, requester, *req.Permissions)
+			if err != nil {
+				return err
+			}
+			codes := permissionCodes(permissions)
+			if role.Name == models.RolAdmin && !containsString(codes, models.PermRoleManage) {
+				return ErrRoleManageLockout
+			}
+			if before := permissionCodes(role.Permissions); strings.Join(before, ",") != strings.Join(codes, ",") {
+				if err := tx.Model(role).Association("Permissions").Replace(permissions); err != nil {
+					return err
+				}
+				changes["permissions"] = map[string]interface{}{"from": before, "to": codes}
+			}
+		}
+
+		if len(changes) == 0 {
+			return nil
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(role).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, meta, AuditRoleUpdated, auditEntityRole, role.ID, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateRoleCache()
+	return s.get(roleID)
+}
+
+// DeleteRole elimina un rol personalizado que nadie usa
+func (s *RoleService) DeleteRole(roleID string, meta AuditMeta) error {
+	requester, err := s.requester(meta.ActorID)
+	if err != nil {
+		return err
+	}
+
+	err = withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		role, err := s.load(tx, roleID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkReach(requester, role); err != nil {
+			return err
+		}
+		if role.IsSystem {
+			return ErrRoleSystem
+		}
+
+		for _, model := range []interface{}{&models.User{}, &models.Invitation{}, &models.OfficeMembership{}} {
+			var count int64
+			if err := tx.Model(model).Where("rol = ?", role.Name).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrRoleInUse
+			}
+		}
+
+		if err := tx.Where("rol = ?", role.Name).Delete(&models.TwoFactorPolicy{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Select("Permissions").Delete(role).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditRoleDeleted, auditEntityRole, role.ID, map[string]interface{}{
+			"name": role.Name,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateRoleCache()
+	return nil
+}
+
+func (s *RoleService) get(roleID string) (*dto.RoleResponse, error) {
+	role, err := s.load(s.db, roleID)
+	if err != nil {
+		return nil, err
+	}
+	response := buildRoleResponse(role)
+	return &response, nil
+}
+
+func (s *RoleService) load(db *gorm.DB, roleID string) (*models.Role, error) {
+	var role models.Role
+	if err := db.Preload("Permissions").Where("id = ?", roleID).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (s *RoleService) requester(userID string) (*models.User, error) {
+	var requester models.User
+	if err := s.db.Where("id = ? AND is_active = ?", userID, true).First(&requester).Error; err != nil {
+		return nil, errors.New("usuario solicitante no encontrado")
+	}
+	return &requester, nil
+}
+
+// checkLevel impide crear roles al mismo nivel o por encima de quien los define
+func (s *RoleService) checkLevel(requester *models.User, level int) error {
+	grants, err := loadRoleGrants(s.db, requester.Rol)
+	if err != nil {
+		return err
+	}
+	if level < 1 || level >= grants.level {
+		return ErrRoleLevelInvalid
+	}
+	return nil
+}
+
+// checkReach impide modificar un rol del mismo nivel o superior al de quien
+// lo edita; si no, podría ampliar los permisos de su propio rol
+func (s *RoleService) checkReach(requester *models.User, role *models.Role) error {
+	grants, err := loadRoleGrants(s.db, requester.Rol)
+	if err != nil {
+		return err
+	}
+	if role.Level >= grants.level {
+		return ErrRoleOutOfReach
+	}
+	return nil
+}
+
+// resolvePermissions valida los códigos contra el catálogo. Nadie puede
+// otorgar un permiso que su propio rol no tiene.
+func (s *RoleService) resolvePermissions(tx *gorm.DB, requester *models.User, codes []string) ([]models.Permission, error) {
+	unique := map[string]bool{}
+	for _, code := range codes {
+		unique[strings.TrimSpace(code)] = true
+	}
+	list := make([]string, 0, len(unique))
+	for code := range unique {
+		list = append(list, code)
+	}
+	if len(list) == 0 {
+		return []models.Permission{}, nil
+	}
+
+	var permissions []models.Permission
+	if err := tx.Where("code IN ?", list).Order("code ASC").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	if len(permissions) != len(list) {
+		return nil, ErrPermissionUnknown
+	}
+
+	grants, err := loadRoleGrants(tx, requester.Rol)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range permissions {
+		if !grants.permissions[p.Code] {
+			return nil, ErrPermissionNotHeld
+		}
+	}
+	return permissions, nil
+}
+
+func permissionCodes(permissions []models.Permission) []string {
+	codes := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		codes = append(codes, p.Code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func buildRoleResponse(role *models.Role) dto.RoleResponse {
+	return dto.RoleResponse{
+		ID:          role.ID,
+		Name:        string(role.Name),
+		Description: role.Description,
+		Level:       role.Level,
+		IsSystem:    role.IsSystem,
+		Permissions: permissionCodes(role.Permissions),
+	}
+}
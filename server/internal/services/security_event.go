@@ -0,0 +1,60 @@
+// server/internal/services/security_event.go
+package services
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+	"server/pkgs/logger"
+)
+
+// recordSecurityEvent inserta un evento en el registro de seguridad.
+// Un fallo al registrar nunca interrumpe la operación principal.
+func recordSecurityEvent(db *gorm.DB, eventType models.SecurityEventType, userID, email, ip *string, details map[string]interface{}) {
+	saveSecurityEvent(db, models.SecurityEvent{
+		Type:   eventType,
+		UserID: userID,
+		Email:  email,
+		IP:     ip,
+	}, details)
+}
+
+// recordClientSecurityEvent registra un evento de inicio de sesión junto con
+// la IP y el dispositivo del cliente
+func recordClientSecurityEvent(db *gorm.DB, eventType models.SecurityEventType, userID *string, email string, meta LoginMeta, details map[string]interface{}) {
+	saveSecurityEvent(db, models.SecurityEvent{
+		Type:      eventType,
+		UserID:    userID,
+		Email:     optionalString(email),
+		IP:        optionalString(truncate(meta.IP, 50)),
+		UserAgent: optionalString(truncate(meta.UserAgent, 255)),
+	}, details)
+}
+
+func saveSecurityEvent(db *gorm.DB, event models.SecurityEvent, details map[string]interface{}) {
+	if len(details) > 0 {
+		if raw, err := json.Marshal(details); err == nil {
+			str := string(raw)
+			event.Details = &str
+		}
+	}
+
+	if err := db.Create(&event).Error; err != nil {
+		logger.Log.Errorf("❌ No se pudo registrar el evento de seguridad %s: %v", event.Type, err)
+		return
+	}
+
+	// Los inicios de sesión correctos solo se guardan como historial
+	if event.Type == models.SecurityEventLoginSucceeded {
+		return
+	}
+	logger.Log.Warnf("🛡️ Security event %s (user=%v ip=%v)", event.Type, deref(event.UserID), deref(event.IP))
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,112 @@
+// server/internal/services/security_event_service.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/dto"
+	"server/internal/models"
+)
+
+const (
+	securityEventsDefaultLimit = 50
+	securityEventsMaxLimit     = 200
+)
+
+var uuidRx = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var ErrSecurityEventFilter = errors.New("filtro inválido: userId debe ser un UUID y las fechas YYYY-MM-DD o RFC 3339")
+
+type SecurityEventService struct {
+	db *gorm.DB
+}
+
+func NewSecurityEventService(db *gorm.DB) *SecurityEventService {
+	return &SecurityEventService{db: db}
+}
+
+// List devuelve los eventos del más reciente al más antiguo, filtrados por
+// usuario, tipo y rango de fechas
+func (s *SecurityEventService) List(filter dto.SecurityEventFilter) (*dto.SecurityEventPage, error) {
+	query := s.db.Model(&models.SecurityEvent{})
+
+	if filter.UserID != "" {
+		if !uuidRx.MatchString(filter.UserID) {
+			return nil, ErrSecurityEventFilter
+		}
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", strings.ToUpper(strings.TrimSpace(filter.Type)))
+	}
+	if filter.From != "" {
+		from, _, err := parseEventDate(filter.From)
+		if err != nil {
+			return nil, ErrSecurityEventFilter
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if filter.To != "" {
+		to, dateOnly, err := parseEventDate(filter.To)
+		if err != nil {
+			return nil, ErrSecurityEventFilter
+		}
+		if dateOnly {
+			query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+		} else {
+			query = query.Where("created_at <= ?", to)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	page := max(filter.Page, 1)
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = securityEventsDefaultLimit
+	}
+	limit = min(limit, securityEventsMaxLimit)
+
+	var events []models.SecurityEvent
+	if err := query.Order("created_at DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.SecurityEventResponse, 0, len(events))
+	for _, e := range events {
+		item := dto.SecurityEventResponse{
+			ID:        e.ID,
+			Type:      string(e.Type),
+			UserID:    e.UserID,
+			Email:     e.Email,
+			IP:        e.IP,
+			UserAgent: e.UserAgent,
+			CreatedAt: e.CreatedAt,
+		}
+		if e.Details != nil && json.Valid([]byte(*e.Details)) {
+			item.Details = json.RawMessage(*e.Details)
+		}
+		items = append(items, item)
+	}
+
+	return &dto.SecurityEventPage{Items: items, Total: total, Page: page, Limit: limit}, nil
+}
+
+// parseEventDate acepta una fecha sola o fecha y hora RFC 3339
+func parseEventDate(value string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
@@ -0,0 +1,493 @@
+// server/internal/services/service_account_service.go
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/pkgs/logger"
+)
+
+// Acciones de auditoría sobre cuentas de servicio y sus claves
+const (
+	AuditServiceAccountCreated     = "SERVICE_ACCOUNT_CREATED"
+	AuditServiceAccountDeactivated = "SERVICE_ACCOUNT_DEACTIVATED"
+	AuditAPIKeyCreated             = "API_KEY_CREATED"
+	AuditAPIKeyRevoked             = "API_KEY_REVOKED"
+)
+
+const (
+	// Formato de la clave: sk_<prefijo>_<secreto>. El prefijo se guarda en
+	// claro para localizarla; del total solo se guarda el hash.
+	apiKeyMarker       = "sk_"
+	apiKeyPrefixBytes  = 6
+	apiKeySecretBytes  = 32
+	serviceAccountMail = "@service-accounts.invalid"
+
+	// Frecuencia máxima con la que se actualiza last_used_at
+	apiKeyUsageResolution = time.Minute
+)
+
+var (
+	ErrServiceAccountNotFound    = errors.New("cuenta de servicio no encontrada")
+	ErrServiceAccountNameInvalid = errors.New("el nombre debe tener entre 3 y 50 caracteres en minúsculas, números o guiones")
+	ErrServiceAccountNameTaken   = errors.New("ya existe una cuenta de servicio con ese nombre")
+	ErrServiceAccountInactive    = errors.New("la cuenta de servicio está desactivada")
+	ErrAPIKeyNotFound            = errors.New("API key no encontrada")
+	ErrAPIKeyNameRequired        = errors.New("el nombre de la API key es obligatorio")
+	ErrAPIKeyScopesRequired      = errors.New("la API key debe tener al menos un scope")
+	ErrAPIKeyScopeInvalid        = errors.New("los scopes deben ser permisos del rol de la cuenta y de tu propio rol")
+	ErrAPIKeyExpiryInvalid       = errors.New("la fecha de expiración debe ser futura")
+	ErrAPIKeyRevoked             = errors.New("la API key ya fue revocada")
+	ErrAPIKeyInvalid             = errors.New("API key inválida, expirada o revocada")
+)
+
+var serviceAccountNameRx = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{2,49}$`)
+
+// APIKeyPrincipal es la identidad con la que opera una petición autenticada
+// con API key: la cuenta de servicio, su rol y los scopes de la clave
+type APIKeyPrincipal struct {
+	KeyID  string
+	UserID string
+	Role   models.Rol
+	Scopes []string
+}
+
+type ServiceAccountService struct {
+	db     *gorm.DB
+	keyTTL time.Duration
+}
+
+// NewServiceAccountService recibe la vigencia por defecto de las claves
+// (0 = sin expiración salvo que se indique al crearlas)
+func NewServiceAccountService(db *gorm.DB, keyTTL time.Duration) *ServiceAccountService {
+	return &ServiceAccountService{db: db, keyTTL: keyTTL}
+}
+
+// forRequest devuelve una copia del servicio cuyas consultas llevan el
+// contexto de la petición
+func (s *ServiceAccountService) forRequest(ctx context.Context) *ServiceAccountService {
+	bound := *s
+	bound.db = s.db.WithContext(ctx)
+	return &bound
+}
+
+// List devuelve las cuentas de servicio de roles inferiores al del solicitante
+func (s *ServiceAccountService) List(ctx context.Context, requesterID string) ([]dto.ServiceAccountResponse, error) {
+	s = s.forRequest(ctx)
+	requester, err := s.requester(requesterID)
+	if err != nil {
+		return nil, err
+	}
+	lower, err := lowerRoles(s.db, requester)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []models.User
+	if err := s.db.Preload("Office").
+		Where("is_service_account = ? AND rol IN ?", true, lower).
+		Order("created_at ASC").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.ServiceAccountResponse, 0, len(accounts))
+	for i := range accounts {
+		account, err := s.buildAccount(&accounts[i])
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, *account)
+	}
+	return response, nil
+}
+
+// Create da de alta la cuenta. No tiene contraseña ni correo real, por lo que
+// no puede iniciar sesión; solo opera con las API keys que se le emitan.
+func (s *ServiceAccountService) Create(ctx context.Context, req dto.CreateServiceAccountRequest, meta AuditMeta) (*dto.ServiceAccountResponse, error) {
+	s = s.forRequest(ctx)
+	requester, err := s.requester(meta.ActorID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if !serviceAccountNameRx.MatchString(name) {
+		return nil, ErrServiceAccountNameInvalid
+	}
+	rol := models.Rol(req.Role)
+	if err := assignableRole(s.db, requester, rol); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	account := models.User{
+		Name:             &name,
+		Email:            name + serviceAccountMail,
+		EmailVerified:    &now,
+		Rol:              rol,
+		IsActive:         true,
+		IsServiceAccount: true,
+		CreatedByID:      &requester.ID,
+	}
+	if req.Office != nil && strings.TrimSpace(*req.Office) != "" {
+		office, err := findActiveOffice(s.db, *req.Office)
+		if err != nil {
+			return nil, err
+		}
+		account.OfficeID = &office.ID
+	}
+
+	err = withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("LOWER(email) = ?", normalizeEmail(account.Email)).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrServiceAccountNameTaken
+		}
+
+		if err := tx.Omit("CreatedBy", "Office").Create(&account).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditServiceAccountCreated, auditEntityUser, account.ID, map[string]interface{}{
+			"name":   name,
+			"rol":    rol,
+			"office": req.Office,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Infof("🤖 Service account %s created by %s", name, requester.Email)
+	if err := loadUserOffice(s.db, &account); err != nil {
+		return nil, err
+	}
+	return s.buildAccount(&account)
+}
+
+// Deactivate desactiva la cuenta y revoca todas sus claves
+func (s *ServiceAccountService) Deactivate(ctx context.Context, accountID string, meta AuditMeta) error {
+	s = s.forRequest(ctx)
+	requester, err := s.requester(meta.ActorID)
+	if err != nil {
+		return err
+	}
+	account, err := s.load(requester, accountID)
+	if err != nil {
+		return err
+	}
+	if !account.IsActive {
+		return ErrServiceAccountInactive
+	}
+
+	return withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(account).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		revoked := tx.Model(&models.APIKey{}).
+			Where("user_id = ? AND revoked_at IS NULL", account.ID).
+			Update("revoked_at", time.Now())
+		if revoked.Error != nil {
+			return revoked.Error
+		}
+		return recordAudit(tx, meta, AuditServiceAccountDeactivated, auditEntityUser, account.ID, map[string]interface{}{
+			"revokedKeys": revoked.RowsAffected,
+		})
+	})
+}
+
+// CreateKey emite una API key. La clave completa solo se devuelve aquí.
+func (s *ServiceAccountService) CreateKey(ctx context.Context, accountID string, req dto.CreateAPIKeyRequest, meta AuditMeta) (*dto.APIKeyCreatedResponse, error) {
+	s = s.forRequest(ctx)
+	requester, err := s.requester(meta.ActorID)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.load(requester, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !account.IsActive {
+		return nil, ErrServiceAccountInactive
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrAPIKeyNameRequired
+	}
+
+	var expiresAt *time.Time
+	switch {
+	case req.ExpiresAt != nil:
+		if !req.ExpiresAt.After(time.Now()) {
+			return nil, ErrAPIKeyExpiryInvalid
+		}
+		expiresAt = req.ExpiresAt
+	case s.keyTTL > 0:
+		expires := time.Now().Add(s.keyTTL)
+		expiresAt = &expires
+	}
+
+	permissions, err := s.resolveScopes(requester, account, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, secret, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	raw := apiKeyMarker + prefix + "_" + secret
+
+	key := models.APIKey{
+		UserID:      account.ID,
+		Name:        name,
+		Prefix:      prefix,
+		KeyHash:     hashAPIKey(raw),
+		ExpiresAt:   expiresAt,
+		CreatedByID: &requester.ID,
+	}
+
+	err = withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User", "CreatedBy", "Permissions").Create(&key).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&key).Association("Permissions").Replace(permissions); err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditAPIKeyCreated, auditEntityUser, account.ID, map[string]interface{}{
+			"keyId":     key.ID,
+			"prefix":    prefix,
+			"scopes":    permissionCodes(permissions),
+			"expiresAt": expiresAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	key.Permissions = permissions
+	return &dto.APIKeyCreatedResponse{APIKeyResponse: buildAPIKeyResponse(&key), Key: raw}, nil
+}
+
+// RevokeKey invalida la clave de inmediato
+func (s *ServiceAccountService) RevokeKey(ctx context.Context, accountID, keyID string, meta AuditMeta) error {
+	s = s.forRequest(ctx)
+	requester, err := s.requester(meta.ActorID)
+	if err != nil {
+		return err
+	}
+	account, err := s.load(requester, accountID)
+	if err != nil {
+		return err
+	}
+
+	return withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		var key models.APIKey
+		if err := tx.Where("id = ? AND user_id = ?", keyID, account.ID).First(&key).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAPIKeyNotFound
+			}
+			return err
+		}
+		if key.RevokedAt != nil {
+			return ErrAPIKeyRevoked
+		}
+
+		if err := tx.Model(&key).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditAPIKeyRevoked, auditEntityUser, account.ID, map[string]interface{}{
+			"keyId":  key.ID,
+			"prefix": key.Prefix,
+		})
+	})
+}
+
+func (s *ServiceAccountService) requester(userID string) (*models.User, error) {
+	var requester models.User
+	if err := s.db.Where("id = ? AND is_active = ?", userID, true).First(&requester).Error; err != nil {
+		return nil, errors.New("usuario solicitante no encontrado")
+	}
+	if err := requirePermission(s.db, &requester, models.PermServiceAccountManage); err != nil {
+		return nil, err
+	}
+	return &requester, nil
+}
+
+// load devuelve la cuenta si su rol está por debajo del solicitante
+func (s *ServiceAccountService) load(requester *models.User, accountID string) (*models.User, error) {
+	var account models.User
+	if err := s.db.Preload("Office").
+		Where("id = ? AND is_service_account = ?", accountID, true).
+		First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrServiceAccountNotFound
+		}
+		return nil, err
+	}
+
+	above, err := outranks(s.db, requester.Rol, account.Rol)
+	if err != nil {
+		return nil, err
+	}
+	if !above {
+		return nil, ErrServiceAccountNotFound
+	}
+	return &account, nil
+}
+
+// resolveScopes exige que cada scope sea un permiso del rol de la cuenta y
+// del solicitante: una clave nunca amplía lo que ninguno de los dos puede hacer
+func (s *ServiceAccountService) resolveScopes(requester, account *models.User, scopes []string) ([]models.Permission, error) {
+	unique := map[string]bool{}
+	for _, scope := range scopes {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			unique[scope] = true
+		}
+	}
+	if len(unique) == 0 {
+		return nil, ErrAPIKeyScopesRequired
+	}
+
+	codes := make([]string, 0, len(unique))
+	for code := range unique {
+		for _, user := range []*models.User{requester, account} {
+			ok, err := hasPermission(s.db, user.Rol, code)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, ErrAPIKeyScopeInvalid
+			}
+		}
+		codes = append(codes, code)
+	}
+
+	var permissions []models.Permission
+	if err := s.db.Where("code IN ?", codes).Order("code ASC").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (s *ServiceAccountService) buildAccount(account *models.User) (*dto.ServiceAccountResponse, error) {
+	var keys []models.APIKey
+	if err := s.db.Preload("Permissions").Where("user_id = ?", account.ID).
+		Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	response := &dto.ServiceAccountResponse{
+		ID:        account.ID,
+		Name:      deref(account.Name),
+		Role:      string(account.Rol),
+		Office:    officeCode(account.Office),
+		IsActive:  account.IsActive,
+		CreatedAt: account.CreatedAt.Format("2006-01-02 15:04:05"),
+		Keys:      make([]dto.APIKeyResponse, 0, len(keys)),
+	}
+	for i := range keys {
+		response.Keys = append(response.Keys, buildAPIKeyResponse(&keys[i]))
+	}
+	return response, nil
+}
+
+func buildAPIKeyResponse(key *models.APIKey) dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     apiKeyMarker + key.Prefix,
+		Scopes:     permissionCodes(key.Permissions),
+		ExpiresAt:  formatOptionalTime(key.ExpiresAt),
+		LastUsedAt: formatOptionalTime(key.LastUsedAt),
+		LastUsedIP: key.LastUsedIP,
+		RevokedAt:  formatOptionalTime(key.RevokedAt),
+		CreatedAt:  key.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format("2006-01-02 15:04:05")
+	return &formatted
+}
+
+// IsAPIKey indica si el token Bearer tiene formato de API key
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyMarker)
+}
+
+// authenticateAPIKey localiza la clave por su prefijo y compara el hash. La
+// clave debe estar vigente y su cuenta de servicio activa.
+func authenticateAPIKey(db *gorm.DB, raw, ip string) (*APIKeyPrincipal, error) {
+	rest := strings.TrimPrefix(raw, apiKeyMarker)
+	prefixLen := hex.EncodedLen(apiKeyPrefixBytes)
+	if len(rest) <= prefixLen+1 || rest[prefixLen] != '_' {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	var key models.APIKey
+	if err := db.Preload("User").Preload("Permissions").
+		Where("prefix = ?", rest[:prefixLen]).First(&key).Error; err != nil {
+		return nil, ErrAPIKeyInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashAPIKey(raw))) != 1 {
+		return nil, ErrAPIKeyInvalid
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+		return nil, ErrAPIKeyInvalid
+	}
+	if !key.User.IsActive || !key.User.IsServiceAccount {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyUsageResolution {
+		if err := db.Model(&key).UpdateColumns(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": optionalString(ip),
+		}).Error; err != nil {
+			logger.Log.Errorf("❌ No se pudo registrar el uso de la API key %s: %v", key.Prefix, err)
+		}
+	}
+
+	return &APIKeyPrincipal{
+		KeyID:  key.ID,
+		UserID: key.UserID,
+		Role:   key.User.Rol,
+		Scopes: permissionCodes(key.Permissions),
+	}, nil
+}
+
+func generateAPIKey() (string, string, error) {
+	prefix := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(prefix), base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
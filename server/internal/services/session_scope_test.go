@@ -0,0 +1,33 @@
+package services
+
+import "testing"
+
+func TestScopeAllows(t *testing.T) {
+	cases := []struct {
+		scope string
+		path  string
+		want  bool
+	}{
+		{SessionScopeFull, "/auth/2fa/policy", true},
+		{"", "/users", true},
+		{SessionScopeTwoFactorSetup, "/auth/2fa/enroll", true},
+		{SessionScopeTwoFactorSetup, "/auth/2fa/enroll/confirm", true},
+		{SessionScopeTwoFactorSetup, "/auth/2fa/enroll/", true},
+		{SessionScopeTwoFactorSetup, "/Auth/2FA/Enroll", true},
+		{SessionScopeTwoFactorSetup, "/auth/signout", true},
+		// La política y los demás endpoints del grupo exigen sesión completa
+		{SessionScopeTwoFactorSetup, "/auth/2fa/policy", false},
+		{SessionScopeTwoFactorSetup, "/auth/2fa/disable", false},
+		{SessionScopeTwoFactorSetup, "/auth/2fa/recovery-codes", false},
+		{SessionScopeTwoFactorSetup, "/user/update-password", false},
+		{SessionScopePasswordChange, "/user/update-password", true},
+		{SessionScopePasswordChange, "/user/update-password-admin", false},
+		{SessionScopePasswordChange, "/auth/2fa/enroll", false},
+		{"desconocido", "/auth/signout", false},
+	}
+	for _, tc := range cases {
+		if got := ScopeAllows(tc.scope, tc.path); got != tc.want {
+			t.Errorf("ScopeAllows(%q, %q) = %v, se esperaba %v", tc.scope, tc.path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+// server/internal/services/session_service.go
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/pkgs/geoip"
+	"server/pkgs/logger"
+	"server/pkgs/mailer"
+	"server/pkgs/security"
+)
+
+const (
+	SessionScopeFull           = "full"
+	SessionScopeTwoFactorSetup = "2fa_setup"
+	SessionScopePasswordChange = "password_change"
+
+	twoFactorChallengePurpose = "2fa_challenge"
+	twoFactorChallengeTTL     = 5 * time.Minute
+)
+
+var ErrSessionInvalid = errors.New("sesión inválida o expirada")
+
+// scopeAllowedPaths limita las rutas accesibles con una sesión restringida.
+// Son rutas exactas: un prefijo abriría también las que se agreguen después
+// bajo el mismo grupo (p. ej. la política 2FA).
+var scopeAllowedPaths = map[string][]string{
+	SessionScopeTwoFactorSetup: {"/auth/2fa/status", "/auth/2fa/enroll", "/auth/2fa/enroll/confirm", "/auth/signout"},
+	SessionScopePasswordChange: {"/user/update-password", "/auth/signout"},
+}
+
+// LoginMeta contiene los datos del cliente que inicia sesión
+type LoginMeta struct {
+	IP        string
+	UserAgent string
+}
+
+type SessionService struct {
+	db               *gorm.DB
+	jwt              *security.JWTService
+	mail             *mailer.Mailer
+	geo              *geoip.Resolver
+	ttl              time.Duration
+	impersonationTTL time.Duration
+	passwordMaxAge   time.Duration
+}
+
+func NewSessionService(db *gorm.DB, jwtService *security.JWTService, mail *mailer.Mailer, geo *geoip.Resolver, ttl, impersonationTTL, passwordMaxAge time.Duration) *SessionService {
+	return &SessionService{db: db, jwt: jwtService, mail: mail, geo: geo, ttl: ttl, impersonationTTL: impersonationTTL, passwordMaxAge: passwordMaxAge}
+}
+
+// CompleteLogin decide el paso siguiente tras validar la contraseña:
+// desafío 2FA, sesión restringida (cambio de contraseña o enrolamiento) o sesión completa.
+func (s *SessionService) CompleteLogin(user *models.User, meta LoginMeta) (*dto.AuthResponse, error) {
+	if user.TwoFactorEnabled {
+		challenge, err := s.jwt.GeneratePurposeToken(user.ID, twoFactorChallengePurpose, twoFactorChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.AuthResponse{
+			Email:             user.Email,
+			TwoFactorRequired: true,
+			ChallengeToken:    &challenge,
+		}, nil
+	}
+
+	return s.Issue(user, s.ScopeFor(user), meta)
+}
+
+// ScopeFor devuelve el scope que corresponde al usuario una vez superada la
+// autenticación: primero se exige el cambio de contraseña y luego el enrolamiento 2FA.
+func (s *SessionService) ScopeFor(user *models.User) string {
+	if s.PasswordChangeRequired(user) {
+		return SessionScopePasswordChange
+	}
+	if !user.TwoFactorEnabled && s.TwoFactorRequired(user.Rol) {
+		return SessionScopeTwoFactorSetup
+	}
+	return SessionScopeFull
+}
+
+// PasswordChangeRequired indica si la contraseña local fue marcada para cambio
+// o superó la antigüedad máxima. Las cuentas sin contraseña local no aplican.
+func (s *SessionService) PasswordChangeRequired(user *models.User) bool {
+	if user.Password == nil || *user.Password == "" {
+		return false
+	}
+	if user.MustChangePassword {
+		return true
+	}
+	if s.passwordMaxAge <= 0 {
+		return false
+	}
+
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return time.Since(changedAt) > s.passwordMaxAge
+}
+
+// Issue crea la sesión en base de datos y firma el token de acceso. Solo una
+// sesión completa cuenta como inicio de sesión: las restringidas aún esperan
+// el cambio de contraseña o el enrolamiento 2FA.
+func (s *SessionService) Issue(user *models.User, scope string, meta LoginMeta) (*dto.AuthResponse, error) {
+	expires := time.Now().Add(s.ttl)
+	sessionID, err := s.store(user.ID, scope, expires, meta, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.jwt.GenerateSessionToken(user.ID, user.Email, string(user.Rol), sessionID, scope, expires)
+	if err != nil {
+		return nil, err
+	}
+
+	if scope == SessionScopeFull {
+		s.recordLogin(user, meta)
+	}
+
+	if err := loadUserOffice(s.db, user); err != nil {
+		return nil, err
+	}
+	response := buildAuthResponse(user)
+	expiresAt := expires.Unix()
+	response.AccessToken = &token
+	response.ExpiresAt = &expiresAt
+	response.TwoFactorSetupRequired = scope == SessionScopeTwoFactorSetup
+	response.PasswordChangeRequired = scope == SessionScopePasswordChange
+
+	return response, nil
+}
+
+// IssueImpersonation abre una sesión completa del usuario operada por
+// impersonatorID. Dura lo configurado para suplantación y nunca más que una
+// sesión normal; el token lleva el claim "act" con el usuario real.
+func (s *SessionService) IssueImpersonation(user *models.User, impersonatorID string, meta LoginMeta) (*dto.AuthResponse, error) {
+	ttl := s.impersonationTTL
+	if ttl <= 0 || ttl > s.ttl {
+		ttl = s.ttl
+	}
+	expires := time.Now().Add(ttl)
+
+	sessionID, err := s.store(user.ID, SessionScopeFull, expires, meta, &impersonatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.jwt.GenerateImpersonationToken(user.ID, user.Email, string(user.Rol), sessionID, impersonatorID, expires)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadUserOffice(s.db, user); err != nil {
+		return nil, err
+	}
+	response := buildAuthResponse(user)
+	expiresAt := expires.Unix()
+	response.AccessToken = &token
+	response.ExpiresAt = &expiresAt
+	response.ImpersonatedBy = &impersonatorID
+
+	return response, nil
+}
+
+// store guarda la sesión y devuelve su identificador
+func (s *SessionService) store(userID, scope string, expires time.Time, meta LoginMeta, impersonatorID *string) (string, error) {
+	sessionID, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	location, country := s.locate(meta.IP)
+	session := models.Session{
+		SessionToken:   sessionID,
+		UserID:         userID,
+		Expires:        expires,
+		Scope:          scope,
+		IP:             optionalString(meta.IP),
+		UserAgent:      optionalString(truncate(meta.UserAgent, 255)),
+		Location:       location,
+		Country:        country,
+		ImpersonatorID: impersonatorID,
+	}
+	if err := s.db.Omit("User", "Impersonator").Create(&session).Error; err != nil {
+		return "", err
+	}
+	return sessionID, nil
+}
+
+// Validate verifica el token y que la sesión siga vigente y el usuario activo.
+// En una suplantación también debe seguir activo quien la inició.
+func (s *SessionService) Validate(token string) (*security.SessionClaims, error) {
+	claims, err := s.jwt.ParseSessionToken(token)
+	if err != nil {
+		return nil, ErrSessionInvalid
+	}
+
+	query := s.db.Model(&models.Session{}).
+		Joins("JOIN users ON users.id = sessions.user_id").
+		Where("sessions.session_token = ? AND sessions.user_id = ? AND sessions.expires > ? AND users.is_active = ?",
+			claims.SessionID, claims.Subject, time.Now(), true)
+	if claims.Actor != nil {
+		query = query.Joins("JOIN users actors ON actors.id = sessions.impersonator_id").
+			Where("sessions.impersonator_id = ? AND actors.is_active = ?", claims.Actor.Subject, true)
+	} else {
+		query = query.Where("sessions.impersonator_id IS NULL")
+	}
+
+	var count int64
+	query.Count(&count)
+	if count == 0 {
+		return nil, ErrSessionInvalid
+	}
+
+	return claims, nil
+}
+
+// ValidateAPIKey autentica una API key de cuenta de servicio y registra su uso
+func (s *SessionService) ValidateAPIKey(key, ip string) (*APIKeyPrincipal, error) {
+	return authenticateAPIKey(s.db, key, ip)
+}
+
+// Revoke elimina la sesión indicada
+func (s *SessionService) Revoke(sessionID string) error {
+	return s.db.Where("session_token = ?", sessionID).Delete(&models.Session{}).Error
+}
+
+// RevokeAllForUser cierra todas las sesiones del usuario
+func (s *SessionService) RevokeAllForUser(userID string) error {
+	return revokeUserSessions(s.db, userID)
+}
+
+// revokeUserSessions borra las sesiones del usuario con db, que puede ser la
+// transacción del cambio que las invalida
+func revokeUserSessions(db *gorm.DB, userID string) error {
+	return db.Where("user_id = ?", userID).Delete(&models.Session{}).Error
+}
+
+// ParseChallenge devuelve el usuario asociado a un desafío 2FA vigente
+func (s *SessionService) ParseChallenge(challengeToken string) (string, error) {
+	return s.jwt.ParsePurposeToken(challengeToken, twoFactorChallengePurpose)
+}
+
+// TwoFactorRequired indica si la política exige 2FA para el rol
+func (s *SessionService) TwoFactorRequired(rol models.Rol) bool {
+	var policy models.TwoFactorPolicy
+	if err := s.db.Where("rol = ?", rol).First(&policy).Error; err != nil {
+		return false
+	}
+	return policy.Required
+}
+
+// ScopeAllows indica si una sesión con el scope dado puede acceder a la ruta
+func ScopeAllows(scope, path string) bool {
+	if scope == "" || scope == SessionScopeFull {
+		return true
+	}
+	// Fiber enruta sin distinguir mayúsculas ni la barra final
+	path = strings.ToLower(strings.TrimSuffix(path, "/"))
+	return slices.Contains(scopeAllowedPaths[scope], path)
+}
+
+func randomToken(numBytes int) (string, error) {
+	b := make([]byte, numBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// recordLogin guarda fecha, IP y dispositivo del inicio de sesión en el usuario
+// y en el registro de seguridad. Issue lo llama al emitir una sesión completa;
+// un fallo al guardarlo no impide el acceso.
+func (s *SessionService) recordLogin(user *models.User, meta LoginMeta) {
+	s.detectNewLogin(user, meta)
+
+	user.LastLogin = ptrTimeNow()
+	user.LastIP = optionalString(truncate(meta.IP, 50))
+	user.LastDevice = optionalString(truncate(meta.UserAgent, 255))
+	user.LastOS = optionalString(detectOS(meta.UserAgent))
+	user.LastLocation, user.LastCountry = s.locate(meta.IP)
+
+	if err := s.db.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+		"last_login":    user.LastLogin,
+		"last_ip":       user.LastIP,
+		"last_device":   user.LastDevice,
+		"last_os":       user.LastOS,
+		"last_location": user.LastLocation,
+		"last_country":  user.LastCountry,
+	}).Error; err != nil {
+		logger.Log.Warnf("⚠️ No se pudo registrar el inicio de sesión de %s: %v", user.Email, err)
+	}
+}
+
+// locate geolocaliza la IP con la base MMDB local. Si la IP es desconocida o
+// no hay base configurada ambos valores quedan en nil; una IP interna solo
+// lleva la ubicación "Red interna".
+func (s *SessionService) locate(ip string) (*string, *string) {
+	loc, ok := s.geo.Lookup(ip)
+	if !ok {
+		return nil, nil
+	}
+	return optionalString(truncate(loc.String(), 255)), optionalString(loc.CountryCode)
+}
+
+// detectOS obtiene el sistema operativo a partir del User-Agent
+func detectOS(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case ua == "":
+		return ""
+	case strings.Contains(ua, "android"):
+		return "Android"
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"):
+		return "iOS"
+	case strings.Contains(ua, "windows"):
+		return "Windows"
+	case strings.Contains(ua, "mac os x"), strings.Contains(ua, "macintosh"):
+		return "macOS"
+	case strings.Contains(ua, "cros"):
+		return "ChromeOS"
+	case strings.Contains(ua, "linux"):
+		return "Linux"
+	default:
+		return "Desconocido"
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
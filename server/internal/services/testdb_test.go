@@ -0,0 +1,70 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"server/internal/audit"
+	"server/internal/database/migrate"
+	"server/internal/models"
+	"server/pkgs/oidc"
+	"server/pkgs/security"
+)
+
+// testDB abre la base de pruebas indicada en TEST_DATABASE_DSN y migra las
+// tablas que usan los servicios. Sin la variable, la prueba se omite.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN no está definida")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("conectando a la base de pruebas: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Permission{},
+		&models.Role{},
+		&models.Office{},
+		&models.User{},
+		&models.Account{},
+		&models.OfficeMembership{},
+		&models.Session{},
+		&models.AuditLog{},
+		&models.Asset{},
+		&models.OffboardingRecord{},
+		&models.AuthThrottle{},
+		&models.RecoveryCode{},
+		&models.SecurityEvent{},
+	); err != nil {
+		t.Fatalf("migrando la base de pruebas: %v", err)
+	}
+	if err := migrate.NormalizeUserEmails(db); err != nil {
+		t.Fatalf("normalizando correos: %v", err)
+	}
+	if err := migrate.SeedRoles(db); err != nil {
+		t.Fatalf("creando roles: %v", err)
+	}
+	// Los cambios de los servicios se auditan como en el servidor
+	if err := audit.Register(db, security.SecretKey("clave-de-auditoria-solo-para-pruebas")); err != nil {
+		t.Fatalf("registrando la auditoría: %v", err)
+	}
+	invalidateRoleCache()
+	return db
+}
+
+// uniqueEmail evita choques entre ejecuciones sobre la misma base
+func uniqueEmail(t *testing.T, local string) string {
+	t.Helper()
+	suffix, err := oidc.RandomString(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.ToLower(local + "." + suffix + "@example.gob.pe")
+}
@@ -0,0 +1,370 @@
+// server/internal/services/two_factor_service.go
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/pkgs/security"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrTwoFactorAlreadyEnabled   = errors.New("la autenticación en dos pasos ya está activa")
+	ErrTwoFactorNotEnrolled      = errors.New("primero debes iniciar el enrolamiento 2FA")
+	ErrTwoFactorNotEnabled       = errors.New("la autenticación en dos pasos no está activa")
+	ErrTwoFactorInvalidCode      = errors.New("código de verificación inválido")
+	ErrTwoFactorRequiredByPolicy = errors.New("la política de seguridad exige 2FA para tu rol")
+	ErrTwoFactorChallengeInvalid = errors.New("el desafío de inicio de sesión es inválido o expiró")
+	ErrOnlyAdminPolicy           = errors.New("no tienes permisos para modificar la política 2FA")
+)
+
+type TwoFactorService struct {
+	db       *gorm.DB
+	sessions *SessionService
+	throttle *LoginThrottleService
+	issuer   string
+}
+
+func NewTwoFactorService(db *gorm.DB, sessions *SessionService, throttle *LoginThrottleService, issuer string) *TwoFactorService {
+	return &TwoFactorService{db: db, sessions: sessions, throttle: throttle, issuer: issuer}
+}
+
+// forRequest devuelve una copia del servicio cuyas consultas llevan el
+// contexto de la petición
+func (s *TwoFactorService) forRequest(ctx context.Context) *TwoFactorService {
+	bound := *s
+	bound.db = s.db.WithContext(ctx)
+	return &bound
+}
+
+func (s *TwoFactorService) Status(userID string) (*dto.TwoFactorStatusResponse, error) {
+	user, err := s.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var remaining int64
+	s.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&remaining)
+
+	return &dto.TwoFactorStatusResponse{
+		Enabled:                user.TwoFactorEnabled,
+		Required:               s.sessions.TwoFactorRequired(user.Rol),
+		RecoveryCodesRemaining: int(remaining),
+	}, nil
+}
+
+// BeginEnrollment genera un secreto pendiente y su URI de aprovisionamiento
+func (s *TwoFactorService) BeginEnrollment(ctx context.Context, userID string) (*dto.TwoFactorEnrollResponse, error) {
+	s = s.forRequest(ctx)
+	user, err := s.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"two_factor_secret":    secret,
+		"two_factor_last_step": 0,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.TwoFactorEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: security.TOTPProvisioningURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment activa 2FA con el primer código válido y entrega los
+// códigos de recuperación. Si la sesión actual era restringida se reemplaza.
+func (s *TwoFactorService) ConfirmEnrollment(ctx context.Context, userID, sessionID, sessionScope, code string, meta LoginMeta) (*dto.TwoFactorConfirmResponse, error) {
+	s = s.forRequest(ctx)
+	user, err := s.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TwoFactorSecret == nil || *user.TwoFactorSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	// Los intentos fallidos cuentan como los del login: el código tiene solo
+	// seis dígitos y una sesión 2fa_setup podría probarlos todos
+	if err := s.throttle.Check(user.Email, meta.IP); err != nil {
+		return nil, err
+	}
+	step, err := security.VerifyTOTP(*user.TwoFactorSecret, code, time.Now(), 1)
+	if err != nil {
+		s.throttle.RegisterFailure(user.Email, meta.IP, &user.ID)
+		return nil, ErrTwoFactorInvalidCode
+	}
+	s.throttle.RegisterSuccess(user.Email, meta.IP)
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"two_factor_enabled":   true,
+			"two_factor_last_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recordSecurityEvent(s.db, models.SecurityEventTwoFactorOn, &user.ID, &user.Email, optionalString(meta.IP), nil)
+
+	user.TwoFactorEnabled = true
+	response := &dto.TwoFactorConfirmResponse{RecoveryCodes: codes}
+	if sessionScope == SessionScopeTwoFactorSetup {
+		_ = s.sessions.Revoke(sessionID)
+		session, err := s.sessions.Issue(user, s.sessions.ScopeFor(user), meta)
+		if err != nil {
+			return nil, err
+		}
+		response.Session = session
+	}
+
+	return response, nil
+}
+
+// Disable desactiva 2FA tras validar un código, salvo que la política lo exija
+func (s *TwoFactorService) Disable(ctx context.Context, userID, code, ip string) error {
+	s = s.forRequest(ctx)
+	user, err := s.activeUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if s.sessions.TwoFactorRequired(user.Rol) {
+		return ErrTwoFactorRequiredByPolicy
+	}
+	if err := s.verifyCode(user, code, ip); err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"two_factor_enabled":   false,
+			"two_factor_secret":    nil,
+			"two_factor_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(s.db, models.SecurityEventTwoFactorOff, &user.ID, &user.Email, optionalString(ip), nil)
+	return nil
+}
+
+// RegenerateRecoveryCodes invalida los códigos anteriores y genera nuevos
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID, code, ip string) ([]string, error) {
+	s = s.forRequest(ctx)
+	user, err := s.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.verifyCode(user, code, ip); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// VerifyChallenge completa el segundo paso del login y emite la sesión
+func (s *TwoFactorService) VerifyChallenge(ctx context.Context, req dto.TwoFactorVerifyRequest, meta LoginMeta) (*dto.AuthResponse, error) {
+	s = s.forRequest(ctx)
+	userID, err := s.sessions.ParseChallenge(req.ChallengeToken)
+	if err != nil {
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+
+	user, err := s.activeUser(userID)
+	if err != nil {
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+
+	if err := s.throttle.Check(user.Email, meta.IP); err != nil {
+		return nil, err
+	}
+	if err := s.verifyCode(user, req.Code, meta.IP); err != nil {
+		recordClientSecurityEvent(s.db, models.SecurityEventLoginFailed, &user.ID, user.Email, meta,
+			map[string]interface{}{"reason": err.Error(), "step": "2fa"})
+		return nil, err
+	}
+	s.throttle.RegisterSuccess(user.Email, meta.IP)
+
+	return s.sessions.Issue(user, s.sessions.ScopeFor(user), meta)
+}
+
+func (s *TwoFactorService) GetPolicies() []dto.TwoFactorPolicyResponse {
+	var policies []models.TwoFactorPolicy
+	s.db.Find(&policies)
+
+	required := make(map[models.Rol]bool)
+	for _, p := range policies {
+		required[p.Rol] = p.Required
+	}
+
+	var roles []models.Role
+	s.db.Order("level DESC").Find(&roles)
+
+	response := make([]dto.TwoFactorPolicyResponse, 0, len(roles))
+	for _, role := range roles {
+		response = append(response, dto.TwoFactorPolicyResponse{
+			Role:     string(role.Name),
+			Required: required[role.Name],
+		})
+	}
+	return response
+}
+
+func (s *TwoFactorService) SetPolicy(ctx context.Context, adminID string, req dto.TwoFactorPolicyRequest) error {
+	s = s.forRequest(ctx)
+	admin, err := s.activeUser(adminID)
+	if err != nil {
+		return err
+	}
+	if err := requirePermission(s.db, admin, models.PermSecurityPolicyManage); err != nil {
+		return ErrOnlyAdminPolicy
+	}
+
+	rol := models.Rol(req.Role)
+	grants, err := loadRoleGrants(s.db, rol)
+	if err != nil {
+		return err
+	}
+	if !grants.exists {
+		return errors.New("rol inválido")
+	}
+
+	policy := models.TwoFactorPolicy{Rol: rol, Required: req.Required, UpdatedByID: &admin.ID}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "rol"}},
+		DoUpdates: clause.AssignmentColumns([]string{"required", "updated_by_id", "updated_at"}),
+	}).Create(&policy).Error
+}
+
+// verifyCode acepta un código TOTP (no reutilizado) o un código de recuperación
+func (s *TwoFactorService) verifyCode(user *models.User, code, ip string) error {
+	code = strings.TrimSpace(code)
+
+	if len(code) == 6 && user.TwoFactorSecret != nil {
+		step, err := security.VerifyTOTP(*user.TwoFactorSecret, code, time.Now(), 1)
+		if err == nil {
+			// Un mismo código no puede usarse dos veces
+			res := s.db.Model(&models.User{}).
+				Where("id = ? AND two_factor_last_step < ?", user.ID, step).
+				Update("two_factor_last_step", step)
+			if res.Error == nil && res.RowsAffected == 1 {
+				return nil
+			}
+		}
+		s.throttle.RegisterFailure(user.Email, ip, &user.ID)
+		return ErrTwoFactorInvalidCode
+	}
+
+	res := s.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if res.Error != nil || res.RowsAffected == 0 {
+		s.throttle.RegisterFailure(user.Email, ip, &user.ID)
+		return ErrTwoFactorInvalidCode
+	}
+
+	recordSecurityEvent(s.db, models.SecurityEventRecoveryUsed, &user.ID, &user.Email, optionalString(ip), nil)
+	return nil
+}
+
+func (s *TwoFactorService) activeUser(userID string) (*models.User, error) {
+	var user models.User
+	if err := s.db.Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
+		return nil, errors.New("usuario no encontrado o inactivo")
+	}
+	return &user, nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID string) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)})
+	}
+
+	if err := tx.Omit("User").Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode produce códigos con formato XXXXX-XXXXX
+func generateRecoveryCode() (string, error) {
+	const chars = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	code := make([]byte, 10)
+	for i := range code {
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = chars[num.Int64()]
+	}
+	return string(code[:5]) + "-" + string(code[5:]), nil
+}
+
+// hashRecoveryCode normaliza el código (sin guiones ni espacios) y lo hashea
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/config"
+	"server/internal/models"
+	"server/pkgs/security"
+)
+
+func newTestTwoFactor(t *testing.T, db *gorm.DB) (*TwoFactorService, *models.User, string) {
+	t.Helper()
+	throttle := NewLoginThrottleService(db, config.ThrottleConfig{
+		AccountMaxAttempts: 3,
+		IPMaxAttempts:      1000,
+		AttemptWindow:      time.Hour,
+		LockoutBase:        time.Hour,
+		LockoutMax:         time.Hour,
+	})
+	sessions := NewSessionService(db, nil, nil, nil, time.Hour, time.Hour, 0)
+	service := NewTwoFactorService(db, sessions, throttle, "Pruebas")
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := createTestUser(t, db, "Dos Pasos", uniqueEmail(t, "totp"), models.RolEmployee)
+	if err := db.Model(user).Update("two_factor_secret", secret).Error; err != nil {
+		t.Fatal(err)
+	}
+	user.TwoFactorSecret = &secret
+	return service, user, secret
+}
+
+func TestConfirmEnrollmentIsThrottled(t *testing.T) {
+	db := testDB(t)
+	service, user, _ := newTestTwoFactor(t, db)
+	meta := LoginMeta{IP: "198.51.100.7"}
+
+	for i := 0; i < 3; i++ {
+		_, err := service.ConfirmEnrollment(context.Background(), user.ID, "", SessionScopeTwoFactorSetup, "000000", meta)
+		if !errors.Is(err, ErrTwoFactorInvalidCode) && !errors.Is(err, ErrTooManyAttempts) {
+			t.Fatalf("intento %d: se esperaba código inválido, se obtuvo %v", i+1, err)
+		}
+	}
+	_, err := service.ConfirmEnrollment(context.Background(), user.ID, "", SessionScopeTwoFactorSetup, "000000", meta)
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("tras agotar los intentos se esperaba ErrTooManyAttempts, se obtuvo %v", err)
+	}
+}
+
+func TestVerifyCodeRejectsReusedStep(t *testing.T) {
+	db := testDB(t)
+	service, user, secret := newTestTwoFactor(t, db)
+
+	code, err := security.TOTPCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.verifyCode(user, code, "198.51.100.8"); err != nil {
+		t.Fatalf("el primer uso debe aceptarse: %v", err)
+	}
+	if err := service.verifyCode(user, code, "198.51.100.8"); !errors.Is(err, ErrTwoFactorInvalidCode) {
+		t.Fatalf("el mismo código no puede usarse dos veces, se obtuvo %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"server/internal/dto"
+	"server/internal/models"
+)
+
+func TestEmailsAreCaseInsensitive(t *testing.T) {
+	db := testDB(t)
+	email := uniqueEmail(t, "mayusculas")
+	user := createTestUser(t, db, "Mayúsculas", "  "+strings.ToUpper(email)+" ", models.RolEmployee)
+	if user.Email != email {
+		t.Fatalf("el correo debe guardarse normalizado: %q", user.Email)
+	}
+
+	// El índice sobre LOWER(email) rechaza la misma dirección con otra capitalización
+	if err := db.Exec("INSERT INTO users (email, rol) VALUES (?, 'EMPLOYEE')", strings.ToUpper(email)).Error; err == nil {
+		t.Fatal("se esperaba violación del índice único sobre LOWER(email)")
+	}
+
+	auth := NewAuthService(db, nil, nil, nil, nil, nil)
+	_, err := auth.Signup(context.Background(), dto.SignupRequest{Email: strings.ToUpper(email), Password: "x"})
+	if !errors.Is(err, ErrSignupEmailTaken) {
+		t.Fatalf("se esperaba ErrSignupEmailTaken, se obtuvo %v", err)
+	}
+}
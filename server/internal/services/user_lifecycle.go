@@ -0,0 +1,285 @@
+// server/internal/services/user_lifecycle.go
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"server/internal/dto"
+	"server/internal/models"
+)
+
+// UpdateUser modifica nombre, teléfono, rol u oficina. Cambiar el rol o la
+// oficina exige además user.role.assign o user.office.assign.
+// Si cambia el rol o la oficina se cierran las sesiones del usuario.
+func (s *UserManagementService) UpdateUser(ctx context.Context, userID string, req dto.UpdateUserRequest, meta AuditMeta) (*dto.UserListResponse, error) {
+	s = s.forRequest(ctx)
+	requester, user, err := s.loadManageable(meta.ActorID, userID, models.PermUserUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	changes := map[string]interface{}{}
+	track := func(column string, from, to interface{}) {
+		updates[column] = to
+		changes[column] = map[string]interface{}{"from": from, "to": to}
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if deref(user.Name) != name {
+			track("name", deref(user.Name), name)
+		}
+	}
+	if req.Phone != nil {
+		phone := strings.TrimSpace(*req.Phone)
+		if deref(user.Phone) != phone {
+			track("phone", deref(user.Phone), phone)
+		}
+	}
+	if req.Role != nil && models.Rol(*req.Role) != user.Rol {
+		rol := models.Rol(*req.Role)
+		if err := requirePermission(s.db, requester, models.PermUserRoleAssign); err != nil {
+			return nil, ErrCannotManageUser
+		}
+		if err := assignableRole(s.db, requester, rol); err != nil {
+			return nil, err
+		}
+		track("rol", user.Rol, rol)
+	}
+	if req.Office != nil && deref(officeCode(user.Office)) != normalizeOfficeCode(*req.Office) {
+		office, err := findActiveOffice(s.db, *req.Office)
+		if err != nil {
+			return nil, err
+		}
+		if err := requirePermission(s.db, requester, models.PermUserOfficeAssign); err != nil {
+			return nil, ErrCannotManageUser
+		}
+		updates["office_id"] = office.ID
+		changes["office"] = map[string]interface{}{"from": officeCode(user.Office), "to": office.Code}
+	}
+
+	if len(updates) == 0 {
+		item := buildUserListItem(user)
+		return &item, nil
+	}
+
+	// Updates escribe los valores nuevos en user; el evento necesita el rol previo
+	oldRol := user.Rol
+	err = withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(updates).Error; err != nil {
+			return err
+		}
+		// Las oficinas adicionales no pueden quedar con un rol mayor al nuevo
+		if rol, ok := updates["rol"].(models.Rol); ok {
+			higher := tx.Model(&models.Role{}).Select("name").
+				Where("level > (?)", tx.Model(&models.Role{}).Select("level").Where("name = ?", rol))
+			if err := tx.Model(&models.OfficeMembership{}).
+				Where("user_id = ? AND rol IN (?)", user.ID, higher).
+				Update("rol", rol).Error; err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, meta, AuditUserUpdated, auditEntityUser, user.ID, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rol, ok := updates["rol"].(models.Rol); ok {
+		recordSecurityEvent(s.db, models.SecurityEventRoleChanged, &user.ID, &user.Email, optionalString(meta.IP), map[string]interface{}{
+			"from":      oldRol,
+			"to":        rol,
+			"changedBy": meta.ActorID,
+		})
+	}
+
+	// El rol viaja en el token de sesión; se fuerza un nuevo inicio de sesión
+	_, rolChanged := updates["rol"]
+	_, officeChanged := updates["office_id"]
+	if rolChanged || officeChanged {
+		if err := s.sessions.RevokeAllForUser(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	var updated models.User
+	if err := s.db.Preload("Office").Preload("Memberships.Office").Where("id = ?", user.ID).First(&updated).Error; err != nil {
+		return nil, err
+	}
+	item := buildUserListItem(&updated)
+	return &item, nil
+}
+
+// DeactivateUser bloquea el acceso del usuario y cierra sus sesiones
+func (s *UserManagementService) DeactivateUser(ctx context.Context, userID string, meta AuditMeta) error {
+	s = s.forRequest(ctx)
+	_, user, err := s.loadManageable(meta.ActorID, userID, models.PermUserDeactivate)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return ErrUserAlreadyInactive
+	}
+
+	// Bienes y empleados a cargo se reasignan con el proceso de baja
+	custody, err := s.hasCustody(user)
+	if err != nil {
+		return err
+	}
+	if custody {
+		return ErrUserHasCustody
+	}
+
+	err = withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditUserDeactivated, auditEntityUser, user.ID, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.sessions.RevokeAllForUser(user.ID)
+}
+
+// ReactivateUser devuelve el acceso a un usuario desactivado
+func (s *UserManagementService) ReactivateUser(ctx context.Context, userID string, meta AuditMeta) error {
+	s = s.forRequest(ctx)
+	_, user, err := s.loadManageable(meta.ActorID, userID, models.PermUserDeactivate)
+	if err != nil {
+		return err
+	}
+	if user.IsActive {
+		return ErrUserAlreadyActive
+	}
+
+	return withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("is_active", true).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditUserReactivated, auditEntityUser, user.ID, nil)
+	})
+}
+
+// ResetUserPassword envía al usuario un código de recuperación y cierra sus
+// sesiones. La contraseña actual sigue vigente hasta que la reemplace.
+func (s *UserManagementService) ResetUserPassword(ctx context.Context, userID string, meta AuditMeta) error {
+	s = s.forRequest(ctx)
+	_, user, err := s.loadManageable(meta.ActorID, userID, models.PermUserPasswordReset)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return ErrUserInactiveCannotReset
+	}
+	if user.Password == nil || *user.Password == "" {
+		return ErrUserHasNoLocalPassword
+	}
+
+	if err := s.resets.SendResetCode(user); err != nil {
+		return err
+	}
+	if err := recordAudit(s.db, meta, AuditUserPasswordReset, auditEntityUser, user.ID, nil); err != nil {
+		return err
+	}
+	recordSecurityEvent(s.db, models.SecurityEventResetRequested, &user.ID, &user.Email, optionalString(meta.IP), map[string]interface{}{
+		"source":      "admin",
+		"requestedBy": meta.ActorID,
+	})
+	return s.sessions.RevokeAllForUser(user.ID)
+}
+
+// RequirePasswordChange obliga al usuario a cambiar su contraseña en el
+// próximo inicio de sesión y cierra sus sesiones activas.
+func (s *UserManagementService) RequirePasswordChange(ctx context.Context, userID string, meta AuditMeta) error {
+	s = s.forRequest(ctx)
+	_, user, err := s.loadManageable(meta.ActorID, userID, models.PermUserPasswordReset)
+	if err != nil {
+		return err
+	}
+	if user.Password == nil || *user.Password == "" {
+		return ErrUserHasNoLocalPassword
+	}
+
+	err = withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("must_change_password", true).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, meta, AuditUserUpdated, auditEntityUser, user.ID, map[string]interface{}{
+			"must_change_password": map[string]interface{}{"from": user.MustChangePassword, "to": true},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return s.sessions.RevokeAllForUser(user.ID)
+}
+
+// loadManageable carga al solicitante y al usuario sobre el que actúa, y
+// verifica que pueda ejecutar la acción protegida por permission.
+func (s *UserManagementService) loadManageable(requesterID, userID, permission string) (*models.User, *models.User, error) {
+	var requester models.User
+	if err := s.db.Where("id = ? AND is_active = ?", requesterID, true).First(&requester).Error; err != nil {
+		return nil, nil, errors.New("usuario solicitante no encontrado")
+	}
+	if requesterID == userID {
+		return nil, nil, ErrCannotManageSelf
+	}
+
+	var user models.User
+	if err := s.db.Preload("Office").Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrManagedUserNotFound
+		}
+		return nil, nil, err
+	}
+
+	if err := s.checkManageable(&requester, &user, permission); err != nil {
+		return nil, nil, err
+	}
+	return &requester, &user, nil
+}
+
+// checkManageable exige el permiso de la acción, un rol de nivel mayor que el
+// del usuario y, sin user.manage.all, que el usuario esté en una oficina que
+// el solicitante supervisa.
+func (s *UserManagementService) checkManageable(requester, user *models.User, permission string) error {
+	return checkManageable(s.db, requester, user, permission)
+}
+
+// checkManageable es la verificación de jerarquía compartida por los servicios
+// que actúan sobre otros usuarios
+func checkManageable(db *gorm.DB, requester, user *models.User, permission string) error {
+	if err := requirePermission(db, requester, permission); err != nil {
+		return ErrCannotManageUser
+	}
+	above, err := outranks(db, requester.Rol, user.Rol)
+	if err != nil {
+		return err
+	}
+	if !above {
+		return ErrCannotManageUser
+	}
+
+	all, err := requesterCan(db, requester, models.PermUserManageAll)
+	if err != nil || all {
+		return err
+	}
+	managed, err := managedOfficeIDs(db, requester)
+	if err != nil {
+		return err
+	}
+	member, err := memberOfAny(db, user, managed)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return ErrCannotManageUser
+	}
+	return nil
+}
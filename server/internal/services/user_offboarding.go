@@ -0,0 +1,520 @@
+// server/internal/services/user_offboarding.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"server/internal/dto"
+	"server/internal/models"
+)
+
+const (
+	AuditUserOffboarded = "USER_OFFBOARDED"
+
+	assetRelationRegistered  = "REGISTERED"
+	assetRelationResponsible = "RESPONSIBLE"
+	assetRelationFinal       = "FINAL"
+)
+
+var (
+	ErrUserHasCustody              = errors.New("el usuario tiene bienes o empleados a cargo; usa el proceso de baja para reasignarlos")
+	ErrOffboardingRecipientInvalid = errors.New("el receptor indicado no es válido")
+	ErrOffboardingNotFound         = errors.New("el usuario no tiene actas de entrega")
+)
+
+// OffboardingIncompleteError lista los elementos que quedaron sin receptor
+type OffboardingIncompleteError struct {
+	Assets    []uint   `json:"assets"`
+	Employees []string `json:"employees"`
+}
+
+func (e *OffboardingIncompleteError) Error() string {
+	return "todos los bienes y empleados a cargo deben tener un receptor"
+}
+
+// custodyAsset es un bien del usuario saliente con los vínculos que lo atan a él
+type custodyAsset struct {
+	asset     models.Asset
+	relations []string
+}
+
+// OffboardingPreview muestra lo que el usuario tiene a su cargo
+func (s *UserManagementService) OffboardingPreview(ctx context.Context, userID, requestedByID string) (*dto.OffboardingPreview, error) {
+	s = s.forRequest(ctx)
+	_, user, err := s.loadManageable(requestedByID, userID, models.PermUserOffboard)
+	if err != nil {
+		return nil, err
+	}
+
+	assets, employees, err := s.custody(s.db, user, nil)
+	if err != nil {
+		return nil, err
+	}
+	nameMatches, err := s.nameMatches(s.db, user, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &dto.OffboardingPreview{
+		User:             buildUserListItem(user),
+		Assets:           make([]dto.OffboardingAsset, 0, len(assets)),
+		NameMatches:      make([]dto.OffboardingAsset, 0, len(nameMatches)),
+		ManagedEmployees: make([]dto.UserListResponse, 0, len(employees)),
+	}
+	for _, item := range assets {
+		preview.Assets = append(preview.Assets, offboardingAsset(item))
+	}
+	for _, item := range nameMatches {
+		preview.NameMatches = append(preview.NameMatches, offboardingAsset(item))
+	}
+	for i := range employees {
+		preview.ManagedEmployees = append(preview.ManagedEmployees, buildUserListItem(&employees[i]))
+	}
+	return preview, nil
+}
+
+func offboardingAsset(item custodyAsset) dto.OffboardingAsset {
+	return dto.OffboardingAsset{
+		ID:              item.asset.ID,
+		PatrimonialCode: item.asset.PatrimonialCode,
+		Description:     item.asset.Description,
+		Location:        item.asset.Location,
+		Relations:       item.relations,
+	}
+}
+
+// Offboard reasigna todos los bienes y empleados a cargo, desactiva la cuenta
+// y guarda el acta de entrega, todo en una sola transacción. Si algún
+// elemento queda sin receptor no se modifica nada.
+func (s *UserManagementService) Offboard(ctx context.Context, userID string, req dto.OffboardingRequest, meta AuditMeta) (*dto.OffboardingResponse, error) {
+	s = s.forRequest(ctx)
+	requester, user, err := s.loadManageable(meta.ActorID, userID, models.PermUserOffboard)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrUserAlreadyInactive
+	}
+
+	var record models.OffboardingRecord
+	var assetCount, employeeCount int
+	err = withAudit(s.db, meta).Transaction(func(tx *gorm.DB) error {
+		// Se bloquea la cuenta para que dos bajas simultáneas no se crucen
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND is_active = ?", user.ID, true).First(user).Error; err != nil {
+			return ErrUserAlreadyInactive
+		}
+
+		assets, employees, err := s.custody(tx, user, req.ConfirmedNameMatches)
+		if err != nil {
+			return err
+		}
+		assetCount, employeeCount = len(assets), len(employees)
+
+		assetTargets, employeeTargets, err := resolveOffboardingTargets(req, assets, employees)
+		if err != nil {
+			return err
+		}
+		recipients, err := s.loadRecipients(tx, requester, user, assetTargets, employeeTargets)
+		if err != nil {
+			return err
+		}
+
+		var lines []offboardingLine
+		for _, item := range assets {
+			recipient := recipients[assetTargets[item.asset.ID]]
+			updates := map[string]interface{}{}
+			for _, relation := range item.relations {
+				switch relation {
+				case assetRelationRegistered:
+					updates["registered_by_id"] = recipient.ID
+				case assetRelationResponsible:
+					updates["responsible_employee"] = custodyKey(recipient)
+				case assetRelationFinal:
+					updates["final_employee"] = custodyKey(recipient)
+				}
+			}
+			if err := tx.Model(&models.Asset{}).Where("id = ?", item.asset.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+			lines = append(lines, offboardingLine{
+				kind:      "asset",
+				id:        strconv.FormatUint(uint64(item.asset.ID), 10),
+				label:     fmt.Sprintf("[%s] %s", item.asset.PatrimonialCode, item.asset.Description),
+				detail:    strings.Join(item.relations, ", "),
+				recipient: recipient,
+			})
+		}
+
+		for _, employee := range employees {
+			recipient := recipients[employeeTargets[employee.ID]]
+			if err := tx.Model(&models.User{}).Where("id = ?", employee.ID).
+				Update("created_by_id", recipient.ID).Error; err != nil {
+				return err
+			}
+			lines = append(lines, offboardingLine{
+				kind:      "employee",
+				id:        employee.ID,
+				label:     fmt.Sprintf("%s <%s>", deref(employee.Name), employee.Email),
+				recipient: recipient,
+			})
+		}
+
+		if err := tx.Model(user).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		// Las sesiones se cierran con la baja: si no se pueden cerrar, no hay baja
+		if err := revokeUserSessions(tx, user.ID); err != nil {
+			return err
+		}
+
+		items, err := json.Marshal(offboardingItems(lines))
+		if err != nil {
+			return err
+		}
+		record = models.OffboardingRecord{
+			UserID:        user.ID,
+			PerformedByID: requester.ID,
+			Items:         string(items),
+			Document:      buildOffboardingDocument(user, requester, lines, deref(req.Notes), time.Now()),
+		}
+		if err := tx.Omit("User", "PerformedBy").Create(&record).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(tx, meta, AuditUserOffboarded, auditEntityUser, user.ID, map[string]interface{}{
+			"offboarding_record_id": record.ID,
+			"assets":                assetCount,
+			"employees":             employeeCount,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.OffboardingResponse{
+		ID:                  record.ID,
+		UserID:              user.ID,
+		AssetsHandedOver:    assetCount,
+		EmployeesHandedOver: employeeCount,
+		Document:            record.Document,
+		CreatedAt:           record.CreatedAt.Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// OffboardingDocument devuelve la última acta de entrega del usuario
+func (s *UserManagementService) OffboardingDocument(ctx context.Context, userID, requestedByID string) (*models.OffboardingRecord, error) {
+	s = s.forRequest(ctx)
+	if _, _, err := s.loadManageable(requestedByID, userID, models.PermUserOffboard); err != nil {
+		return nil, err
+	}
+
+	var record models.OffboardingRecord
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").First(&record).Error; err != nil {
+		return nil, ErrOffboardingNotFound
+	}
+	return &record, nil
+}
+
+// hasCustody indica si el usuario aún tiene bienes o empleados a cargo
+func (s *UserManagementService) hasCustody(user *models.User) (bool, error) {
+	assets, employees, err := s.custody(s.db, user, nil)
+	if err != nil {
+		return false, err
+	}
+	return len(assets) > 0 || len(employees) > 0, nil
+}
+
+// custody busca los bienes registrados por el usuario o asignados a su correo
+// y los empleados que creó. Los bienes que solo coinciden por nombre entran
+// únicamente si su ID está en confirmed: dos personas pueden llamarse igual.
+func (s *UserManagementService) custody(db *gorm.DB, user *models.User, confirmed []uint) ([]custodyAsset, []models.User, error) {
+	email := custodyKey(user)
+
+	var assets []models.Asset
+	if err := db.Where("registered_by_id = ? OR LOWER(TRIM(responsible_employee)) = ? OR LOWER(TRIM(final_employee)) = ?",
+		user.ID, email, email).
+		Order("id").Find(&assets).Error; err != nil {
+		return nil, nil, err
+	}
+
+	result := make([]custodyAsset, 0, len(assets))
+	index := make(map[uint]int, len(assets))
+	for _, asset := range assets {
+		item := custodyAsset{asset: asset}
+		if asset.RegisteredByID == user.ID {
+			item.relations = append(item.relations, assetRelationRegistered)
+		}
+		if matchesCustodyKey(asset.ResponsibleEmployee, email) {
+			item.relations = append(item.relations, assetRelationResponsible)
+		}
+		if matchesCustodyKey(asset.FinalEmployee, email) {
+			item.relations = append(item.relations, assetRelationFinal)
+		}
+		index[asset.ID] = len(result)
+		result = append(result, item)
+	}
+
+	if len(confirmed) > 0 {
+		byName, err := s.nameMatches(db, user, confirmed)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, item := range byName {
+			if i, ok := index[item.asset.ID]; ok {
+				result[i].relations = append(result[i].relations, item.relations...)
+				continue
+			}
+			result = append(result, item)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].asset.ID < result[j].asset.ID })
+	}
+
+	var employees []models.User
+	if err := db.Where("created_by_id = ?", user.ID).Order("created_at").Find(&employees).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return result, employees, nil
+}
+
+// nameMatches lista los bienes cuyo responsable o usuario final coincide con
+// el nombre del usuario. No se reasignan solos: un administrador debe
+// confirmarlos. Con ids se limita a esos bienes.
+func (s *UserManagementService) nameMatches(db *gorm.DB, user *models.User, ids []uint) ([]custodyAsset, error) {
+	name := strings.ToLower(strings.TrimSpace(deref(user.Name)))
+	if name == "" {
+		return nil, nil
+	}
+
+	query := db.Where("LOWER(TRIM(responsible_employee)) = ? OR LOWER(TRIM(final_employee)) = ?", name, name)
+	if ids != nil {
+		query = db.Where("id IN ?", ids).Where(query)
+	}
+	var assets []models.Asset
+	if err := query.Order("id").Find(&assets).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]custodyAsset, 0, len(assets))
+	for _, asset := range assets {
+		item := custodyAsset{asset: asset}
+		if matchesCustodyKey(asset.ResponsibleEmployee, name) {
+			item.relations = append(item.relations, assetRelationResponsible)
+		}
+		if matchesCustodyKey(asset.FinalEmployee, name) {
+			item.relations = append(item.relations, assetRelationFinal)
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// custodyKey es el valor que se guarda en responsible_employee y
+// final_employee al entregar un bien: el correo normalizado con el que
+// custody vuelve a encontrarlo
+func custodyKey(user *models.User) string {
+	return strings.ToLower(strings.TrimSpace(user.Email))
+}
+
+// matchesCustodyKey compara un campo de texto libre del bien con un correo o
+// nombre ya en minúsculas
+func matchesCustodyKey(value, key string) bool {
+	return strings.ToLower(strings.TrimSpace(value)) == key
+}
+
+// resolveOffboardingTargets asigna un receptor a cada elemento: primero la
+// excepción por ID y si no el receptor general.
+func resolveOffboardingTargets(req dto.OffboardingRequest, assets []custodyAsset, employees []models.User) (map[uint]string, map[string]string, error) {
+	missing := &OffboardingIncompleteError{Assets: []uint{}, Employees: []string{}}
+
+	assetTargets := make(map[uint]string, len(assets))
+	for _, item := range assets {
+		target := req.Assets[strconv.FormatUint(uint64(item.asset.ID), 10)]
+		if target == "" {
+			target = deref(req.AssetsTo)
+		}
+		if target == "" {
+			missing.Assets = append(missing.Assets, item.asset.ID)
+			continue
+		}
+		assetTargets[item.asset.ID] = target
+	}
+
+	employeeTargets := make(map[string]string, len(employees))
+	for _, employee := range employees {
+		target := req.Employees[employee.ID]
+		if target == "" {
+			target = deref(req.EmployeesTo)
+		}
+		if target == "" {
+			missing.Employees = append(missing.Employees, employee.ID)
+			continue
+		}
+		employeeTargets[employee.ID] = target
+	}
+
+	if len(missing.Assets) > 0 || len(missing.Employees) > 0 {
+		return nil, nil, missing
+	}
+	return assetTargets, employeeTargets, nil
+}
+
+// loadRecipients valida a los receptores: deben estar activos, no pueden ser
+// el usuario saliente y, si quien ejecuta no tiene user.manage.all, deben ser
+// él mismo o usuarios que puede gestionar. Quien recibe empleados debe poder
+// supervisarlos.
+func (s *UserManagementService) loadRecipients(tx *gorm.DB, requester, user *models.User, assetTargets map[uint]string, employeeTargets map[string]string) (map[string]*models.User, error) {
+	ids := map[string]bool{}
+	for _, id := range assetTargets {
+		ids[id] = true
+	}
+	for _, id := range employeeTargets {
+		ids[id] = true
+	}
+	if len(ids) == 0 {
+		return map[string]*models.User{}, nil
+	}
+
+	list := make([]string, 0, len(ids))
+	for id := range ids {
+		list = append(list, id)
+	}
+	var found []models.User
+	if err := tx.Where("id IN ? AND is_active = ?", list, true).Find(&found).Error; err != nil {
+		return nil, err
+	}
+
+	recipients := make(map[string]*models.User, len(found))
+	for i := range found {
+		recipients[found[i].ID] = &found[i]
+	}
+
+	all, err := requesterCan(tx, requester, models.PermUserManageAll)
+	if err != nil {
+		return nil, err
+	}
+	for id := range ids {
+		recipient, ok := recipients[id]
+		if !ok || recipient.ID == user.ID {
+			return nil, fmt.Errorf("%w: %s", ErrOffboardingRecipientInvalid, id)
+		}
+		if !all && recipient.ID != requester.ID {
+			if err := s.checkManageable(requester, recipient, models.PermUserOffboard); err != nil {
+				if errors.Is(err, ErrCannotManageUser) {
+					return nil, fmt.Errorf("%w: %s", ErrOffboardingRecipientInvalid, id)
+				}
+				return nil, err
+			}
+		}
+	}
+	for _, id := range employeeTargets {
+		supervises, err := hasPermission(tx, recipients[id].Rol, models.PermUserManage)
+		if err != nil {
+			return nil, err
+		}
+		all, err := hasPermission(tx, recipients[id].Rol, models.PermUserManageAll)
+		if err != nil {
+			return nil, err
+		}
+		if !supervises && !all {
+			return nil, fmt.Errorf("%w: %s no puede tener empleados a cargo", ErrOffboardingRecipientInvalid, id)
+		}
+	}
+
+	return recipients, nil
+}
+
+// offboardingLine es una fila del acta de entrega
+type offboardingLine struct {
+	kind      string
+	id        string
+	label     string
+	detail    string
+	recipient *models.User
+}
+
+func offboardingItems(lines []offboardingLine) []map[string]string {
+	items := make([]map[string]string, 0, len(lines))
+	for _, line := range lines {
+		items = append(items, map[string]string{
+			"type":        line.kind,
+			"id":          line.id,
+			"description": line.label,
+			"relations":   line.detail,
+			"recipientId": line.recipient.ID,
+		})
+	}
+	return items
+}
+
+// buildOffboardingDocument arma el acta de entrega en texto plano
+func buildOffboardingDocument(user, performedBy *models.User, lines []offboardingLine, notes string, at time.Time) string {
+	var b strings.Builder
+
+	office := "-"
+	if user.Office != nil {
+		office = user.Office.Code
+	}
+
+	fmt.Fprintf(&b, "ACTA DE ENTREGA DE CARGO\n")
+	fmt.Fprintf(&b, "========================\n\n")
+	fmt.Fprintf(&b, "Fecha:            %s\n", at.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "Usuario saliente: %s <%s>\n", displayName(user), user.Email)
+	fmt.Fprintf(&b, "Rol / Oficina:    %s / %s\n", user.Rol, office)
+	fmt.Fprintf(&b, "Ejecutado por:    %s <%s>\n", displayName(performedBy), performedBy.Email)
+
+	sections := []struct {
+		kind  string
+		title string
+	}{
+		{"asset", "BIENES ENTREGADOS"},
+		{"employee", "EMPLEADOS A CARGO REASIGNADOS"},
+	}
+	for _, section := range sections {
+		var rows []offboardingLine
+		for _, line := range lines {
+			if line.kind == section.kind {
+				rows = append(rows, line)
+			}
+		}
+		sort.SliceStable(rows, func(i, j int) bool { return rows[i].recipient.Email < rows[j].recipient.Email })
+
+		fmt.Fprintf(&b, "\n%s (%d)\n", section.title, len(rows))
+		if len(rows) == 0 {
+			fmt.Fprintf(&b, "  Ninguno\n")
+			continue
+		}
+		for _, row := range rows {
+			fmt.Fprintf(&b, "  - %s", row.label)
+			if row.detail != "" {
+				fmt.Fprintf(&b, " (%s)", row.detail)
+			}
+			fmt.Fprintf(&b, "\n      → %s <%s>\n", displayName(row.recipient), row.recipient.Email)
+		}
+	}
+
+	if notes = strings.TrimSpace(notes); notes != "" {
+		fmt.Fprintf(&b, "\nOBSERVACIONES\n  %s\n", notes)
+	}
+
+	fmt.Fprintf(&b, "\nLa cuenta del usuario saliente quedó desactivada y sus sesiones cerradas.\n")
+	return b.String()
+}
+
+// displayName devuelve el nombre del usuario o, si no tiene, su correo
+func displayName(user *models.User) string {
+	if name := strings.TrimSpace(deref(user.Name)); name != "" {
+		return name
+	}
+	return user.Email
+}
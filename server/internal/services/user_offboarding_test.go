@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/dto"
+	"server/internal/models"
+)
+
+func createTestUser(t *testing.T, db *gorm.DB, name, email string, rol models.Rol) *models.User {
+	t.Helper()
+	user := &models.User{Name: &name, Email: email, Rol: rol, IsActive: true}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("creando %s: %v", email, err)
+	}
+	return user
+}
+
+func newTestUserManagement(db *gorm.DB) *UserManagementService {
+	sessions := NewSessionService(db, nil, nil, nil, time.Hour, time.Hour, 0)
+	invitations := NewInvitationService(db, nil, nil, time.Hour, nil)
+	resets := NewPasswordResetService(db, nil, nil, 5, nil, nil, nil)
+	return NewUserManagementService(db, invitations, sessions, resets)
+}
+
+func TestOffboardTransfersCustodyToRecipient(t *testing.T) {
+	db := testDB(t)
+	admin := createTestUser(t, db, "Admin Baja", uniqueEmail(t, "admin"), models.RolAdmin)
+	leaving := createTestUser(t, db, "Saliente", uniqueEmail(t, "saliente"), models.RolEmployee)
+	recipient := createTestUser(t, db, "Receptor", uniqueEmail(t, "receptor"), models.RolEmployee)
+
+	code, err := randomToken(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	asset := models.Asset{
+		OldLabel:            "old-" + code,
+		PatrimonialCode:     "pc-" + code,
+		Description:         "Laptop",
+		RegisteredByID:      admin.ID,
+		ResponsibleEmployee: " " + leaving.Email + " ",
+		FinalEmployee:       leaving.Email,
+	}
+	if err := db.Create(&asset).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Session{SessionToken: "offboard-" + code, UserID: leaving.ID, Expires: time.Now().Add(time.Hour)}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	service := newTestUserManagement(db)
+	ctx := context.Background()
+	_, err = service.Offboard(ctx, leaving.ID, dto.OffboardingRequest{AssetsTo: &recipient.ID}, AuditMeta{ActorID: admin.ID})
+	if err != nil {
+		t.Fatalf("Offboard: %v", err)
+	}
+
+	// Lo entregado debe aparecer en la custodia del receptor
+	assets, _, err := service.custody(db, recipient, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 1 || assets[0].asset.ID != asset.ID || len(assets[0].relations) != 2 {
+		t.Fatalf("la custodia del receptor no incluye el bien entregado: %+v", assets)
+	}
+
+	var sessions int64
+	db.Model(&models.Session{}).Where("user_id = ?", leaving.ID).Count(&sessions)
+	if sessions != 0 {
+		t.Fatalf("las sesiones del usuario saliente deben cerrarse con la baja, quedan %d", sessions)
+	}
+}
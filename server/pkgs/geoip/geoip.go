@@ -0,0 +1,155 @@
+// server/pkgs/geoip/geoip.go
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Config indica la base MaxMind (GeoLite2/GeoIP2 City o Country, formato
+// MMDB) con la que se resuelven las IP. Sin DBPath la geolocalización queda
+// desactivada. Language elige el idioma de los nombres; si la base no lo
+// tiene se usa inglés.
+type Config struct {
+	DBPath   string
+	Language string
+}
+
+// Location es el resultado de resolver una IP. Internal marca direcciones
+// privadas, de loopback o link-local, que no tienen ubicación geográfica.
+type Location struct {
+	City        string
+	Region      string
+	Country     string
+	CountryCode string
+	Internal    bool
+}
+
+// String devuelve la ubicación legible: "Ciudad, Región, País"
+func (l Location) String() string {
+	if l.Internal {
+		return "Red interna"
+	}
+	parts := make([]string, 0, 3)
+	for _, p := range []string{l.City, l.Region, l.Country} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Resolver consulta la base MMDB cargada en memoria; no requiere red
+type Resolver struct {
+	reader   *maxminddb.Reader
+	language string
+}
+
+// cgnatRange es el espacio compartido de NAT de operador (RFC 6598)
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Geo es el resolvedor compartido; nil si no hay base configurada
+var Geo *Resolver
+
+// InitGeoIP abre la base configurada y la deja disponible en Geo. Sin ruta
+// no hace nada: Lookup sobre un Resolver nil no resuelve ninguna IP.
+func InitGeoIP(cfg Config) error {
+	if strings.TrimSpace(cfg.DBPath) == "" {
+		return nil
+	}
+	r, err := Open(cfg)
+	if err != nil {
+		return err
+	}
+	Geo = r
+	return nil
+}
+
+func Open(cfg Config) (*Resolver, error) {
+	reader, err := maxminddb.Open(cfg.DBPath)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir la base GeoIP %s: %w", cfg.DBPath, err)
+	}
+	language := cfg.Language
+	if language == "" {
+		language = "en"
+	}
+	return &Resolver{reader: reader, language: language}, nil
+}
+
+// Close libera la base
+func (r *Resolver) Close() error {
+	if r == nil {
+		return nil
+	}
+	return r.reader.Close()
+}
+
+// record son los campos de GeoLite2/GeoIP2 City que se usan; una base
+// Country simplemente deja vacíos ciudad y región
+type record struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	Country struct {
+		IsoCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+}
+
+// Lookup resuelve la IP. Devuelve false si la IP es inválida, no está en la
+// base o no hay base cargada. Las direcciones internas se resuelven sin
+// consultar la base.
+func (r *Resolver) Lookup(ip string) (Location, bool) {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return Location{}, false
+	}
+	if isInternal(parsed) {
+		return Location{Internal: true}, true
+	}
+	if r == nil {
+		return Location{}, false
+	}
+
+	var rec record
+	_, found, err := r.reader.LookupNetwork(parsed, &rec)
+	if err != nil || !found {
+		return Location{}, false
+	}
+
+	loc := Location{
+		City:        r.name(rec.City.Names),
+		Country:     r.name(rec.Country.Names),
+		CountryCode: rec.Country.IsoCode,
+	}
+	if len(rec.Subdivisions) > 0 {
+		loc.Region = r.name(rec.Subdivisions[0].Names)
+	}
+	if loc.String() == "" {
+		return Location{}, false
+	}
+	return loc, true
+}
+
+func (r *Resolver) name(names map[string]string) string {
+	if n, ok := names[r.language]; ok {
+		return n
+	}
+	return names["en"]
+}
+
+// isInternal detecta rangos sin ubicación pública (RFC 1918, ULA, loopback,
+// link-local, CGNAT y no especificada)
+func isInternal(ip net.IP) bool {
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return true
+	}
+	return cgnatRange.Contains(ip)
+}
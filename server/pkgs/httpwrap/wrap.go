@@ -1,11 +1,34 @@
 package httpwrap
 
-import "github.com/gofiber/fiber/v3"
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// DataError es un error HTTP que incluye detalles estructurados en "data"
+type DataError struct {
+	Code    int
+	Message string
+	Data    interface{}
+}
+
+func (e *DataError) Error() string {
+	return e.Message
+}
 
 func Wrap(fn func(fiber.Ctx) (interface{}, string, error)) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		data, msg, err := fn(c)
 		if err != nil {
+			var de *DataError
+			if errors.As(err, &de) {
+				return c.Status(de.Code).JSON(fiber.Map{
+					"status":  de.Code,
+					"message": de.Message,
+					"data":    de.Data,
+				})
+			}
 			if fe, ok := err.(*fiber.Error); ok {
 				return c.Status(fe.Code).JSON(fiber.Map{
 					"status":  fe.Code,
@@ -0,0 +1,128 @@
+// server/pkgs/imaging/imaging.go
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/png"
+	"net/http"
+
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+var (
+	ErrUnsupportedType = errors.New("formato de imagen no soportado (usa JPEG, PNG o GIF)")
+	ErrTooLarge        = errors.New("la imagen excede las dimensiones permitidas")
+	ErrInvalidImage    = errors.New("el archivo no es una imagen válida")
+)
+
+// allowedTypes se detectan por contenido, no por la extensión ni el encabezado del cliente
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Decode valida el tipo real del archivo y sus dimensiones antes de
+// decodificarlo, para no reservar memoria con imágenes gigantes.
+func Decode(data []byte, maxSide int) (image.Image, error) {
+	if !allowedTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxSide || cfg.Height > maxSide {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	return img, nil
+}
+
+// Square recorta el centro de la imagen y la reduce a size×size promediando
+// los píxeles de origen que caen en cada píxel de destino.
+func Square(src image.Image, size int) *image.NRGBA {
+	bounds := src.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	// Copia a NRGBA para leer los canales directamente
+	square := image.NewNRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), src, crop.Min, draw.Src)
+
+	if side <= size {
+		if side == size {
+			return square
+		}
+		return upscale(square, size)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := y*side/size, (y+1)*side/size
+		for x := 0; x < size; x++ {
+			x0, x1 := x*side/size, (x+1)*side/size
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := square.Pix[sy*square.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					alpha := uint64(p[3])
+					r += uint64(p[0]) * alpha
+					g += uint64(p[1]) * alpha
+					b += uint64(p[2]) * alpha
+					a += alpha
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			if a > 0 {
+				dst.Pix[i] = uint8(r / a)
+				dst.Pix[i+1] = uint8(g / a)
+				dst.Pix[i+2] = uint8(b / a)
+			}
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// upscale amplía por vecino más cercano (imágenes más chicas que el tamaño pedido)
+func upscale(src *image.NRGBA, size int) *image.NRGBA {
+	side := src.Bounds().Dx()
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		sy := y * side / size
+		for x := 0; x < size; x++ {
+			sx := x * side / size
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], src.Pix[sy*src.Stride+sx*4:sy*src.Stride+sx*4+4])
+		}
+	}
+	return dst
+}
+
+// EncodePNG serializa la imagen en PNG
+func EncodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,208 @@
+// server/pkgs/ldapauth/client.go
+package ldapauth
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+var (
+	ErrUserNotFound       = errors.New("usuario no encontrado en el directorio")
+	ErrInvalidCredentials = errors.New("credenciales inválidas en el directorio")
+	ErrUnavailable        = errors.New("el directorio LDAP no está disponible")
+)
+
+// Config contiene los datos de conexión y búsqueda del directorio
+type Config struct {
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	BindDN             string
+	BindPassword       string
+	BaseDN             string
+	// UserFilter usa %s para el identificador ingresado (ya escapado)
+	UserFilter string
+	AttrID     string
+	AttrEmail  string
+	AttrName   string
+	AttrPhone  string
+	AttrGroups string
+	Timeout    time.Duration
+}
+
+// Entry es el resultado de una autenticación exitosa
+type Entry struct {
+	DN     string
+	ID     string
+	Email  string
+	Name   string
+	Phone  string
+	Groups []string
+}
+
+// Directory es la abstracción usada por el backend de autenticación
+type Directory interface {
+	Authenticate(username, password string) (*Entry, error)
+	// Lookup busca al usuario con la cuenta de servicio, sin su contraseña
+	Lookup(username string) (*Entry, error)
+}
+
+type Client struct {
+	cfg Config
+}
+
+func NewClient(cfg Config) *Client {
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(&(objectClass=user)(|(mail=%s)(userPrincipalName=%s)))"
+	}
+	if cfg.AttrID == "" {
+		cfg.AttrID = "objectGUID"
+	}
+	if cfg.AttrEmail == "" {
+		cfg.AttrEmail = "mail"
+	}
+	if cfg.AttrName == "" {
+		cfg.AttrName = "displayName"
+	}
+	if cfg.AttrPhone == "" {
+		cfg.AttrPhone = "telephoneNumber"
+	}
+	if cfg.AttrGroups == "" {
+		cfg.AttrGroups = "memberOf"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &Client{cfg: cfg}
+}
+
+// Authenticate busca al usuario con la cuenta de servicio y luego
+// verifica su contraseña haciendo bind con su propio DN.
+func (c *Client) Authenticate(username, password string) (*Entry, error) {
+	// Un bind con contraseña vacía es "no autenticado" y siempre tiene éxito
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := c.find(conn, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: bind de usuario: %v", ErrUnavailable, err)
+	}
+
+	return c.toEntry(entry), nil
+}
+
+// Lookup devuelve la entrada del usuario sin verificar su contraseña. Se usa
+// para vincular cuentas locales existentes de forma explícita.
+func (c *Client) Lookup(username string) (*Entry, error) {
+	if username == "" {
+		return nil, ErrUserNotFound
+	}
+
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := c.find(conn, username)
+	if err != nil {
+		return nil, err
+	}
+	return c.toEntry(entry), nil
+}
+
+// find hace bind con la cuenta de servicio y busca una única entrada
+func (c *Client) find(conn *ldap.Conn, username string) (*ldap.Entry, error) {
+	if c.cfg.BindDN != "" {
+		if err := conn.Bind(c.cfg.BindDN, c.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("%w: bind de servicio: %v", ErrUnavailable, err)
+		}
+	}
+
+	escaped := ldap.EscapeFilter(username)
+	filter := strings.ReplaceAll(c.cfg.UserFilter, "%s", escaped)
+
+	search := ldap.NewSearchRequest(
+		c.cfg.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(c.cfg.Timeout.Seconds()), false,
+		filter,
+		[]string{c.cfg.AttrID, c.cfg.AttrEmail, c.cfg.AttrName, c.cfg.AttrPhone, c.cfg.AttrGroups},
+		nil,
+	)
+
+	result, err := conn.Search(search)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%w: búsqueda: %v", ErrUnavailable, err)
+	}
+	if len(result.Entries) != 1 {
+		return nil, ErrUserNotFound
+	}
+	return result.Entries[0], nil
+}
+
+func (c *Client) toEntry(entry *ldap.Entry) *Entry {
+	return &Entry{
+		DN:     entry.DN,
+		ID:     attributeID(entry, c.cfg.AttrID),
+		Email:  entry.GetAttributeValue(c.cfg.AttrEmail),
+		Name:   entry.GetAttributeValue(c.cfg.AttrName),
+		Phone:  entry.GetAttributeValue(c.cfg.AttrPhone),
+		Groups: entry.GetAttributeValues(c.cfg.AttrGroups),
+	}
+}
+
+func (c *Client) connect() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.cfg.InsecureSkipVerify}
+
+	conn, err := ldap.DialURL(c.cfg.URL, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	conn.SetTimeout(c.cfg.Timeout)
+
+	if c.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: StartTLS: %v", ErrUnavailable, err)
+		}
+	}
+
+	return conn, nil
+}
+
+// attributeID devuelve el identificador estable; objectGUID es binario y se codifica en hex
+func attributeID(entry *ldap.Entry, attr string) string {
+	raw := entry.GetRawAttributeValue(attr)
+	if len(raw) == 0 {
+		return entry.DN
+	}
+	if utf8.Valid(raw) && !strings.EqualFold(attr, "objectGUID") {
+		return string(raw)
+	}
+	return hex.EncodeToString(raw)
+}
@@ -0,0 +1,134 @@
+package ldapauth
+
+import (
+	"errors"
+	"testing"
+
+	"server/pkgs/ldapauth/ldaptest"
+)
+
+const (
+	testBaseDN      = "ou=personal,dc=example,dc=gob,dc=pe"
+	testBindDN      = "cn=svc-inventario,ou=servicios,dc=example,dc=gob,dc=pe"
+	testBindPass    = "svc-secret"
+	testUserDN      = "cn=Ana Torres,ou=personal,dc=example,dc=gob,dc=pe"
+	testUserPass    = "ana-secret"
+	testUserEmail   = "ana@example.gob.pe"
+	testAdminsGroup = "CN=Inventario-Admins,OU=Grupos,DC=example,DC=gob,DC=pe"
+)
+
+func newDirectory(t *testing.T, entries ...ldaptest.Entry) (*ldaptest.Server, *Client) {
+	t.Helper()
+	if len(entries) == 0 {
+		entries = []ldaptest.Entry{{
+			DN:       testUserDN,
+			Password: testUserPass,
+			Attributes: map[string][]string{
+				"objectClass":     {"user"},
+				"mail":            {testUserEmail},
+				"objectGUID":      {"\x01\x02\x03\x04"},
+				"displayName":     {"Ana Torres"},
+				"telephoneNumber": {"999888777"},
+				"memberOf":        {testAdminsGroup},
+			},
+		}}
+	}
+	server, err := ldaptest.NewServer(testBindDN, testBindPass, entries...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+
+	client := NewClient(Config{
+		URL:          server.URL,
+		BindDN:       testBindDN,
+		BindPassword: testBindPass,
+		BaseDN:       testBaseDN,
+	})
+	return server, client
+}
+
+func TestAuthenticateReturnsDirectoryEntry(t *testing.T) {
+	server, client := newDirectory(t)
+
+	entry, err := client.Authenticate(testUserEmail, testUserPass)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if entry.DN != testUserDN || entry.Email != testUserEmail || entry.Name != "Ana Torres" || entry.Phone != "999888777" {
+		t.Fatalf("entrada inesperada: %+v", entry)
+	}
+	if entry.ID != "01020304" {
+		t.Fatalf("objectGUID debe codificarse en hex, se obtuvo %q", entry.ID)
+	}
+	if len(entry.Groups) != 1 || entry.Groups[0] != testAdminsGroup {
+		t.Fatalf("grupos inesperados: %v", entry.Groups)
+	}
+
+	binds := server.Binds()
+	if len(binds) != 2 || binds[0] != testBindDN || binds[1] != testUserDN {
+		t.Fatalf("se esperaba bind de servicio y luego del usuario, se obtuvo %v", binds)
+	}
+}
+
+func TestAuthenticateFailures(t *testing.T) {
+	_, client := newDirectory(t)
+
+	cases := []struct {
+		name     string
+		user     string
+		password string
+		want     error
+	}{
+		{"contraseña incorrecta", testUserEmail, "otra", ErrInvalidCredentials},
+		{"contraseña vacía", testUserEmail, "", ErrInvalidCredentials},
+		{"usuario inexistente", "nadie@example.gob.pe", "x", ErrUserNotFound},
+		{"filtro inyectado", "*)(mail=*", "x", ErrUserNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := client.Authenticate(tc.user, tc.password); !errors.Is(err, tc.want) {
+				t.Fatalf("se esperaba %v, se obtuvo %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestAuthenticateServiceBindFailure(t *testing.T) {
+	server, _ := newDirectory(t)
+	client := NewClient(Config{URL: server.URL, BindDN: testBindDN, BindPassword: "mala", BaseDN: testBaseDN})
+
+	if _, err := client.Authenticate(testUserEmail, testUserPass); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("se esperaba ErrUnavailable, se obtuvo %v", err)
+	}
+}
+
+func TestLookupDoesNotBindAsUser(t *testing.T) {
+	server, client := newDirectory(t)
+
+	entry, err := client.Lookup(testUserEmail)
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if entry.DN != testUserDN {
+		t.Fatalf("DN inesperado: %s", entry.DN)
+	}
+	if binds := server.Binds(); len(binds) != 1 || binds[0] != testBindDN {
+		t.Fatalf("Lookup solo debe usar la cuenta de servicio, binds: %v", binds)
+	}
+}
+
+func TestAmbiguousSearchIsNotFound(t *testing.T) {
+	twin := func(dn string) ldaptest.Entry {
+		return ldaptest.Entry{DN: dn, Password: "x", Attributes: map[string][]string{
+			"objectClass": {"user"},
+			"mail":        {testUserEmail},
+		}}
+	}
+	_, client := newDirectory(t,
+		twin("cn=a,"+testBaseDN), twin("cn=b,"+testBaseDN), twin("cn=c,"+testBaseDN))
+
+	if _, err := client.Authenticate(testUserEmail, "x"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("se esperaba ErrUserNotFound, se obtuvo %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+// server/pkgs/ldapauth/ldaptest/server.go
+package ldaptest
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Entry es un objeto del directorio simulado. Password vacío impide el bind.
+type Entry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// Server es un directorio LDAP en memoria para pruebas: atiende bind simple,
+// búsqueda con filtros de igualdad, and/or/not y presencia, y unbind
+type Server struct {
+	URL string
+
+	listener     net.Listener
+	bindDN       string
+	bindPassword string
+	entries      []Entry
+
+	mu    sync.Mutex
+	binds []string
+	wg    sync.WaitGroup
+}
+
+// NewServer levanta el directorio en 127.0.0.1 con una cuenta de servicio
+// (bindDN/bindPassword) y las entradas indicadas
+func NewServer(bindDN, bindPassword string, entries ...Entry) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		URL:          "ldap://" + listener.Addr().String(),
+		listener:     listener,
+		bindDN:       bindDN,
+		bindPassword: bindPassword,
+		entries:      entries,
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Close detiene el servidor y espera a que terminen las conexiones
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.wg.Wait()
+}
+
+// Binds devuelve los DN que hicieron bind con éxito, en orden
+func (s *Server) Binds() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.binds...)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id := packet.Children[0].Value
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			code := s.bind(op)
+			if _, err := conn.Write(response(id, ldap.ApplicationBindResponse, code).Bytes()); err != nil {
+				return
+			}
+		case ldap.ApplicationSearchRequest:
+			for _, out := range s.search(id, op) {
+				if _, err := conn.Write(out.Bytes()); err != nil {
+					return
+				}
+			}
+		case ldap.ApplicationUnbindRequest:
+			return
+		default:
+			if _, err := conn.Write(response(id, ldap.ApplicationExtendedResponse, ldap.LDAPResultUnwillingToPerform).Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) bind(op *ber.Packet) uint16 {
+	if len(op.Children) < 3 {
+		return ldap.LDAPResultProtocolError
+	}
+	dn := op.Children[1].Data.String()
+	password := op.Children[2].Data.String()
+
+	ok := dn == s.bindDN && password == s.bindPassword
+	for _, entry := range s.entries {
+		if strings.EqualFold(entry.DN, dn) && entry.Password != "" && entry.Password == password {
+			ok = true
+		}
+	}
+	if !ok || password == "" {
+		return ldap.LDAPResultInvalidCredentials
+	}
+
+	s.mu.Lock()
+	s.binds = append(s.binds, dn)
+	s.mu.Unlock()
+	return ldap.LDAPResultSuccess
+}
+
+func (s *Server) search(id interface{}, op *ber.Packet) []*ber.Packet {
+	if len(op.Children) < 8 {
+		return []*ber.Packet{response(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError)}
+	}
+	base := strings.ToLower(op.Children[0].Data.String())
+	sizeLimit, _ := op.Children[3].Value.(int64)
+	filter := op.Children[6]
+
+	var out []*ber.Packet
+	for _, entry := range s.entries {
+		if !strings.HasSuffix(strings.ToLower(entry.DN), base) || !matches(entry, filter) {
+			continue
+		}
+		if sizeLimit > 0 && int64(len(out)) >= sizeLimit {
+			return append(out, response(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSizeLimitExceeded))
+		}
+		out = append(out, searchEntry(id, entry))
+	}
+	return append(out, response(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+}
+
+func matches(entry Entry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matches(entry, child) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if matches(entry, child) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return len(filter.Children) == 1 && !matches(entry, filter.Children[0])
+	case ldap.FilterPresent:
+		return len(values(entry, filter.Data.String())) > 0
+	case ldap.FilterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		want := filter.Children[1].Data.String()
+		for _, value := range values(entry, filter.Children[0].Data.String()) {
+			if strings.EqualFold(value, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func values(entry Entry, attr string) []string {
+	for name, vals := range entry.Attributes {
+		if strings.EqualFold(name, attr) {
+			return vals
+		}
+	}
+	return nil
+}
+
+func envelope(id interface{}) *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+	return packet
+}
+
+func response(id interface{}, tag ber.Tag, code uint16) *ber.Packet {
+	packet := envelope(id)
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	packet.AppendChild(result)
+	return packet
+}
+
+func searchEntry(id interface{}, entry Entry) *ber.Packet {
+	packet := envelope(id)
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "objectName"))
+
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, vals := range entry.Attributes {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, value := range vals {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+		}
+		attr.AppendChild(set)
+		attrs.AppendChild(attr)
+	}
+	result.AppendChild(attrs)
+	packet.AppendChild(result)
+	return packet
+}
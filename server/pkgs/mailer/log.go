@@ -0,0 +1,63 @@
+// server/pkgs/mailer/log.go
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+
+	"server/pkgs/logger"
+)
+
+// LogTransport guarda cada correo como archivo .eml (para desarrollo)
+type LogTransport struct {
+	dir  string
+	from mail.Address
+}
+
+func NewLogTransport(dir, fromAddress, fromName string) *LogTransport {
+	if dir == "" {
+		dir = filepath.Join("logs", "mail")
+	}
+	return &LogTransport{dir: dir, from: mail.Address{Name: fromName, Address: fromAddress}}
+}
+
+func (t *LogTransport) Send(_ context.Context, msg *Message) error {
+	raw, err := buildMIME(t.from, msg)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(t.dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	name := fmt.Sprintf("%s_%s_%s.eml", time.Now().Format("20060102-150405.000"), hex.EncodeToString(suffix), sanitizeFileName(msg.To[0]))
+	path := filepath.Join(t.dir, name)
+	if err := os.WriteFile(path, raw, 0600); err != nil {
+		return err
+	}
+
+	logger.Log.Infof("📨 [mail:log] %s → %v guardado en %s", msg.Subject, msg.To, path)
+	return nil
+}
+
+func sanitizeFileName(s string) string {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			out = append(out, r)
+		default:
+			out = append(out, '_')
+		}
+	}
+	return string(out)
+}
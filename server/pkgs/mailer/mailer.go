@@ -0,0 +1,126 @@
+// server/pkgs/mailer/mailer.go
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrQueueFull      = errors.New("la cola de correos está llena")
+	ErrQueueClosed    = errors.New("la cola de correos está cerrada")
+	ErrNoRecipients   = errors.New("el correo no tiene destinatarios")
+	ErrUnknownDriver  = errors.New("transporte de correo desconocido")
+	ErrMailerDisabled = errors.New("el servicio de correo no está inicializado")
+)
+
+// Message es un correo listo para enviarse
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Transport entrega un mensaje (SMTP, archivo/log, ...)
+type Transport interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Config agrupa la configuración del subsistema de correo
+type Config struct {
+	// Driver: "smtp" o "log"
+	Driver      string
+	FromAddress string
+	FromName    string
+	AppName     string
+	AppBaseURL  string
+
+	SMTP SMTPConfig
+
+	LogDir string
+
+	QueueSize   int
+	Workers     int
+	MaxAttempts int
+	RetryDelay  time.Duration
+}
+
+// Mailer renderiza plantillas y encola los mensajes
+type Mailer struct {
+	queue    *Queue
+	renderer *Renderer
+	cfg      Config
+}
+
+var Mail *Mailer
+
+// InitMailer crea el transporte, la cola y arranca los workers
+func InitMailer(cfg Config) error {
+	m, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	m.queue.Start()
+	Mail = m
+	return nil
+}
+
+func New(cfg Config) (*Mailer, error) {
+	var transport Transport
+	switch cfg.Driver {
+	case "smtp":
+		transport = NewSMTPTransport(cfg.SMTP, cfg.FromAddress, cfg.FromName)
+	case "log", "":
+		transport = NewLogTransport(cfg.LogDir, cfg.FromAddress, cfg.FromName)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, cfg.Driver)
+	}
+
+	renderer, err := NewRenderer()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Mailer{
+		queue:    NewQueue(transport, cfg.QueueSize, cfg.Workers, cfg.MaxAttempts, cfg.RetryDelay),
+		renderer: renderer,
+		cfg:      cfg,
+	}, nil
+}
+
+// SendTemplate renderiza la plantilla indicada y encola el correo.
+// AppName, AppBaseURL, SupportEmail y Year se agregan a los datos.
+func (m *Mailer) SendTemplate(to string, template string, data map[string]interface{}) error {
+	if m == nil {
+		return ErrMailerDisabled
+	}
+	if to == "" {
+		return ErrNoRecipients
+	}
+
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	data["AppName"] = m.cfg.AppName
+	data["AppBaseURL"] = m.cfg.AppBaseURL
+	data["SupportEmail"] = m.cfg.FromAddress
+	data["Year"] = time.Now().Year()
+
+	msg, err := m.renderer.Render(template, data)
+	if err != nil {
+		return err
+	}
+	msg.To = []string{to}
+
+	return m.queue.Enqueue(msg)
+}
+
+// Stop espera a que la cola se vacíe o expire el contexto
+func (m *Mailer) Stop(ctx context.Context) {
+	if m != nil {
+		m.queue.Stop(ctx)
+	}
+}
@@ -0,0 +1,145 @@
+// server/pkgs/mailer/queue.go
+package mailer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"server/pkgs/logger"
+)
+
+type job struct {
+	msg      *Message
+	attempts int
+}
+
+// Queue entrega los mensajes en segundo plano con reintentos y backoff exponencial
+type Queue struct {
+	transport   Transport
+	jobs        chan *job
+	workers     int
+	maxAttempts int
+	retryDelay  time.Duration
+
+	mu      sync.RWMutex
+	closed  bool
+	wg      sync.WaitGroup
+	pending sync.WaitGroup
+}
+
+func NewQueue(transport Transport, size, workers, maxAttempts int, retryDelay time.Duration) *Queue {
+	if size <= 0 {
+		size = 100
+	}
+	if workers <= 0 {
+		workers = 2
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	if retryDelay <= 0 {
+		retryDelay = 5 * time.Second
+	}
+	return &Queue{
+		transport:   transport,
+		jobs:        make(chan *job, size),
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		retryDelay:  retryDelay,
+	}
+}
+
+func (q *Queue) Start() {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+}
+
+// Enqueue agrega el mensaje sin bloquear; falla si la cola está llena
+func (q *Queue) Enqueue(msg *Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	q.pending.Add(1)
+	if err := q.push(&job{msg: msg}); err != nil {
+		q.pending.Done()
+		return err
+	}
+	return nil
+}
+
+func (q *Queue) push(j *job) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	select {
+	case q.jobs <- j:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	for j := range q.jobs {
+		q.deliver(j)
+	}
+}
+
+func (q *Queue) deliver(j *job) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err := q.transport.Send(ctx, j.msg)
+	cancel()
+
+	if err == nil {
+		logger.Log.Infof("📧 Correo enviado a %v: %s", j.msg.To, j.msg.Subject)
+		q.pending.Done()
+		return
+	}
+
+	j.attempts++
+	if j.attempts >= q.maxAttempts {
+		logger.Log.Errorf("❌ Correo a %v descartado tras %d intentos: %v", j.msg.To, j.attempts, err)
+		q.pending.Done()
+		return
+	}
+
+	delay := q.retryDelay * time.Duration(1<<uint(j.attempts-1))
+	logger.Log.Warnf("⚠️ Error enviando correo a %v (intento %d), reintento en %s: %v", j.msg.To, j.attempts, delay, err)
+
+	time.AfterFunc(delay, func() {
+		if err := q.push(j); err != nil {
+			logger.Log.Errorf("❌ No se pudo reencolar el correo a %v: %v", j.msg.To, err)
+			q.pending.Done()
+		}
+	})
+}
+
+// Stop deja de aceptar mensajes y espera las entregas pendientes
+func (q *Queue) Stop(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		q.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.Log.Warn("⚠️ Se cerró la cola de correos con mensajes pendientes")
+	}
+
+	q.mu.Lock()
+	q.closed = true
+	close(q.jobs)
+	q.mu.Unlock()
+
+	q.wg.Wait()
+}
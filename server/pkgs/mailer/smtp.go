@@ -0,0 +1,101 @@
+// server/pkgs/mailer/smtp.go
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTPConfig define el servidor de salida. TLSMode: "starttls", "tls" o "none"
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	TLSMode  string
+}
+
+type SMTPTransport struct {
+	cfg  SMTPConfig
+	from mail.Address
+}
+
+func NewSMTPTransport(cfg SMTPConfig, fromAddress, fromName string) *SMTPTransport {
+	if cfg.TLSMode == "" {
+		cfg.TLSMode = "starttls"
+	}
+	return &SMTPTransport{cfg: cfg, from: mail.Address{Name: fromName, Address: fromAddress}}
+}
+
+func (t *SMTPTransport) Send(ctx context.Context, msg *Message) error {
+	raw, err := buildMIME(t.from, msg)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(t.cfg.Host, t.cfg.Port)
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	tlsConfig := &tls.Config{ServerName: t.cfg.Host}
+
+	var conn net.Conn
+	if t.cfg.TLSMode == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, t.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if t.cfg.TLSMode == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("el servidor SMTP no soporta STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if t.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.cfg.Username, t.cfg.Password, t.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(t.from.Address); err != nil {
+		return err
+	}
+	for _, rcpt := range msg.To {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,281 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"server/pkgs/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = logrus.New()
+	logger.Log.SetOutput(io.Discard)
+	m.Run()
+}
+
+// received es un correo aceptado por el servidor SMTP de prueba
+type received struct {
+	from string
+	to   []string
+	auth string
+	data string
+}
+
+// fakeSMTP es un servidor SMTP mínimo en 127.0.0.1. Las primeras failFirst
+// transacciones se rechazan en DATA con 451 para probar los reintentos.
+type fakeSMTP struct {
+	listener net.Listener
+
+	mu        sync.Mutex
+	failFirst int
+	messages  []received
+	delivered chan struct{}
+}
+
+func newFakeSMTP(t *testing.T, failFirst int) *fakeSMTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{listener: listener, failFirst: failFirst, delivered: make(chan struct{}, 100)}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeSMTP) config() SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return SMTPConfig{Host: host, Port: port, Username: "inventario", Password: "smtp-secret", TLSMode: "none"}
+}
+
+func (s *fakeSMTP) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTP) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	var msg received
+	reply("220 localhost ESMTP prueba")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(cmd, "AUTH PLAIN "):
+			raw, _ := base64.StdEncoding.DecodeString(line[len("AUTH PLAIN "):])
+			msg.auth = string(raw)
+			reply("235 2.7.0 autenticado")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			msg = received{auth: msg.auth, from: strings.Trim(line[len("MAIL FROM:"):], "<> ")}
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 fin con <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.data = data.String()
+
+			s.mu.Lock()
+			if s.failFirst > 0 {
+				s.failFirst--
+				s.mu.Unlock()
+				reply("451 4.3.0 intente más tarde")
+				continue
+			}
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 OK en cola")
+			s.delivered <- struct{}{}
+		case cmd == "QUIT":
+			reply("221 adiós")
+			return
+		default:
+			reply("502 no implementado")
+		}
+	}
+}
+
+func (s *fakeSMTP) received() []received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]received(nil), s.messages...)
+}
+
+func (s *fakeSMTP) waitDelivered(t *testing.T, n int) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < n; i++ {
+		select {
+		case <-s.delivered:
+		case <-timeout:
+			t.Fatalf("se esperaban %d correos, llegaron %d", n, len(s.received()))
+		}
+	}
+}
+
+func TestSMTPTransportDeliversMultipartMessage(t *testing.T) {
+	server := newFakeSMTP(t, 0)
+	transport := NewSMTPTransport(server.config(), "soporte@example.gob.pe", "Soporte Inventario")
+
+	err := transport.Send(context.Background(), &Message{
+		To:      []string{"ana@example.gob.pe"},
+		Subject: "Recuperación de contraseña",
+		Text:    "Tu código es 123456\n",
+		HTML:    "<p>Tu código es <b>123456</b></p>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	msgs := server.received()
+	if len(msgs) != 1 {
+		t.Fatalf("se esperaba un correo, llegaron %d", len(msgs))
+	}
+	got := msgs[0]
+	if got.from != "soporte@example.gob.pe" || len(got.to) != 1 || got.to[0] != "ana@example.gob.pe" {
+		t.Fatalf("sobre inesperado: from=%s to=%v", got.from, got.to)
+	}
+	if got.auth != "\x00inventario\x00smtp-secret" {
+		t.Fatalf("credenciales AUTH PLAIN inesperadas: %q", got.auth)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("el mensaje no es RFC 5322 válido: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "Recuperación de contraseña" {
+		t.Fatalf("asunto inesperado: %q (%v)", subject, err)
+	}
+	contentType := parsed.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "multipart/alternative") {
+		t.Fatalf("Content-Type inesperado: %s", contentType)
+	}
+	if !strings.Contains(got.data, "text/plain") || !strings.Contains(got.data, "text/html") {
+		t.Fatal("faltan las partes de texto y HTML")
+	}
+}
+
+func TestSMTPTransportRequiresStartTLS(t *testing.T) {
+	server := newFakeSMTP(t, 0)
+	cfg := server.config()
+	cfg.TLSMode = "starttls"
+
+	err := NewSMTPTransport(cfg, "soporte@example.gob.pe", "").Send(context.Background(), &Message{
+		To: []string{"ana@example.gob.pe"}, Subject: "x", Text: "x",
+	})
+	if err == nil {
+		t.Fatal("sin STARTTLS el envío debe fallar en lugar de ir en claro")
+	}
+	if len(server.received()) != 0 {
+		t.Fatal("no debe entregarse el correo")
+	}
+}
+
+func TestMailerRendersTemplateAndRetries(t *testing.T) {
+	server := newFakeSMTP(t, 1)
+	m, err := New(Config{
+		Driver:      "smtp",
+		FromAddress: "soporte@example.gob.pe",
+		AppName:     "Inventario",
+		SMTP:        server.config(),
+		MaxAttempts: 3,
+		RetryDelay:  10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.queue.Start()
+	t.Cleanup(func() { m.Stop(context.Background()) })
+
+	if err := m.SendTemplate("ana@example.gob.pe", "password_reset", map[string]interface{}{
+		"Code":    "482913",
+		"Minutes": 15,
+	}); err != nil {
+		t.Fatalf("SendTemplate: %v", err)
+	}
+	server.waitDelivered(t, 1)
+
+	msgs := server.received()
+	if len(msgs) != 1 {
+		t.Fatalf("el reintento debe entregar un único correo, llegaron %d", len(msgs))
+	}
+	if !strings.Contains(msgs[0].data, "482913") {
+		t.Fatal("el correo no contiene el código")
+	}
+}
+
+func TestStopDrainsPendingMail(t *testing.T) {
+	server := newFakeSMTP(t, 0)
+	transport := NewSMTPTransport(server.config(), "soporte@example.gob.pe", "")
+	queue := NewQueue(transport, 10, 1, 1, time.Millisecond)
+	queue.Start()
+
+	for i := 0; i < 3; i++ {
+		if err := queue.Enqueue(&Message{To: []string{"ana@example.gob.pe"}, Subject: "x", Text: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	queue.Stop(ctx)
+
+	if n := len(server.received()); n != 3 {
+		t.Fatalf("Stop debe esperar los 3 correos pendientes, se entregaron %d", n)
+	}
+	if err := queue.Enqueue(&Message{To: []string{"ana@example.gob.pe"}}); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("tras Stop se esperaba ErrQueueClosed, se obtuvo %v", err)
+	}
+}
+
+func TestEnqueueFailsWhenQueueIsFull(t *testing.T) {
+	queue := NewQueue(NewLogTransport(t.TempDir(), "soporte@example.gob.pe", ""), 1, 1, 1, time.Millisecond)
+	msg := &Message{To: []string{"ana@example.gob.pe"}, Subject: "x", Text: "x"}
+
+	if err := queue.Enqueue(msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Enqueue(msg); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("se esperaba ErrQueueFull, se obtuvo %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// server/pkgs/mailer/templates.go
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.html templates/*.txt
+var templateFS embed.FS
+
+// Renderer genera asunto, texto y HTML a partir de las plantillas embebidas.
+// Cada plantilla <nombre>.txt define los bloques "subject" y "text";
+// <nombre>.html define el bloque "content" que se inserta en layout.html.
+type Renderer struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+func NewRenderer() (*Renderer, error) {
+	text, err := texttemplate.ParseFS(templateFS, "templates/*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("error cargando plantillas de texto: %w", err)
+	}
+	html, err := htmltemplate.ParseFS(templateFS, "templates/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("error cargando plantillas HTML: %w", err)
+	}
+	return &Renderer{text: text, html: html}, nil
+}
+
+func (r *Renderer) Render(name string, data map[string]interface{}) (*Message, error) {
+	subject, err := r.execText(name+".subject", data)
+	if err != nil {
+		return nil, err
+	}
+	text, err := r.execText(name+".text", data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cada render usa una copia para poder redefinir "content"
+	html, err := r.html.Clone()
+	if err != nil {
+		return nil, err
+	}
+	content := html.Lookup(name + ".content")
+	if content == nil {
+		return nil, fmt.Errorf("plantilla HTML %q no encontrada", name)
+	}
+	if _, err := html.AddParseTree("content", content.Tree); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := html.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return nil, fmt.Errorf("error renderizando %s: %w", name, err)
+	}
+
+	return &Message{
+		Subject: strings.TrimSpace(subject),
+		Text:    strings.TrimSpace(text) + "\n",
+		HTML:    buf.String(),
+	}, nil
+}
+
+func (r *Renderer) execText(name string, data map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := r.text.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("error renderizando %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,28 @@
+// server/pkgs/oidc/pkce.go
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// RandomString genera un valor aleatorio url-safe (state, nonce, verifier)
+func RandomString(numBytes int) (string, error) {
+	b := make([]byte, numBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GenerateVerifier crea un code_verifier PKCE de 43 caracteres (RFC 7636)
+func GenerateVerifier() (string, error) {
+	return RandomString(32)
+}
+
+// S256Challenge calcula el code_challenge para el método S256
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,232 @@
+// server/pkgs/oidc/provider.go
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrDiscoveryFailed   = errors.New("no se pudo obtener la configuración del proveedor OIDC")
+	ErrIssuerMismatch    = errors.New("el issuer del proveedor no coincide con el configurado")
+	ErrExchangeFailed    = errors.New("no se pudo intercambiar el código de autorización")
+	ErrMissingIDToken    = errors.New("el proveedor no devolvió un id_token")
+	ErrInvalidIDToken    = errors.New("id_token inválido")
+	ErrNonceMismatch     = errors.New("el nonce del id_token no coincide")
+	ErrUnknownSigningKey = errors.New("clave de firma desconocida")
+)
+
+// Config describe un proveedor OIDC configurado (Keycloak, Entra, etc.)
+type Config struct {
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	DiscoveryURL string
+	RedirectURL  string
+	Scopes       []string
+	// TrustEmail permite vincular automáticamente por correo verificado
+	TrustEmail bool
+}
+
+// Discovery contiene los campos usados del documento openid-configuration
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// TokenResponse es la respuesta del token endpoint
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Scope        string `json:"scope"`
+}
+
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *Discovery
+	keys      *keySet
+}
+
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.DiscoveryURL == "" {
+		cfg.DiscoveryURL = strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if cfg.DisplayName == "" {
+		cfg.DisplayName = cfg.Name
+	}
+	return &Provider{cfg: cfg, client: client}
+}
+
+func (p *Provider) Name() string { return p.cfg.Name }
+
+func (p *Provider) Config() Config { return p.cfg }
+
+// Discover obtiene (y cachea) el documento de descubrimiento del proveedor
+func (p *Provider) Discover(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d Discovery
+	if err := p.getJSON(ctx, p.cfg.DiscoveryURL, &d); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscoveryFailed, err)
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != strings.TrimSuffix(p.cfg.Issuer, "/") {
+		return nil, ErrIssuerMismatch
+	}
+
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// AuthCodeURL construye la URL de autorización con PKCE (S256)
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange canjea el código de autorización junto con el code_verifier
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*TokenResponse, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrExchangeFailed, resp.StatusCode)
+	}
+
+	var tokens TokenResponse
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	if tokens.IDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+
+	return &tokens, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d en %s", resp.StatusCode, endpoint)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// Registry agrupa los proveedores habilitados por nombre
+type Registry struct {
+	providers map[string]*Provider
+	order     []string
+}
+
+func NewRegistry(cfgs []Config, client *http.Client) *Registry {
+	r := &Registry{providers: make(map[string]*Provider)}
+	for _, cfg := range cfgs {
+		if _, exists := r.providers[cfg.Name]; exists {
+			continue
+		}
+		r.providers[cfg.Name] = NewProvider(cfg, client)
+		r.order = append(r.order, cfg.Name)
+	}
+	return r
+}
+
+func (r *Registry) Get(name string) (*Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+func (r *Registry) List() []*Provider {
+	list := make([]*Provider, 0, len(r.order))
+	for _, name := range r.order {
+		list = append(list, r.providers[name])
+	}
+	return list
+}
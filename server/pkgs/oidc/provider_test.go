@@ -0,0 +1,230 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockProvider es un proveedor OIDC local: publica discovery y JWKS y canjea
+// un único código validando el code_verifier PKCE
+type mockProvider struct {
+	t        *testing.T
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	kid      string
+	clientID string
+
+	code      string
+	challenge string
+	nonce     string
+	subject   string
+	email     string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockProvider{t: t, key: key, kid: "test-key", clientID: "inventario"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, Discovery{
+			Issuer:                m.server.URL,
+			AuthorizationEndpoint: m.server.URL + "/authorize",
+			TokenEndpoint:         m.server.URL + "/token",
+			JWKSURI:               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []jsonWebKey{{
+			Kty: "RSA",
+			Kid: m.kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("code") != m.code || S256Challenge(r.Form.Get("code_verifier")) != m.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, TokenResponse{
+			AccessToken: "access",
+			TokenType:   "Bearer",
+			IDToken:     m.idToken(m.server.URL, m.clientID, m.nonce, time.Now().Add(time.Minute)),
+		})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockProvider) provider() *Provider {
+	return NewProvider(Config{
+		Name:        "mock",
+		Issuer:      m.server.URL,
+		ClientID:    m.clientID,
+		RedirectURL: "http://localhost/callback",
+	}, m.server.Client())
+}
+
+func (m *mockProvider) idToken(issuer, audience, nonce string, expires time.Time) string {
+	m.t.Helper()
+	claims := IDTokenClaims{
+		Email:         m.email,
+		EmailVerified: true,
+		Nonce:         nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   m.subject,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = m.kid
+	signed, err := token.SignedString(m.key)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return signed
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+	ctx := context.Background()
+
+	verifier, err := GenerateVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.challenge = S256Challenge(verifier)
+	m.code = "code-123"
+	m.nonce = "nonce-abc"
+	m.subject = "user-1"
+	m.email = "ana@example.gob.pe"
+
+	authURL, err := p.AuthCodeURL(ctx, "state-xyz", m.nonce, m.challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := parsed.Query()
+	if q.Get("code_challenge") != m.challenge || q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("la URL no lleva el desafío PKCE: %s", authURL)
+	}
+	if q.Get("state") != "state-xyz" || q.Get("nonce") != m.nonce || q.Get("client_id") != m.clientID {
+		t.Fatalf("la URL no lleva state, nonce o client_id: %s", authURL)
+	}
+
+	tokens, err := p.Exchange(ctx, m.code, verifier)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	claims, err := p.VerifyIDToken(ctx, tokens.IDToken, m.nonce)
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.Subject != m.subject || claims.Email != m.email {
+		t.Fatalf("claims inesperados: %+v", claims)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	m := newMockProvider(t)
+	verifier, _ := GenerateVerifier()
+	m.challenge = S256Challenge(verifier)
+	m.code = "code-123"
+
+	_, err := m.provider().Exchange(context.Background(), m.code, verifier+"x")
+	if !errors.Is(err, ErrExchangeFailed) {
+		t.Fatalf("se esperaba ErrExchangeFailed, se obtuvo %v", err)
+	}
+}
+
+func TestVerifyIDTokenRejections(t *testing.T) {
+	m := newMockProvider(t)
+	m.subject = "user-1"
+	future := time.Now().Add(time.Minute)
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Issuer:    m.server.URL,
+		Audience:  jwt.ClaimStrings{m.clientID},
+		ExpiresAt: jwt.NewNumericDate(future),
+	})
+	forged.Header["kid"] = m.kid
+	forgedRaw, _ := forged.SignedString(other)
+
+	cases := []struct {
+		name  string
+		raw   string
+		nonce string
+		want  error
+	}{
+		{"issuer ajeno", m.idToken("https://otro.example", m.clientID, "n", future), "n", ErrInvalidIDToken},
+		{"audiencia ajena", m.idToken(m.server.URL, "otro-cliente", "n", future), "n", ErrInvalidIDToken},
+		{"expirado", m.idToken(m.server.URL, m.clientID, "n", time.Now().Add(-time.Hour)), "n", ErrInvalidIDToken},
+		{"nonce distinto", m.idToken(m.server.URL, m.clientID, "n", future), "otro", ErrNonceMismatch},
+		{"firma de otra clave", forgedRaw, "", ErrInvalidIDToken},
+	}
+	p := m.provider()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := p.VerifyIDToken(context.Background(), tc.raw, tc.nonce); !errors.Is(err, tc.want) {
+				t.Fatalf("se esperaba %v, se obtuvo %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	m := newMockProvider(t)
+	p := NewProvider(Config{
+		Name:         "mock",
+		Issuer:       "https://otro.example",
+		DiscoveryURL: m.server.URL + "/.well-known/openid-configuration",
+		ClientID:     m.clientID,
+	}, m.server.Client())
+
+	if _, err := p.Discover(context.Background()); !errors.Is(err, ErrIssuerMismatch) {
+		t.Fatalf("se esperaba ErrIssuerMismatch, se obtuvo %v", err)
+	}
+	if !strings.HasPrefix(p.Config().DiscoveryURL, m.server.URL) {
+		t.Fatal("DiscoveryURL explícita no debe reemplazarse")
+	}
+}
@@ -0,0 +1,62 @@
+// server/pkgs/passpolicy/breached.go
+package passpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachedList consulta una copia local de contraseñas filtradas con el
+// esquema k-anonymity de Have I Been Pwned: el directorio contiene un archivo
+// por prefijo de 5 caracteres del SHA-1 ("ABCDE.txt") con líneas "SUFIJO:CONTEO".
+// Solo se lee el archivo del prefijo consultado.
+type BreachedList struct {
+	dir string
+}
+
+func NewBreachedList(dir string) *BreachedList {
+	return &BreachedList{dir: dir}
+}
+
+func (b *BreachedList) Check(password string, _ Subject) ([]Violation, error) {
+	breached, err := b.Contains(password)
+	if err != nil {
+		return nil, err
+	}
+	if breached {
+		return []Violation{NewViolation(CodeBreached, nil)}, nil
+	}
+	return nil, nil
+}
+
+// Contains indica si el SHA-1 de la contraseña está en la lista
+func (b *BreachedList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:5], digest[5:]
+
+	file, err := os.Open(filepath.Join(b.dir, prefix+".txt"))
+	if err != nil {
+		// Un prefijo sin archivo significa que no hay filtraciones con ese prefijo
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate, _, _ := strings.Cut(line, ":")
+		if strings.EqualFold(candidate, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
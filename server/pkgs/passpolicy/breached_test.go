@@ -0,0 +1,112 @@
+package passpolicy
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rangeServer imita el endpoint /range/{prefijo} de Have I Been Pwned: recibe
+// solo los 5 primeros caracteres del SHA-1 y responde "SUFIJO:CONTEO" por línea
+func rangeServer(t *testing.T, breached ...string) *httptest.Server {
+	t.Helper()
+	ranges := map[string][]string{}
+	for _, password := range breached {
+		sum := sha1.Sum([]byte(password))
+		digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+		ranges[digest[:5]] = append(ranges[digest[:5]], digest[5:]+":42")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := strings.TrimPrefix(r.URL.Path, "/range/")
+		if len(prefix) != 5 {
+			t.Errorf("se pidió %q: el cliente debe enviar solo el prefijo", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		// Relleno como el de HIBP, para que el sufijo buscado no sea la única línea
+		lines := append([]string{"0000000000000000000000000000000000A:0"}, ranges[strings.ToUpper(prefix)]...)
+		io.WriteString(w, strings.Join(lines, "\r\n"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// syncRange descarga el rango de la contraseña y lo guarda como <PREFIJO>.txt,
+// igual que la copia local que consulta BreachedList
+func syncRange(t *testing.T, srv *httptest.Server, dir, password string) {
+	t.Helper()
+	sum := sha1.Sum([]byte(password))
+	prefix := strings.ToUpper(hex.EncodeToString(sum[:]))[:5]
+
+	resp, err := http.Get(srv.URL + "/range/" + prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, prefix+".txt"), body, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBreachedListMatchesRangeResponse(t *testing.T) {
+	srv := rangeServer(t, "P@ssw0rd", "Inventario2026!")
+	dir := t.TempDir()
+	for _, password := range []string{"P@ssw0rd", "Inventario2026!", "Otra-Clave-Segura-91"} {
+		syncRange(t, srv, dir, password)
+	}
+
+	list := NewBreachedList(dir)
+	cases := []struct {
+		password string
+		want     bool
+	}{
+		{"P@ssw0rd", true},
+		{"Inventario2026!", true},
+		{"Otra-Clave-Segura-91", false},
+		{"p@ssw0rd", false},
+		{"prefijo-sin-archivo", false},
+	}
+	for _, tc := range cases {
+		got, err := list.Contains(tc.password)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.password, err)
+		}
+		if got != tc.want {
+			t.Fatalf("Contains(%q) = %v, se esperaba %v", tc.password, got, tc.want)
+		}
+	}
+
+	violations, err := list.Check("P@ssw0rd", Subject{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 1 || violations[0].Code != CodeBreached {
+		t.Fatalf("infracciones = %+v", violations)
+	}
+}
+
+func TestBreachedListIgnoresSuffixCase(t *testing.T) {
+	sum := sha1.Sum([]byte("P@ssw0rd"))
+	digest := hex.EncodeToString(sum[:])
+	dir := t.TempDir()
+	line := strings.ToLower(digest[5:]) + ":3\n"
+	if err := os.WriteFile(filepath.Join(dir, strings.ToUpper(digest[:5])+".txt"), []byte(line), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewBreachedList(dir).Contains("P@ssw0rd")
+	if err != nil || !got {
+		t.Fatalf("Contains = %v, %v; se esperaba true", got, err)
+	}
+}
@@ -0,0 +1,172 @@
+// server/pkgs/passpolicy/policy.go
+package passpolicy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Códigos de infracción; el cliente los traduce usando Params
+const (
+	CodeTooShort         = "PASSWORD_TOO_SHORT"
+	CodeTooLong          = "PASSWORD_TOO_LONG"
+	CodeMissingUppercase = "PASSWORD_MISSING_UPPERCASE"
+	CodeMissingLowercase = "PASSWORD_MISSING_LOWERCASE"
+	CodeMissingDigit     = "PASSWORD_MISSING_DIGIT"
+	CodeMissingSymbol    = "PASSWORD_MISSING_SYMBOL"
+	CodeContainsEmail    = "PASSWORD_CONTAINS_EMAIL"
+	CodeReused           = "PASSWORD_REUSED"
+	CodeBreached         = "PASSWORD_BREACHED"
+)
+
+// messages son los textos por defecto (es) de cada código
+var messages = map[string]string{
+	CodeTooShort:         "La contraseña debe tener al menos %d caracteres",
+	CodeTooLong:          "La contraseña no puede superar %d caracteres",
+	CodeMissingUppercase: "La contraseña debe incluir una letra mayúscula",
+	CodeMissingLowercase: "La contraseña debe incluir una letra minúscula",
+	CodeMissingDigit:     "La contraseña debe incluir un número",
+	CodeMissingSymbol:    "La contraseña debe incluir un símbolo",
+	CodeContainsEmail:    "La contraseña no puede contener tu correo",
+	CodeReused:           "No puedes reutilizar ninguna de tus últimas %d contraseñas",
+	CodeBreached:         "La contraseña aparece en filtraciones conocidas, elige otra",
+}
+
+// Violation es una regla incumplida
+type Violation struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Params  map[string]int `json:"params,omitempty"`
+}
+
+// NewViolation arma la infracción; los mensajes tienen a lo sumo un parámetro numérico
+func NewViolation(code string, params map[string]int) Violation {
+	msg := messages[code]
+	for _, n := range params {
+		msg = fmt.Sprintf(msg, n)
+	}
+	return Violation{Code: code, Message: msg, Params: params}
+}
+
+// PolicyError agrupa todas las infracciones de una contraseña
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, v.Message)
+	}
+	return strings.Join(parts, ". ")
+}
+
+// Subject describe al dueño de la contraseña para reglas contextuales
+type Subject struct {
+	UserID string
+	Email  string
+}
+
+// Rule es una regla de la política. Las reglas con estado (historial,
+// filtraciones) se implementan fuera del paquete y se registran en el motor.
+type Rule interface {
+	Check(password string, subject Subject) ([]Violation, error)
+}
+
+// RuleFunc adapta una función a Rule
+type RuleFunc func(password string, subject Subject) ([]Violation, error)
+
+func (f RuleFunc) Check(password string, subject Subject) ([]Violation, error) {
+	return f(password, subject)
+}
+
+// Engine evalúa todas las reglas y reporta todas las infracciones juntas
+type Engine struct {
+	rules []Rule
+}
+
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+// Use agrega reglas al final del motor
+func (e *Engine) Use(rules ...Rule) {
+	e.rules = append(e.rules, rules...)
+}
+
+// Validate devuelve *PolicyError si hay infracciones, u otro error si una regla falló
+func (e *Engine) Validate(password string, subject Subject) error {
+	var violations []Violation
+	for _, rule := range e.rules {
+		found, err := rule.Check(password, subject)
+		if err != nil {
+			return err
+		}
+		violations = append(violations, found...)
+	}
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// CompositionRules define longitud y clases de caracteres exigidas
+type CompositionRules struct {
+	MinLength      int
+	MaxLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSymbol  bool
+	RejectEmailUse bool
+}
+
+func (r CompositionRules) Check(password string, subject Subject) ([]Violation, error) {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if r.MinLength > 0 && length < r.MinLength {
+		violations = append(violations, NewViolation(CodeTooShort, map[string]int{"min": r.MinLength}))
+	}
+	if r.MaxLength > 0 && length > r.MaxLength {
+		violations = append(violations, NewViolation(CodeTooLong, map[string]int{"max": r.MaxLength}))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, ch := range password {
+		switch {
+		case unicode.IsUpper(ch):
+			upper = true
+		case unicode.IsLower(ch):
+			lower = true
+		case unicode.IsDigit(ch):
+			digit = true
+		case unicode.IsPunct(ch) || unicode.IsSymbol(ch) || unicode.IsSpace(ch):
+			symbol = true
+		}
+	}
+
+	if r.RequireUpper && !upper {
+		violations = append(violations, NewViolation(CodeMissingUppercase, nil))
+	}
+	if r.RequireLower && !lower {
+		violations = append(violations, NewViolation(CodeMissingLowercase, nil))
+	}
+	if r.RequireDigit && !digit {
+		violations = append(violations, NewViolation(CodeMissingDigit, nil))
+	}
+	if r.RequireSymbol && !symbol {
+		violations = append(violations, NewViolation(CodeMissingSymbol, nil))
+	}
+
+	if r.RejectEmailUse && subject.Email != "" {
+		local := strings.ToLower(strings.SplitN(subject.Email, "@", 2)[0])
+		if len(local) >= 4 && strings.Contains(strings.ToLower(password), local) {
+			violations = append(violations, NewViolation(CodeContainsEmail, nil))
+		}
+	}
+
+	return violations, nil
+}
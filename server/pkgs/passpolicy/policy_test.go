@@ -0,0 +1,102 @@
+package passpolicy
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func violationCodes(violations []Violation) []string {
+	codes := make([]string, 0, len(violations))
+	for _, v := range violations {
+		codes = append(codes, v.Code)
+	}
+	return codes
+}
+
+func TestCompositionRules(t *testing.T) {
+	strict := CompositionRules{
+		MinLength:      10,
+		MaxLength:      20,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSymbol:  true,
+		RejectEmailUse: true,
+	}
+	subject := Subject{UserID: "user-1", Email: "Mariela.Rojas@example.com"}
+
+	cases := []struct {
+		name     string
+		rules    CompositionRules
+		password string
+		subject  Subject
+		want     []string
+	}{
+		{"cumple todo", strict, "Inventario-2026", subject, []string{}},
+		{"demasiado corta", strict, "Ab1-", subject, []string{CodeTooShort}},
+		{"demasiado larga", strict, "Inventario-2026-Oficina-Central", subject, []string{CodeTooLong}},
+		{"cuenta runas, no bytes", CompositionRules{MinLength: 6}, "ñandú", subject, []string{CodeTooShort}},
+		{"sin mayúscula", strict, "inventario-2026", subject, []string{CodeMissingUppercase}},
+		{"sin minúscula", strict, "INVENTARIO-2026", subject, []string{CodeMissingLowercase}},
+		{"sin número", strict, "Inventario-Anual", subject, []string{CodeMissingDigit}},
+		{"sin símbolo", strict, "Inventario2026", subject, []string{CodeMissingSymbol}},
+		{"espacio cuenta como símbolo", strict, "Inventario 2026", subject, []string{}},
+		{"letras no ascii", strict, "Ñandú-Ártico-7", subject, []string{}},
+		{"contiene el correo", strict, "X-mariela.rojas-9", subject, []string{CodeContainsEmail}},
+		{"correo sin distinguir mayúsculas", strict, "MARIELA.ROJAS-9a", subject, []string{CodeContainsEmail}},
+		{"local corto no se compara", strict, "Inventario-ana-1", Subject{Email: "ana@example.com"}, []string{}},
+		{"sin sujeto", strict, "Mariela.Rojas-9", Subject{}, []string{}},
+		{"varias a la vez", strict, "abc", subject, []string{CodeTooShort, CodeMissingUppercase, CodeMissingDigit, CodeMissingSymbol}},
+		{"reglas vacías", CompositionRules{}, "", Subject{}, []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations, err := tc.rules.Check(tc.password, tc.subject)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := violationCodes(violations); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("códigos = %v, se esperaban %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewViolationFormatsParams(t *testing.T) {
+	v := NewViolation(CodeTooShort, map[string]int{"min": 12})
+	if v.Message != "La contraseña debe tener al menos 12 caracteres" {
+		t.Fatalf("mensaje = %q", v.Message)
+	}
+	if v.Params["min"] != 12 {
+		t.Fatalf("params = %v", v.Params)
+	}
+}
+
+func TestEngineCollectsViolationsAndStopsOnError(t *testing.T) {
+	engine := NewEngine(CompositionRules{MinLength: 8, RequireDigit: true})
+	engine.Use(RuleFunc(func(string, Subject) ([]Violation, error) {
+		return []Violation{NewViolation(CodeBreached, nil)}, nil
+	}))
+
+	err := engine.Validate("corta", Subject{})
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("err = %v, se esperaba *PolicyError", err)
+	}
+	want := []string{CodeTooShort, CodeMissingDigit, CodeBreached}
+	if got := violationCodes(policyErr.Violations); !reflect.DeepEqual(got, want) {
+		t.Fatalf("códigos = %v, se esperaban %v", got, want)
+	}
+
+	failure := errors.New("lista no disponible")
+	engine.Use(RuleFunc(func(string, Subject) ([]Violation, error) { return nil, failure }))
+	if err := engine.Validate("corta", Subject{}); !errors.Is(err, failure) {
+		t.Fatalf("err = %v, se esperaba el error de la regla", err)
+	}
+
+	if err := NewEngine(CompositionRules{MinLength: 4}).Validate("suficiente", Subject{}); err != nil {
+		t.Fatalf("contraseña válida rechazada: %v", err)
+	}
+}
@@ -26,21 +26,43 @@ type Argon2Params struct {
 	KeyLength   uint32
 }
 
+// Validate rechaza parámetros que argon2 no admite o que serían inseguros
+func (p Argon2Params) Validate() error {
+	switch {
+	case p.Parallelism < 1:
+		return errors.New("argon2: parallelism debe ser al menos 1")
+	case p.Iterations < 1:
+		return errors.New("argon2: iterations debe ser al menos 1")
+	case p.Memory < 8*uint32(p.Parallelism):
+		return errors.New("argon2: memory debe ser al menos 8 KiB por hilo")
+	case p.SaltLength < 16:
+		return errors.New("argon2: salt length debe ser al menos 16 bytes")
+	case p.KeyLength < 16:
+		return errors.New("argon2: key length debe ser al menos 16 bytes")
+	}
+	return nil
+}
+
+// DefaultArgon2Params devuelve los parámetros recomendados por OWASP
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      64 * 1024, // 64 MB (mínimo recomendado por OWASP)
+		Iterations:  3,         // 3 iteraciones (balance seguridad/rendimiento)
+		Parallelism: 4,         // 4 hilos (tu valor original)
+		SaltLength:  16,        // 16 bytes (128 bits)
+		KeyLength:   32,        // 32 bytes (256 bits)
+	}
+}
+
 type Argon2Service struct {
-	params *Argon2Params
+	params  *Argon2Params
+	limiter *HashLimiter
 }
 
 // NewArgon2Service crea una instancia con parámetros recomendados por OWASP
 func NewArgon2Service() *Argon2Service {
-	return &Argon2Service{
-		params: &Argon2Params{
-			Memory:      64 * 1024, // 64 MB (mínimo recomendado por OWASP)
-			Iterations:  3,         // 3 iteraciones (balance seguridad/rendimiento)
-			Parallelism: 4,         // 4 hilos (tu valor original)
-			SaltLength:  16,        // 16 bytes (128 bits)
-			KeyLength:   32,        // 32 bytes (256 bits)
-		},
-	}
+	params := DefaultArgon2Params()
+	return &Argon2Service{params: &params}
 }
 
 // NewArgon2ServiceWithParams permite personalizar los parámetros
@@ -48,6 +70,30 @@ func NewArgon2ServiceWithParams(params *Argon2Params) *Argon2Service {
 	return &Argon2Service{params: params}
 }
 
+// WithLimiter hace que cada hash pase por el limitador de concurrencia
+func (a *Argon2Service) WithLimiter(limiter *HashLimiter) *Argon2Service {
+	a.limiter = limiter
+	return a
+}
+
+// Limiter devuelve el limitador configurado (nil si no tiene)
+func (a *Argon2Service) Limiter() *HashLimiter {
+	return a.limiter
+}
+
+// idKey calcula Argon2id respetando el limitador, si lo hay
+func (a *Argon2Service) idKey(password, salt []byte, params *Argon2Params) ([]byte, error) {
+	if a.limiter == nil {
+		return argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength), nil
+	}
+
+	var key []byte
+	err := a.limiter.Do(func() {
+		key = argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	})
+	return key, err
+}
+
 // HashPassword genera un hash Argon2id de la contraseña
 func (a *Argon2Service) HashPassword(password string) (string, error) {
 	if password == "" {
@@ -59,14 +105,10 @@ func (a *Argon2Service) HashPassword(password string) (string, error) {
 		return "", fmt.Errorf("failed to generate salt: %w", err)
 	}
 
-	hash := argon2.IDKey(
-		[]byte(password),
-		salt,
-		a.params.Iterations,
-		a.params.Memory,
-		a.params.Parallelism,
-		a.params.KeyLength,
-	)
+	hash, err := a.idKey([]byte(password), salt, a.params)
+	if err != nil {
+		return "", err
+	}
 
 	// Formato estándar PHC: $argon2id$v=19$m=65536,t=3,p=4$salt$hash
 	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
@@ -102,14 +144,10 @@ func (a *Argon2Service) ComparePassword(encodedHash, password string) error {
 		return fmt.Errorf("failed to decode hash: %w", err)
 	}
 
-	otherHash := argon2.IDKey(
-		[]byte(password),
-		salt,
-		params.Iterations,
-		params.Memory,
-		params.Parallelism,
-		params.KeyLength,
-	)
+	otherHash, err := a.idKey([]byte(password), salt, params)
+	if err != nil {
+		return err
+	}
 
 	// Usar subtle.ConstantTimeCompare en lugar de implementación manual
 	if subtle.ConstantTimeCompare(hash, otherHash) != 1 {
@@ -168,7 +206,7 @@ func (a *Argon2Service) decodeHash(encodedHash string) (*Argon2Params, []byte, [
 
 // NeedsRehash verifica si el hash necesita ser regenerado (cambio de parámetros)
 func (a *Argon2Service) NeedsRehash(encodedHash string) bool {
-	params, _, _, err := a.decodeHash(encodedHash)
+	params, salt, _, err := a.decodeHash(encodedHash)
 	if err != nil {
 		return true
 	}
@@ -176,5 +214,6 @@ func (a *Argon2Service) NeedsRehash(encodedHash string) bool {
 	return params.Memory != a.params.Memory ||
 		params.Iterations != a.params.Iterations ||
 		params.Parallelism != a.params.Parallelism ||
-		params.KeyLength != a.params.KeyLength
+		params.KeyLength != a.params.KeyLength ||
+		uint32(len(salt)) != a.params.SaltLength
 }
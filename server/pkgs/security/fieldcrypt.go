@@ -0,0 +1,160 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrFieldCipherDisabled = errors.New("el cifrado de columnas no está inicializado")
+	ErrFieldKeyUnknown     = errors.New("el valor está cifrado con una versión de clave desconocida")
+	ErrFieldCiphertext     = errors.New("el valor cifrado está dañado o no corresponde a esta columna")
+)
+
+// Los valores cifrados tienen la forma enc:v<versión>:<base64(nonce|cifrado)>
+const fieldCipherMarker = "enc:v"
+
+// FieldKeyConfig lista las claves de cifrado de columnas con su versión, en
+// formato "1:<base64>,2:<base64>" (32 bytes cada una). Cifra la versión más
+// alta salvo que Active indique otra; las demás solo descifran.
+type FieldKeyConfig struct {
+	Keys   string
+	Active int
+}
+
+// FieldCipher cifra columnas sensibles con AES-256-GCM. Cada valor guarda la
+// versión de la clave, así una rotación no invalida lo ya cifrado.
+type FieldCipher struct {
+	keys   map[int]cipher.AEAD
+	active int
+}
+
+// Fields es el cifrador de columnas compartido por los modelos
+var Fields *FieldCipher
+
+// InitFieldCipher carga las claves y deja el cifrador disponible en Fields
+func InitFieldCipher(cfg FieldKeyConfig) error {
+	c, err := NewFieldCipher(cfg)
+	if err != nil {
+		return err
+	}
+	Fields = c
+	return nil
+}
+
+func NewFieldCipher(cfg FieldKeyConfig) (*FieldCipher, error) {
+	c := &FieldCipher{keys: map[int]cipher.AEAD{}}
+
+	for _, entry := range strings.Split(cfg.Keys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		versionStr, encoded, ok := strings.Cut(entry, ":")
+		version, err := strconv.Atoi(versionStr)
+		if !ok || err != nil || version < 1 {
+			return nil, fmt.Errorf("clave de cifrado inválida %q: usa <versión>:<base64>", versionStr)
+		}
+		if _, dup := c.keys[version]; dup {
+			return nil, fmt.Errorf("la versión %d de la clave de cifrado está repetida", version)
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("la clave de cifrado v%d debe ser de 32 bytes en base64", version)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		c.keys[version] = aead
+		if version > c.active {
+			c.active = version
+		}
+	}
+
+	if len(c.keys) == 0 {
+		return nil, errors.New("no hay claves de cifrado de columnas configuradas (FIELD_ENCRYPTION_KEYS)")
+	}
+	if cfg.Active != 0 {
+		if _, ok := c.keys[cfg.Active]; !ok {
+			return nil, fmt.Errorf("la versión activa %d no está entre las claves de cifrado", cfg.Active)
+		}
+		c.active = cfg.Active
+	}
+	return c, nil
+}
+
+// ActiveVersion es la versión de clave con la que se cifran los valores nuevos
+func (c *FieldCipher) ActiveVersion() int {
+	return c.active
+}
+
+// Encrypt cifra el valor con la clave activa. context (tabla, columna y fila)
+// se autentica junto al cifrado para que no pueda copiarse a otra celda.
+func (c *FieldCipher) Encrypt(plaintext, context string) (string, error) {
+	aead := c.keys[c.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(context))
+	return fieldCipherMarker + strconv.Itoa(c.active) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt descifra un valor producido por Encrypt. Los valores sin el prefijo
+// de cifrado se devuelven tal cual: son filas anteriores al cifrado que aún no
+// pasaron por la re-encriptación.
+func (c *FieldCipher) Decrypt(value, context string) (string, error) {
+	version, payload, encrypted := c.parse(value)
+	if !encrypted {
+		return value, nil
+	}
+	aead, ok := c.keys[version]
+	if !ok {
+		return "", fmt.Errorf("%w: v%d", ErrFieldKeyUnknown, version)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrFieldCiphertext
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(context))
+	if err != nil {
+		return "", ErrFieldCiphertext
+	}
+	return string(plaintext), nil
+}
+
+// IsCurrent indica si el valor ya está cifrado con la clave activa
+func (c *FieldCipher) IsCurrent(value string) bool {
+	version, _, encrypted := c.parse(value)
+	return encrypted && version == c.active
+}
+
+func (c *FieldCipher) parse(value string) (int, string, bool) {
+	if !strings.HasPrefix(value, fieldCipherMarker) {
+		return 0, "", false
+	}
+	versionStr, payload, ok := strings.Cut(strings.TrimPrefix(value, fieldCipherMarker), ":")
+	if !ok {
+		return 0, "", false
+	}
+	version, err := strconv.Atoi(versionStr)
+	if err != nil {
+		return 0, "", false
+	}
+	return version, payload, true
+}
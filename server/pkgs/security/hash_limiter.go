@@ -0,0 +1,134 @@
+package security
+
+import (
+	"errors"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// ErrHasherBusy indica que no hubo cupo para calcular el hash a tiempo
+var ErrHasherBusy = errors.New("el servidor está ocupado procesando contraseñas, intenta nuevamente en unos segundos")
+
+// HasherBusyError acompaña a ErrHasherBusy con el tiempo sugerido de reintento
+type HasherBusyError struct {
+	Wait time.Duration
+}
+
+func (e *HasherBusyError) Error() string {
+	return ErrHasherBusy.Error()
+}
+
+func (e *HasherBusyError) Is(target error) bool {
+	return target == ErrHasherBusy
+}
+
+// RetryAfter devuelve los segundos sugeridos antes de reintentar (mínimo 1)
+func (e *HasherBusyError) RetryAfter() int {
+	secs := int(math.Ceil(e.Wait.Seconds()))
+	if secs < 1 {
+		return 1
+	}
+	return secs
+}
+
+// HashLimiterConfig define cuántos hashes Argon2 pueden calcularse a la vez
+// y cuánto puede esperar una petición por un cupo libre.
+type HashLimiterConfig struct {
+	MaxConcurrent int
+	QueueTimeout  time.Duration
+}
+
+// HashLimiterStats es una foto de la cola de hashing
+type HashLimiterStats struct {
+	MaxConcurrent int    `json:"maxConcurrent"`
+	Active        int64  `json:"active"`
+	Queued        int64  `json:"queued"`
+	PeakQueued    int64  `json:"peakQueued"`
+	Completed     uint64 `json:"completed"`
+	Rejected      uint64 `json:"rejected"`
+}
+
+// HashLimiter acota la memoria usada por Argon2: cada hash reserva
+// Argon2Params.Memory, así que el pico queda en MaxConcurrent × Memory.
+type HashLimiter struct {
+	slots   chan struct{}
+	timeout time.Duration
+
+	active     atomic.Int64
+	queued     atomic.Int64
+	peakQueued atomic.Int64
+	completed  atomic.Uint64
+	rejected   atomic.Uint64
+}
+
+func NewHashLimiter(cfg HashLimiterConfig) *HashLimiter {
+	if cfg.MaxConcurrent < 1 {
+		cfg.MaxConcurrent = 1
+	}
+	return &HashLimiter{
+		slots:   make(chan struct{}, cfg.MaxConcurrent),
+		timeout: cfg.QueueTimeout,
+	}
+}
+
+// Do ejecuta fn cuando hay un cupo libre. Si la espera supera QueueTimeout
+// devuelve *HasherBusyError sin ejecutar fn.
+func (l *HashLimiter) Do(fn func()) error {
+	if err := l.acquire(); err != nil {
+		return err
+	}
+	defer l.release()
+
+	fn()
+	return nil
+}
+
+func (l *HashLimiter) acquire() error {
+	// Camino rápido: hay cupo sin esperar
+	select {
+	case l.slots <- struct{}{}:
+		l.active.Add(1)
+		return nil
+	default:
+	}
+
+	queued := l.queued.Add(1)
+	defer l.queued.Add(-1)
+	for {
+		peak := l.peakQueued.Load()
+		if queued <= peak || l.peakQueued.CompareAndSwap(peak, queued) {
+			break
+		}
+	}
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		l.active.Add(1)
+		return nil
+	case <-timer.C:
+		l.rejected.Add(1)
+		return &HasherBusyError{Wait: l.timeout}
+	}
+}
+
+func (l *HashLimiter) release() {
+	l.active.Add(-1)
+	l.completed.Add(1)
+	<-l.slots
+}
+
+// Stats devuelve los contadores actuales de la cola
+func (l *HashLimiter) Stats() HashLimiterStats {
+	return HashLimiterStats{
+		MaxConcurrent: cap(l.slots),
+		Active:        l.active.Load(),
+		Queued:        l.queued.Load(),
+		PeakQueued:    l.peakQueued.Load(),
+		Completed:     l.completed.Load(),
+		Rejected:      l.rejected.Load(),
+	}
+}
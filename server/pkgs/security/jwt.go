@@ -1,31 +1,117 @@
 package security
 
 import (
+	"errors"
 	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var ErrInvalidToken = errors.New("token inválido o expirado")
+
+// JWTService emite y valida los tokens del servidor. La firma y la
+// verificación se delegan en KeyManager (EdDSA o RS256 con kid).
 type JWTService struct {
-	SecretKey string
-	TTL       time.Duration
+	Keys *KeyManager
+	TTL  time.Duration
+}
+
+// SessionClaims son los claims del token de acceso emitido al iniciar sesión
+type SessionClaims struct {
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
+	Scope     string `json:"scope,omitempty"`
+	// Actor identifica al usuario real en una sesión de suplantación (RFC 8693)
+	Actor *ActorClaim `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ActorClaim es el claim "act" de un token emitido en nombre de otro usuario
+type ActorClaim struct {
+	Subject string `json:"sub"`
 }
 
-func NewJWTService(secret string, ttl time.Duration) *JWTService {
+// PurposeClaims son los claims de tokens de un solo propósito (p. ej. desafío 2FA)
+type PurposeClaims struct {
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+func NewJWTService(keys *KeyManager, ttl time.Duration) *JWTService {
 	return &JWTService{
-		SecretKey: secret,
-		TTL:       ttl,
+		Keys: keys,
+		TTL:  ttl,
 	}
 }
 
-func (j *JWTService) GenerateToken(userID string, email string, role string) (string, error) {
-	claims := jwt.MapClaims{
-		"sub":   userID,
-		"email": email,
-		"role":  role,
-		"exp":   time.Now().Add(j.TTL).Unix(),
-		"iat":   time.Now().Unix(),
+// GenerateSessionToken firma un token de acceso ligado a una sesión
+func (j *JWTService) GenerateSessionToken(userID, email, role, sessionID, scope string, expires time.Time) (string, error) {
+	return j.signSession(userID, email, role, sessionID, scope, nil, expires)
+}
+
+// GenerateImpersonationToken firma un token de acceso para userID en el que
+// el claim "act" identifica a quien realmente opera la sesión
+func (j *JWTService) GenerateImpersonationToken(userID, email, role, sessionID, actorID string, expires time.Time) (string, error) {
+	return j.signSession(userID, email, role, sessionID, "", &ActorClaim{Subject: actorID}, expires)
+}
+
+func (j *JWTService) signSession(userID, email, role, sessionID, scope string, actor *ActorClaim, expires time.Time) (string, error) {
+	claims := SessionClaims{
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
+		Scope:     scope,
+		Actor:     actor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.SecretKey))
+	return j.Keys.Sign(claims)
+}
+
+// ParseSessionToken valida firma y expiración del token de acceso
+func (j *JWTService) ParseSessionToken(tokenString string) (*SessionClaims, error) {
+	claims := &SessionClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, j.Keys.Keyfunc,
+		jwt.WithValidMethods(j.Keys.Methods()),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || claims.Subject == "" || claims.SessionID == "" {
+		return nil, ErrInvalidToken
+	}
+	if claims.Actor != nil && claims.Actor.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// GeneratePurposeToken firma un token corto para un único propósito
+func (j *JWTService) GeneratePurposeToken(userID, purpose string, ttl time.Duration) (string, error) {
+	claims := PurposeClaims{
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	}
+
+	return j.Keys.Sign(claims)
+}
+
+// ParsePurposeToken valida el token y que corresponda al propósito esperado
+func (j *JWTService) ParsePurposeToken(tokenString, purpose string) (string, error) {
+	claims := &PurposeClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, j.Keys.Keyfunc,
+		jwt.WithValidMethods(j.Keys.Methods()),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || claims.Purpose != purpose || claims.Subject == "" {
+		return "", ErrInvalidToken
+	}
+	return claims.Subject, nil
 }
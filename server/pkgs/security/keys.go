@@ -0,0 +1,335 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"server/pkgs/logger"
+)
+
+var (
+	ErrNoSigningKeys = errors.New("no hay claves de firma JWT configuradas")
+	ErrUnknownKeyID  = errors.New("kid desconocido o retirado")
+)
+
+const minRSABits = 2048
+
+var keyIDRx = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// activeKeyFile es el archivo de Dir que contiene el kid de la clave que firma
+const activeKeyFile = "active"
+
+// KeyConfig indica de dónde se cargan las claves de firma.
+//
+// Cada archivo <kid>.pem de Dir es una clave privada Ed25519 (EdDSA) o RSA
+// (RS256). Firma la clave cuyo kid figura en el archivo "active" de Dir (si
+// solo hay una clave, el archivo es opcional). La fecha de modificación de
+// los archivos no se usa: copias con cp -p o restauraciones de backup no
+// cambian la clave activa. Al rotar, las claves que verificaban hasta entonces
+// siguen verificando durante Overlap contado desde que el servidor cargó la
+// nueva activa, así los tokens ya emitidos siguen siendo válidos.
+type KeyConfig struct {
+	Dir            string
+	Overlap        time.Duration
+	ReloadInterval time.Duration
+}
+
+// signingKey es una clave cargada desde disco
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// KeyManager firma y verifica todos los tokens emitidos por el servidor
+type KeyManager struct {
+	cfg KeyConfig
+
+	mu     sync.RWMutex
+	active *signingKey
+	keys   map[string]*signingKey
+	// activeSince es cuándo este proceso cargó la clave activa actual y
+	// retiring los kids que siguen verificando hasta activeSince + Overlap
+	activeSince time.Time
+	retiring    map[string]bool
+}
+
+// Keys es el gestor de claves compartido por todo el servidor
+var Keys *KeyManager
+
+// InitKeys carga las claves configuradas y las deja disponibles en Keys. Si
+// ReloadInterval es mayor que 0, vuelve a leer la carpeta periódicamente para
+// aplicar rotaciones sin reiniciar.
+func InitKeys(cfg KeyConfig) error {
+	m, err := NewKeyManager(cfg)
+	if err != nil {
+		return err
+	}
+	Keys = m
+
+	if cfg.ReloadInterval > 0 {
+		go m.watch(cfg.ReloadInterval)
+	}
+	return nil
+}
+
+func NewKeyManager(cfg KeyConfig) (*KeyManager, error) {
+	if strings.TrimSpace(cfg.Dir) == "" {
+		return nil, fmt.Errorf("%w: falta JWT_KEYS_DIR", ErrNoSigningKeys)
+	}
+	m := &KeyManager{cfg: cfg}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload vuelve a leer la carpeta de claves. Si falla, se conservan las
+// claves cargadas anteriormente.
+func (m *KeyManager) Reload() error {
+	paths, err := filepath.Glob(filepath.Join(m.cfg.Dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]*signingKey, len(paths))
+	for _, path := range paths {
+		key, err := loadSigningKey(path)
+		if err != nil {
+			return err
+		}
+		loaded[key.kid] = key
+	}
+	if len(loaded) == 0 {
+		return fmt.Errorf("%w en %s", ErrNoSigningKeys, m.cfg.Dir)
+	}
+
+	activeKID, err := m.readActiveKeyID(loaded)
+	if err != nil {
+		return err
+	}
+	active, ok := loaded[activeKID]
+	if !ok {
+		return fmt.Errorf("%w: la clave activa %q no está en %s", ErrNoSigningKeys, activeKID, m.cfg.Dir)
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case m.active == nil:
+		// Al arrancar no se sabe cuándo se rotó: las demás claves de la
+		// carpeta reciben la ventana completa
+		m.retiring = make(map[string]bool, len(loaded))
+		for kid := range loaded {
+			m.retiring[kid] = true
+		}
+		m.activeSince = now
+	case m.active.kid != active.kid:
+		m.retiring = make(map[string]bool, len(m.keys))
+		for kid := range m.keys {
+			m.retiring[kid] = true
+		}
+		m.activeSince = now
+	}
+
+	keys := map[string]*signingKey{active.kid: active}
+	if now.Before(m.activeSince.Add(m.cfg.Overlap)) {
+		for kid, key := range loaded {
+			if m.retiring[kid] {
+				keys[kid] = key
+			}
+		}
+	}
+
+	m.active = active
+	m.keys = keys
+	return nil
+}
+
+// readActiveKeyID lee el kid del archivo "active". Sin archivo solo se
+// acepta una carpeta con una única clave, para no elegir una al azar.
+func (m *KeyManager) readActiveKeyID(loaded map[string]*signingKey) (string, error) {
+	raw, err := os.ReadFile(filepath.Join(m.cfg.Dir, activeKeyFile))
+	if errors.Is(err, os.ErrNotExist) {
+		if len(loaded) == 1 {
+			for kid := range loaded {
+				return kid, nil
+			}
+		}
+		return "", fmt.Errorf("%w: hay %d claves en %s, indica la activa en el archivo %s", ErrNoSigningKeys, len(loaded), m.cfg.Dir, activeKeyFile)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	kid := strings.TrimSpace(string(raw))
+	if !keyIDRx.MatchString(kid) {
+		return "", fmt.Errorf("el archivo %s contiene un kid inválido %q", activeKeyFile, kid)
+	}
+	return kid, nil
+}
+
+// ActiveKeyID devuelve el kid con el que se firman los tokens nuevos
+func (m *KeyManager) ActiveKeyID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active.kid
+}
+
+// Sign firma los claims con la clave activa e incluye su kid en la cabecera
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	key := m.active
+	m.mu.RUnlock()
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// Keyfunc resuelve la clave pública por el kid del token. El algoritmo del
+// token debe coincidir con el de la clave.
+func (m *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	m.mu.RLock()
+	key, ok := m.keys[kid]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, ErrInvalidToken
+	}
+	return key.public, nil
+}
+
+// Methods son los algoritmos que acepta la verificación
+func (m *KeyManager) Methods() []string {
+	return []string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}
+}
+
+// JWK es una clave pública en formato RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS es el documento publicado en /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS devuelve las claves públicas vigentes: la activa y las que siguen en
+// su ventana de solapamiento
+func (m *KeyManager) JWKS() JWKS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(m.keys))}
+	for _, key := range m.keys {
+		jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.public.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func (m *KeyManager) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		previous := m.ActiveKeyID()
+		if err := m.Reload(); err != nil {
+			logger.Log.Errorf("❌ No se pudieron recargar las claves JWT: %v", err)
+			continue
+		}
+		if current := m.ActiveKeyID(); current != previous {
+			logger.Log.Infof("🔑 Clave de firma JWT rotada: %s → %s", previous, current)
+		}
+	}
+}
+
+// loadSigningKey lee una clave privada PEM (PKCS#8 o PKCS#1). El kid es el
+// nombre del archivo sin extensión.
+func loadSigningKey(path string) (*signingKey, error) {
+	kid := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if !keyIDRx.MatchString(kid) {
+		return nil, fmt.Errorf("nombre de clave inválido %q: usa letras, números, punto, guion o guion bajo", kid)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("la clave %s no está en formato PEM", kid)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("la clave %s tiene un tipo PEM no soportado: %s", kid, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer la clave %s: %w", kid, err)
+	}
+
+	key := &signingKey{kid: kid}
+	switch private := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.method = jwt.SigningMethodEdDSA
+		key.private = private
+		key.public = private.Public()
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("la clave RSA %s debe tener al menos %d bits", kid, minRSABits)
+		}
+		key.method = jwt.SigningMethodRS256
+		key.private = private
+		key.public = &private.PublicKey
+	default:
+		return nil, fmt.Errorf("la clave %s no es Ed25519 ni RSA", kid)
+	}
+	return key, nil
+}
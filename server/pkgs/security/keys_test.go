@@ -0,0 +1,295 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeEd25519Key guarda una clave Ed25519 PKCS#8 como <kid>.pem
+func writeEd25519Key(t *testing.T, dir, kid string) ed25519.PublicKey {
+	t.Helper()
+	pub, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, kid, "PRIVATE KEY", der)
+	return pub
+}
+
+// writeRSAKey guarda una clave RSA PKCS#1 como <kid>.pem
+func writeRSAKey(t *testing.T, dir, kid string) *rsa.PublicKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, minRSABits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, kid, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private))
+	return &private.PublicKey
+}
+
+func writePEM(t *testing.T, dir, kid, blockType string, der []byte) {
+	t.Helper()
+	raw := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setActiveKey(t *testing.T, dir, kid string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, activeKeyFile), []byte(kid+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func signTestToken(t *testing.T, m *KeyManager) string {
+	t.Helper()
+	token, err := m.Sign(jwt.RegisteredClaims{
+		Subject:   "user-1",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func parseTestToken(m *KeyManager, token string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(token, &jwt.RegisteredClaims{}, m.Keyfunc,
+		jwt.WithValidMethods(m.Methods()),
+		jwt.WithExpirationRequired(),
+	)
+}
+
+func TestKeyManagerSignsWithMarkedKeyIgnoringMtime(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "2026-09")
+	writeEd25519Key(t, dir, "2026-10")
+	setActiveKey(t, dir, "2026-09")
+
+	// Una copia con cp -p o un backup restaurado deja fechas arbitrarias
+	future := time.Now().Add(48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "2026-10.pem"), future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewKeyManager(KeyConfig{Dir: dir, Overlap: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.ActiveKeyID(); got != "2026-09" {
+		t.Fatalf("clave activa = %q, se esperaba la del archivo active", got)
+	}
+
+	parsed, err := parseTestToken(m, signTestToken(t, m))
+	if err != nil {
+		t.Fatalf("el token firmado no verifica: %v", err)
+	}
+	if kid := parsed.Header["kid"]; kid != "2026-09" {
+		t.Fatalf("kid = %v, se esperaba 2026-09", kid)
+	}
+}
+
+func TestKeyManagerRequiresMarkerWithSeveralKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "only")
+
+	m, err := NewKeyManager(KeyConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("con una sola clave el archivo active es opcional: %v", err)
+	}
+	if got := m.ActiveKeyID(); got != "only" {
+		t.Fatalf("clave activa = %q", got)
+	}
+
+	writeEd25519Key(t, dir, "other")
+	if _, err := NewKeyManager(KeyConfig{Dir: dir}); !errors.Is(err, ErrNoSigningKeys) {
+		t.Fatalf("sin archivo active y dos claves: err = %v", err)
+	}
+
+	setActiveKey(t, dir, "missing")
+	if _, err := NewKeyManager(KeyConfig{Dir: dir}); !errors.Is(err, ErrNoSigningKeys) {
+		t.Fatalf("kid activo inexistente: err = %v", err)
+	}
+}
+
+func TestKeyManagerRotationKeepsOldKeyDuringOverlap(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "old")
+	writeRSAKey(t, dir, "new")
+	setActiveKey(t, dir, "old")
+
+	m, err := NewKeyManager(KeyConfig{Dir: dir, Overlap: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := signTestToken(t, m)
+
+	setActiveKey(t, dir, "new")
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.ActiveKeyID(); got != "new" {
+		t.Fatalf("clave activa tras rotar = %q", got)
+	}
+
+	after, err := parseTestToken(m, signTestToken(t, m))
+	if err != nil {
+		t.Fatalf("el token nuevo no verifica: %v", err)
+	}
+	if after.Method.Alg() != jwt.SigningMethodRS256.Alg() || after.Header["kid"] != "new" {
+		t.Fatalf("token nuevo firmado con %s/%v", after.Method.Alg(), after.Header["kid"])
+	}
+	if _, err := parseTestToken(m, before); err != nil {
+		t.Fatalf("el token anterior debe verificar durante el solapamiento: %v", err)
+	}
+}
+
+func TestKeyManagerRotationWithoutOverlapRetiresOldKey(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "old")
+	writeEd25519Key(t, dir, "new")
+	setActiveKey(t, dir, "old")
+
+	m, err := NewKeyManager(KeyConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := signTestToken(t, m)
+
+	setActiveKey(t, dir, "new")
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseTestToken(m, before); !errors.Is(err, ErrUnknownKeyID) {
+		t.Fatalf("la clave retirada sigue verificando: err = %v", err)
+	}
+}
+
+func TestKeyfuncResolvesByKidAndPinsAlgorithm(t *testing.T) {
+	dir := t.TempDir()
+	edPub := writeEd25519Key(t, dir, "ed")
+	rsaPub := writeRSAKey(t, dir, "rsa")
+	setActiveKey(t, dir, "ed")
+
+	m, err := NewKeyManager(KeyConfig{Dir: dir, Overlap: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		method  jwt.SigningMethod
+		kid     interface{}
+		wantKey interface{}
+		wantErr error
+	}{
+		{"ed25519 por kid", jwt.SigningMethodEdDSA, "ed", edPub, nil},
+		{"rsa por kid", jwt.SigningMethodRS256, "rsa", rsaPub, nil},
+		{"kid desconocido", jwt.SigningMethodEdDSA, "otro", nil, ErrUnknownKeyID},
+		{"sin kid", jwt.SigningMethodEdDSA, nil, nil, ErrUnknownKeyID},
+		{"algoritmo de otra clave", jwt.SigningMethodRS256, "ed", nil, ErrInvalidToken},
+		{"hmac con kid asimétrico", jwt.SigningMethodHS256, "rsa", nil, ErrInvalidToken},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			token := jwt.New(tc.method)
+			if tc.kid != nil {
+				token.Header["kid"] = tc.kid
+			}
+			key, err := m.Keyfunc(token)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, se esperaba %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			switch want := tc.wantKey.(type) {
+			case ed25519.PublicKey:
+				if got, ok := key.(ed25519.PublicKey); !ok || !got.Equal(want) {
+					t.Fatalf("clave devuelta = %T, no coincide", key)
+				}
+			case *rsa.PublicKey:
+				if got, ok := key.(*rsa.PublicKey); !ok || !got.Equal(want) {
+					t.Fatalf("clave devuelta = %T, no coincide", key)
+				}
+			}
+		})
+	}
+
+	// Un token HS256 firmado con la clave pública como secreto no debe pasar
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	forged.Header["kid"] = "ed"
+	signed, err := forged.SignedString([]byte(edPub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseTestToken(m, signed); err == nil {
+		t.Fatal("se aceptó un token HS256")
+	}
+}
+
+func TestJWKSPublishesVerifyingKeys(t *testing.T) {
+	dir := t.TempDir()
+	edPub := writeEd25519Key(t, dir, "a-ed")
+	rsaPub := writeRSAKey(t, dir, "b-rsa")
+	setActiveKey(t, dir, "a-ed")
+
+	m, err := NewKeyManager(KeyConfig{Dir: dir, Overlap: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := m.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS tiene %d claves, se esperaban 2", len(set.Keys))
+	}
+
+	ed := set.Keys[0]
+	if ed.Kid != "a-ed" || ed.Kty != "OKP" || ed.Crv != "Ed25519" || ed.Alg != "EdDSA" || ed.Use != "sig" {
+		t.Fatalf("JWK Ed25519 inesperado: %+v", ed)
+	}
+	if ed.X != base64.RawURLEncoding.EncodeToString(edPub) || ed.N != "" || ed.E != "" {
+		t.Fatalf("JWK Ed25519 con material incorrecto: %+v", ed)
+	}
+
+	rs := set.Keys[1]
+	if rs.Kid != "b-rsa" || rs.Kty != "RSA" || rs.Alg != "RS256" || rs.Use != "sig" || rs.Crv != "" || rs.X != "" {
+		t.Fatalf("JWK RSA inesperado: %+v", rs)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(rs.N)
+	if err != nil || new(big.Int).SetBytes(n).Cmp(rsaPub.N) != 0 {
+		t.Fatalf("módulo RSA incorrecto: %v", err)
+	}
+	if rs.E != "AQAB" {
+		t.Fatalf("exponente RSA = %q, se esperaba AQAB", rs.E)
+	}
+
+	// Sin solapamiento, tras rotar solo se publica la activa
+	m.cfg.Overlap = 0
+	setActiveKey(t, dir, "b-rsa")
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if set := m.JWKS(); len(set.Keys) != 1 || set.Keys[0].Kid != "b-rsa" {
+		t.Fatalf("JWKS tras rotar = %+v", set.Keys)
+	}
+}
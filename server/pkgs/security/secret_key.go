@@ -0,0 +1,34 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// minSecretKeyBytes es el largo mínimo de una clave HMAC del servidor
+const minSecretKeyBytes = 32
+
+var ErrSecretKeyInvalid = errors.New("la clave secreta debe ser de al menos 32 bytes en base64")
+
+// SecretKey es una clave del servidor para HMAC-SHA256. Se guarda fuera de la
+// base de datos: quien solo lea las tablas no puede recalcular los hashes.
+type SecretKey []byte
+
+// ParseSecretKey decodifica una clave en base64 de al menos 32 bytes
+func ParseSecretKey(encoded string) (SecretKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) < minSecretKeyBytes {
+		return nil, ErrSecretKeyInvalid
+	}
+	return SecretKey(raw), nil
+}
+
+// Sum devuelve HMAC-SHA256 del mensaje en hexadecimal
+func (k SecretKey) Sum(message string) string {
+	mac := hmac.New(sha256.New, k)
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
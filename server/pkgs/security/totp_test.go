@@ -0,0 +1,91 @@
+package security
+
+import (
+	"encoding/base32"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Semilla SHA-1 de los vectores del RFC 6238, Apéndice B
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	// El RFC publica códigos de 8 dígitos; los de 6 son sus últimos 6
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		got, err := TOTPCode(rfc6238Secret, time.Unix(v.unix, 0))
+		if err != nil {
+			t.Fatalf("T=%d: %v", v.unix, err)
+		}
+		if got != v.code {
+			t.Errorf("T=%d: código %s, se esperaba %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestVerifyTOTPSkewWindow(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / totpPeriod
+
+	for offset := int64(-2); offset <= 2; offset++ {
+		code, err := TOTPCode(rfc6238Secret, now.Add(time.Duration(offset*totpPeriod)*time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		step, err := VerifyTOTP(rfc6238Secret, code, now, 1)
+		inWindow := offset >= -1 && offset <= 1
+		switch {
+		case inWindow && err != nil:
+			t.Errorf("desfase %d dentro de la ventana: %v", offset, err)
+		case inWindow && step != current+offset:
+			t.Errorf("desfase %d: paso %d, se esperaba %d", offset, step, current+offset)
+		case !inWindow && !errors.Is(err, ErrInvalidTOTPCode):
+			t.Errorf("desfase %d fuera de la ventana debe rechazarse, se obtuvo %v", offset, err)
+		}
+	}
+}
+
+func TestVerifyTOTPStepDetectsReuse(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, err := TOTPCode(rfc6238Secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := VerifyTOTP(rfc6238Secret, code, now, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Dentro de la ventana el mismo código vuelve a coincidir con el mismo
+	// paso: el llamador lo rechaza comparándolo con el último paso usado
+	second, err := VerifyTOTP(rfc6238Secret, code, now.Add(totpPeriod*time.Second), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != first {
+		t.Fatalf("el código reutilizado debe devolver el mismo paso: %d y %d", first, second)
+	}
+}
+
+func TestVerifyTOTPRejectsMalformedInput(t *testing.T) {
+	now := time.Unix(59, 0)
+	for _, code := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, err := VerifyTOTP(rfc6238Secret, code, now, 1); !errors.Is(err, ErrInvalidTOTPCode) {
+			t.Errorf("código %q: se esperaba ErrInvalidTOTPCode, se obtuvo %v", code, err)
+		}
+	}
+	if _, err := VerifyTOTP("no-es-base32!", "123456", now, 1); !errors.Is(err, ErrInvalidTOTPSecret) {
+		t.Errorf("se esperaba ErrInvalidTOTPSecret, se obtuvo %v", err)
+	}
+}
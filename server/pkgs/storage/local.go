@@ -0,0 +1,82 @@
+// server/pkgs/storage/local.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage guarda los archivos en disco; el servidor los publica con
+// un handler estático bajo PublicURL.
+type LocalStorage struct {
+	dir       string
+	publicURL string
+}
+
+func NewLocalStorage(dir, publicURL string) (*LocalStorage, error) {
+	if dir == "" {
+		dir = "uploads"
+	}
+	if publicURL == "" {
+		publicURL = "/media"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{dir: dir, publicURL: strings.TrimRight(publicURL, "/")}, nil
+}
+
+// Dir devuelve la carpeta base, para registrar el handler estático
+func (s *LocalStorage) Dir() string {
+	return s.dir
+}
+
+// PublicURL devuelve el prefijo público de los archivos
+func (s *LocalStorage) PublicURL() string {
+	return s.publicURL
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	// Se escribe en un temporal y se renombra para no servir archivos a medias
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.publicURL + "/" + key
+}
+
+// path rechaza claves que intenten salir de la carpeta base
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || clean != "/"+key {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
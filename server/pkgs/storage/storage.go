@@ -0,0 +1,55 @@
+// server/pkgs/storage/storage.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownDriver   = errors.New("backend de almacenamiento desconocido")
+	ErrInvalidKey      = errors.New("clave de archivo inválida")
+	ErrStorageDisabled = errors.New("el almacenamiento de archivos no está inicializado")
+)
+
+// Storage guarda archivos bajo una clave ("avatars/<id>/128.png") y
+// devuelve la URL pública con la que el cliente los descarga.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// Config agrupa la configuración del almacenamiento
+type Config struct {
+	// Driver: "local" (por ahora el único disponible)
+	Driver string
+
+	// LocalDir es la carpeta base del backend local
+	LocalDir string
+
+	// PublicURL es el prefijo con el que se sirven los archivos
+	PublicURL string
+}
+
+var Files Storage
+
+// InitStorage crea el backend configurado y lo deja disponible en Files
+func InitStorage(cfg Config) error {
+	s, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	Files = s
+	return nil
+}
+
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case "local", "":
+		return NewLocalStorage(cfg.LocalDir, cfg.PublicURL)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, cfg.Driver)
+	}
+}